@@ -0,0 +1,339 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Expert21/argus/configs"
+	"github.com/Expert21/argus/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// configUsage lists the `argus config` subcommands.
+const configUsage = `Usage: argus config <command> [flags]
+
+Commands:
+  init            Write the default config to the user config path
+  validate        Check a config file and report every problem
+  show            Print the effective config with defaults applied
+  add-source      Add a log source
+  remove-source   Remove a log source by name
+  enable          Enable a log source by name
+  disable         Disable a log source by name
+
+Every command accepts -config PATH to operate on a specific file.
+Commands that write the file refuse to run as root or through sudo.`
+
+// runConfig dispatches `argus config <command>`.
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, configUsage)
+		return errors.New("config: missing command")
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "init":
+		return configInit(args)
+	case "validate":
+		return configValidate(args)
+	case "show":
+		return configShow(args)
+	case "add-source":
+		return configAddSource(args)
+	case "remove-source":
+		return configRemoveSource(args)
+	case "enable":
+		return configSetEnabled(args, true)
+	case "disable":
+		return configSetEnabled(args, false)
+	case "help", "-h", "-help", "--help":
+		fmt.Println(configUsage)
+		return nil
+	default:
+		fmt.Fprintln(os.Stderr, configUsage)
+		return fmt.Errorf("config: unknown command %q", cmd)
+	}
+}
+
+// newConfigFlags creates a flag set with the shared -config flag.
+func newConfigFlags(name, usage string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("argus config "+name, flag.ContinueOnError)
+	path := fs.String("config", "", "path to config file (default ~/.config/argus/config.yaml)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: argus config %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs, path
+}
+
+// geteuid is os.Geteuid; tests replace it.
+var geteuid = os.Geteuid
+
+// refuseElevated stops config writes made as root. The secure install
+// lets argus-users run argus as root without a password, so a write
+// through it would let -config overwrite any file on the system.
+func refuseElevated() error {
+	if os.Getenv("SUDO_UID") != "" || geteuid() == 0 {
+		return errors.New("config: refusing to write config files as root; run argus config without sudo")
+	}
+	return nil
+}
+
+// resolveConfigPath returns the explicit path, or the default user path.
+func resolveConfigPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	return config.ConfigPath()
+}
+
+// configInit writes the embedded default config to disk.
+func configInit(args []string) error {
+	fs, pathFlag := newConfigFlags("init", "init [-config PATH] [-force]")
+	force := fs.Bool("force", false, "overwrite an existing config file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := refuseElevated(); err != nil {
+		return err
+	}
+
+	path, err := resolveConfigPath(*pathFlag)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil && !*force {
+		return fmt.Errorf("config init: %s already exists (use -force to overwrite)", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("config init: failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, configs.DefaultYAML, 0644); err != nil {
+		return fmt.Errorf("config init: failed to write config: %w", err)
+	}
+
+	fmt.Printf("Wrote default config to %s\n", path)
+	return nil
+}
+
+// configValidate loads a config file and reports every problem found.
+func configValidate(args []string) error {
+	fs, pathFlag := newConfigFlags("validate", "validate [-config PATH | PATH]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	path := *pathFlag
+	if path == "" && fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	path, err := resolveConfigPath(path)
+	if err != nil {
+		return err
+	}
+
+	// LoadFrom falls back to defaults for a missing file; here that's an error
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("config validate: %w", err)
+	}
+
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		return fmt.Errorf("config validate: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		problems := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			problems = joined.Unwrap()
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d problem(s)\n", path, len(problems))
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "  - %v\n", p)
+		}
+		return errors.New("config validate: invalid config")
+	}
+
+	fmt.Printf("%s: OK (%d sources, %d enabled)\n", path, len(cfg.Sources), len(cfg.EnabledSources()))
+	return nil
+}
+
+// configShow prints the effective configuration with defaults applied.
+func configShow(args []string) error {
+	fs, pathFlag := newConfigFlags("show", "show [-config PATH]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	path, err := resolveConfigPath(*pathFlag)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		fmt.Printf("# Effective config (from %s, defaults applied)\n", path)
+	} else {
+		fmt.Printf("# Effective config (%s not found, built-in defaults)\n", path)
+	}
+	return writeYAML(os.Stdout, cfg)
+}
+
+// configAddSource appends a new source and saves the file.
+func configAddSource(args []string) error {
+	fs, pathFlag := newConfigFlags("add-source", "add-source -name NAME -type TYPE [flags]")
+	name := fs.String("name", "", "source name (required)")
+	sourceType := fs.String("type", "", "source type (required)")
+	path := fs.String("path", "", "file, directory or named pipe path")
+	glob := fs.String("glob", "", "glob pattern for directory sources")
+	listen := fs.String("listen", "", "address syslog sources listen on (udp://0.0.0.0:514)")
+	parsers := fs.String("parser", "", "comma-separated parser chain for line sources (e.g. json,syslog)")
+	timezone := fs.String("timezone", "", "time zone of timestamps written without one (e.g. UTC)")
+	multiline := fs.String("multiline", "", "join stack traces into one entry: java, python or go")
+	priority := fs.Int("priority", -1, "minimum journald priority 0-7 (-1 = all)")
+	disabled := fs.Bool("disabled", false, "add the source disabled")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *name == "" || *sourceType == "" {
+		return errors.New("config add-source: -name and -type are required")
+	}
+
+	return updateConfig(*pathFlag, func(doc *config.Document) error {
+		src := config.SourceConfig{
+			Name:     *name,
+			Type:     *sourceType,
+			Path:     *path,
+			Glob:     *glob,
+			Listen:   *listen,
+			Timezone: *timezone,
+			Enabled:  !*disabled,
+		}
+		if *priority >= 0 {
+			src.Priority = priority
+		}
+		if *multiline != "" {
+			src.Multiline = &config.MultilineConfig{Preset: *multiline}
+		}
+		if *parsers != "" {
+			for _, name := range strings.Split(*parsers, ",") {
+				src.Parser = append(src.Parser, config.ParserConfig{Type: strings.TrimSpace(name)})
+			}
+		}
+
+		return doc.AddSource(src)
+	}, fmt.Sprintf("Added source %q", *name))
+}
+
+// configRemoveSource removes a source by name and saves the file.
+func configRemoveSource(args []string) error {
+	fs, pathFlag := newConfigFlags("remove-source", "remove-source [-config PATH] NAME")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("config remove-source: expected exactly one source name")
+	}
+	name := fs.Arg(0)
+
+	return updateConfig(*pathFlag, func(doc *config.Document) error {
+		if !doc.RemoveSource(name) {
+			return fmt.Errorf("no source named %q", name)
+		}
+		return nil
+	}, fmt.Sprintf("Removed source %q", name))
+}
+
+// configSetEnabled enables or disables a source by name and saves the file.
+func configSetEnabled(args []string, enabled bool) error {
+	verb := "disable"
+	if enabled {
+		verb = "enable"
+	}
+
+	fs, pathFlag := newConfigFlags(verb, verb+" [-config PATH] NAME")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("config %s: expected exactly one source name", verb)
+	}
+	name := fs.Arg(0)
+
+	return updateConfig(*pathFlag, func(doc *config.Document) error {
+		if !doc.SetEnabled(name, enabled) {
+			return fmt.Errorf("no source named %q", name)
+		}
+		return nil
+	}, fmt.Sprintf("%sd source %q", capitalize(verb), name))
+}
+
+// updateConfig opens the config file, applies change, validates the
+// result and saves it. Nothing is written if the change leaves the config
+// invalid. The file is edited in place, so its comments and layout
+// survive.
+func updateConfig(pathFlag string, change func(*config.Document) error, done string) error {
+	if err := refuseElevated(); err != nil {
+		return err
+	}
+
+	path, err := resolveConfigPath(pathFlag)
+	if err != nil {
+		return err
+	}
+
+	doc, err := config.LoadDocument(path)
+	if err != nil {
+		return err
+	}
+
+	if err := change(doc); err != nil {
+		return err
+	}
+
+	cfg, err := doc.Config()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("refusing to save invalid config:\n%w", err)
+	}
+
+	if err := doc.SaveTo(path); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s in %s\n", done, path)
+	return nil
+}
+
+// writeYAML encodes v as YAML with two-space indentation.
+func writeYAML(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// capitalize upper-cases the first ASCII letter of s.
+func capitalize(s string) string {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return s
+	}
+	return string(s[0]-'a'+'A') + s[1:]
+}
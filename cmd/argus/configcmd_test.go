@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Expert21/argus/internal/config"
+)
+
+// asUser makes the config commands see an unprivileged caller.
+func asUser(t *testing.T) {
+	t.Helper()
+	t.Setenv("SUDO_UID", "")
+	saved := geteuid
+	geteuid = func() int { return 1000 }
+	t.Cleanup(func() { geteuid = saved })
+}
+
+// TestConfigRefusesRoot tests that commands writing the config refuse to
+// run as root or through sudo, and leave the target alone.
+func TestConfigRefusesRoot(t *testing.T) {
+	tests := []struct {
+		name    string
+		sudoUID string
+		euid    int
+	}{
+		{"sudo", "1000", 0},
+		{"root", "", 0},
+		{"sudo to another user", "1000", 1001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asUser(t)
+			t.Setenv("SUDO_UID", tt.sudoUID)
+			geteuid = func() int { return tt.euid }
+
+			target := filepath.Join(t.TempDir(), "passwd")
+			if err := os.WriteFile(target, []byte("root:x:0:0::/root:/bin/sh\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, args := range [][]string{
+				{"init", "-force", "-config", target},
+				{"add-source", "-config", target, "-name", "x", "-type", "stdin"},
+				{"remove-source", "-config", target, "x"},
+				{"enable", "-config", target, "x"},
+				{"disable", "-config", target, "x"},
+			} {
+				err := runConfig(args)
+				if err == nil || !strings.Contains(err.Error(), "as root") {
+					t.Errorf("config %s error = %v, want a refusal", args[0], err)
+				}
+			}
+			if data, _ := os.ReadFile(target); string(data) != "root:x:0:0::/root:/bin/sh\n" {
+				t.Errorf("target was rewritten: %q", data)
+			}
+		})
+	}
+}
+
+// TestConfigRoundTrip tests add-source, disable, enable and
+// remove-source on the file config init writes: each change lands, and
+// the template's comments survive every save.
+func TestConfigRoundTrip(t *testing.T) {
+	asUser(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	comment := "# Authentication log - SSH, sudo, login attempts"
+
+	steps := []struct {
+		args  []string
+		check func(cfg *config.Config) bool
+	}{
+		{[]string{"init", "-config", path}, func(cfg *config.Config) bool {
+			return cfg.GetSource("System Journal") != nil
+		}},
+		{[]string{"add-source", "-config", path, "-name", "App", "-type", "file", "-path", "/var/log/app.log", "-parser", "json,syslog", "-multiline", "java"}, func(cfg *config.Config) bool {
+			src := cfg.GetSource("App")
+			return src != nil && src.Enabled && src.Path == "/var/log/app.log" && len(src.Parser) == 2 && src.Multiline != nil
+		}},
+		{[]string{"disable", "-config", path, "App"}, func(cfg *config.Config) bool {
+			return !cfg.GetSource("App").Enabled
+		}},
+		{[]string{"enable", "-config", path, "App"}, func(cfg *config.Config) bool {
+			return cfg.GetSource("App").Enabled
+		}},
+		{[]string{"disable", "-config", path, "System Journal"}, func(cfg *config.Config) bool {
+			return !cfg.GetSource("System Journal").Enabled && cfg.GetSource("App").Enabled
+		}},
+		{[]string{"remove-source", "-config", path, "App"}, func(cfg *config.Config) bool {
+			return cfg.GetSource("App") == nil && cfg.GetSource("System Journal") != nil
+		}},
+	}
+
+	for _, step := range steps {
+		if err := runConfig(step.args); err != nil {
+			t.Fatalf("config %s error: %v", strings.Join(step.args, " "), err)
+		}
+		cfg, err := config.LoadFrom(path)
+		if err != nil {
+			t.Fatalf("after %s: LoadFrom() error: %v", step.args[0], err)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("after %s: Validate() error: %v", step.args[0], err)
+		}
+		if !step.check(cfg) {
+			t.Errorf("after %s: sources = %+v", strings.Join(step.args, " "), cfg.Sources)
+		}
+		if data, _ := os.ReadFile(path); !strings.Contains(string(data), comment) {
+			t.Errorf("after %s: the template's comments are gone", step.args[0])
+		}
+	}
+}
+
+// TestConfigEditErrors tests that a failed edit leaves the file as it was.
+func TestConfigEditErrors(t *testing.T) {
+	asUser(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := runConfig([]string{"init", "-config", path}); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(path)
+
+	for _, args := range [][]string{
+		{"add-source", "-config", path, "-name", "System Journal", "-type", "journald"},
+		{"add-source", "-config", path, "-name", "Bad", "-type", "file"},
+		{"remove-source", "-config", path, "Nonexistent"},
+		{"enable", "-config", path, "Nonexistent"},
+	} {
+		if err := runConfig(args); err == nil {
+			t.Errorf("config %s succeeded, want an error", strings.Join(args, " "))
+		}
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Error("a failed edit changed the file")
+	}
+}
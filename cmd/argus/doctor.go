@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Expert21/argus/internal/config"
+	"github.com/Expert21/argus/internal/doctor"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+)
+
+// runDoctor checks permissions and environment for every configured source.
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("argus doctor", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to config file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: argus doctor [-config PATH]")
+		fmt.Fprintln(fs.Output(), "\nDiagnose why log sources can't be read and suggest fixes.")
+		fmt.Fprintln(fs.Output(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Don't validate: doctor must still run on a broken config
+	path, err := resolveConfigPath(*configPath)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		return err
+	}
+
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+
+	failures := 0
+	for _, r := range doctor.New(cfg).Run() {
+		fmt.Printf("%s %s: %s\n", statusMark(r.Status), r.Check, r.Detail)
+		if r.Fix != "" && r.Status != doctor.StatusOK {
+			fmt.Printf("    fix: %s\n", r.Fix)
+		}
+		if r.Status == doctor.StatusFail {
+			failures++
+		}
+	}
+
+	if failures > 0 {
+		return errors.New(pluralize(failures, "check") + " failed")
+	}
+	return nil
+}
+
+// statusMark renders a colored symbol for a check status.
+func statusMark(s doctor.Status) string {
+	style := lipgloss.NewStyle().Bold(true)
+	switch s {
+	case doctor.StatusOK:
+		return style.Foreground(lipgloss.Color("#3fb950")).Render("✓")
+	case doctor.StatusSkip:
+		return style.Foreground(lipgloss.Color("#8b949e")).Render("-")
+	case doctor.StatusWarn:
+		return style.Foreground(lipgloss.Color("#d29922")).Render("!")
+	default:
+		return style.Foreground(lipgloss.Color("#f85149")).Render("✗")
+	}
+}
+
+// pluralize returns "1 check" or "3 checks".
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,208 @@
+// Command argus is a terminal log viewer that merges the systemd journal
+// and plain-text log files into a single live stream.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Expert21/argus/internal/aggregate"
+	"github.com/Expert21/argus/internal/config"
+	"github.com/Expert21/argus/internal/ingest"
+	"github.com/Expert21/argus/internal/state"
+	"github.com/Expert21/argus/internal/tui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "argus: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches to a subcommand, or starts the TUI when none is given.
+func run(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "tail":
+			return runTail(args[1:])
+		case "config":
+			return runConfig(args[1:])
+		case "doctor":
+			return runDoctor(args[1:])
+		}
+	}
+	return runTUI(args)
+}
+
+// runTUI parses flags and starts the interactive TUI.
+func runTUI(args []string) error {
+	fs := flag.NewFlagSet("argus", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to config file (default ~/.config/argus/config.yaml)")
+	sinceLastRun := fs.Bool("since-last-run", false, "catch up on everything logged since argus last ran")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: argus [flags]")
+		fmt.Fprintln(fs.Output(), "       argus [flags] -          (view lines piped to standard input)")
+		fmt.Fprintln(fs.Output(), "       argus <command> [flags]")
+		fmt.Fprintln(fs.Output(), "\nCommands:")
+		fmt.Fprintln(fs.Output(), "  tail      Stream the merged log feed to stdout")
+		fmt.Fprintln(fs.Output(), "  config    Manage the config file (init, validate, show, ...)")
+		fmt.Fprintln(fs.Output(), "  doctor    Diagnose permission and environment problems")
+		fmt.Fprintln(fs.Output(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	pipe := fs.NArg() == 1 && fs.Arg(0) == "-"
+	if fs.NArg() > 0 && !pipe {
+		return fmt.Errorf("unexpected argument %q (see argus -h)", fs.Arg(0))
+	}
+
+	load := func() (*config.Config, error) {
+		cfg, err := loadConfig(*configPath)
+		if err != nil {
+			return nil, err
+		}
+		if pipe {
+			// `argus -` views the piped lines alone
+			cfg.Sources = []config.SourceConfig{{Name: "stdin", Type: "stdin", Enabled: true}}
+		}
+		if *sinceLastRun {
+			resumeAll(cfg.Sources)
+		}
+		return cfg, nil
+	}
+
+	cfg, err := load()
+	if err != nil {
+		return err
+	}
+
+	// Positions are flushed after the sources stop (defers run in reverse)
+	store := openState()
+	if store != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		go store.FlushEvery(ctx, state.FlushInterval)
+		defer store.Flush()
+		defer cancel()
+	}
+
+	agg := aggregate.NewAggregator(cfg.General.MaxBuffer)
+	agg.Start()
+	defer agg.Stop()
+
+	// Subscribe before any source starts so the first entries aren't lost
+	sub := agg.Subscribe("tui")
+
+	startErrs := startSources(agg, cfg, store, true)
+
+	// current is the config the running sources were started from; the
+	// reload and boot picker commands replace it from their own goroutines
+	var mu sync.Mutex
+	current := cfg
+
+	reload := func() (*config.Config, error) {
+		// Standard input can't be read again from the start
+		if readsStdin(cfg) {
+			return nil, errors.New("reload isn't available while reading standard input")
+		}
+		newCfg, err := load()
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		current = newCfg
+		stopSources(agg)
+		if errs := startSources(agg, newCfg, store, true); len(errs) > 0 {
+			return newCfg, errs[0]
+		}
+		return newCfg, nil
+	}
+
+	app := tui.NewApp(cfg, agg, sub, reload)
+	app.SetBootPicker(func() ([]ingest.Boot, error) {
+		mu.Lock()
+		cfg := current
+		mu.Unlock()
+		return listBoots(context.Background(), cfg)
+	}, func(boot ingest.Boot) (*aggregate.Subscriber, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if readsStdin(current) {
+			return nil, errors.New("switching boots isn't available while reading standard input")
+		}
+		current = withBoot(current, boot.ID)
+		stopSources(agg)
+		// Start the new boot on an empty history and a fresh subscription,
+		// so nothing of the old boot reaches the view after it is cleared
+		agg.Clear()
+		agg.Unsubscribe("tui")
+		sub := agg.Subscribe("tui")
+		// Journal positions inside an earlier boot aren't worth resuming from
+		if errs := startSources(agg, current, store, boot.Index == 0); len(errs) > 0 {
+			return sub, errs[0]
+		}
+		return sub, nil
+	})
+	switch {
+	case len(startErrs) > 0:
+		app.SetStatus(fmt.Sprintf("⚠ %v", startErrs[0]))
+	case *sinceLastRun && store != nil && !store.LastRun().IsZero():
+		app.SetStatus(fmt.Sprintf("Catching up since last run (%s)", store.LastRun().Format("Jan 2 15:04")))
+	default:
+		app.SetStatus(fmt.Sprintf("Watching %d sources", len(agg.GetSources())))
+	}
+
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if readsStdin(cfg) {
+		// Standard input carries logs; keys come from the terminal
+		opts = append(opts, tea.WithInputTTY())
+	}
+	program := tea.NewProgram(app, opts...)
+	if _, err := program.Run(); err != nil {
+		return fmt.Errorf("tui: %w", err)
+	}
+	return nil
+}
+
+// readsStdin reports whether an enabled source reads standard input.
+func readsStdin(cfg *config.Config) bool {
+	for _, s := range cfg.EnabledSources() {
+		if s.Type == "stdin" {
+			return true
+		}
+	}
+	return false
+}
+
+// loadConfig loads the config from path, or the default location if empty,
+// and validates it.
+func loadConfig(path string) (*config.Config, error) {
+	var (
+		cfg *config.Config
+		err error
+	)
+	if path != "" {
+		cfg, err = config.LoadFrom(path)
+	} else {
+		cfg, err = config.Load()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
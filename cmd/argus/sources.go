@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Expert21/argus/internal/aggregate"
+	"github.com/Expert21/argus/internal/config"
+	"github.com/Expert21/argus/internal/ingest"
+	"github.com/Expert21/argus/internal/state"
+)
+
+// startSources adds every enabled source to the aggregator.
+// A source that fails to start stays registered (shown unhealthy in the
+// sidebar) and its error is returned so the caller can report it.
+// Sources that can record their position do so in store, if not nil;
+// journald sources only if trackJournal is set.
+func startSources(agg *aggregate.Aggregator, cfg *config.Config, store *state.Store, trackJournal bool) []error {
+	var errs []error
+	for _, src := range cfg.EnabledSources() {
+		ing, err := src.NewIngestor()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %q: %w", src.Name, err))
+			continue
+		}
+		if src.Type != "journald" || trackJournal {
+			trackPosition(ing, store)
+		}
+		if err := agg.AddSource(ing); err != nil {
+			errs = append(errs, fmt.Errorf("source %q: %w", src.Name, err))
+		}
+	}
+	return errs
+}
+
+// trackPosition hands the store to sources that can record a position.
+func trackPosition(ing ingest.Ingestor, store *state.Store) {
+	if t, ok := ing.(ingest.PositionTracker); ok && store != nil {
+		t.TrackPosition(store)
+	}
+}
+
+// stopSources stops and removes every source from the aggregator.
+func stopSources(agg *aggregate.Aggregator) {
+	for _, name := range agg.GetSources() {
+		agg.RemoveSource(name)
+	}
+}
+
+// openState opens the saved source positions. Argus works without them,
+// it just can't resume, so failures are only reported.
+func openState() *state.Store {
+	store, err := state.OpenDefault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "argus: %v (read positions won't be saved)\n", err)
+		return nil
+	}
+	return store
+}
+
+// resumeAll makes every source catch up on what was logged since the
+// last run, as if each had resume: true.
+func resumeAll(sources []config.SourceConfig) {
+	for i := range sources {
+		sources[i].Resume = true
+	}
+}
+
+// listBoots lists the boots of the first enabled journald source.
+func listBoots(ctx context.Context, cfg *config.Config) ([]ingest.Boot, error) {
+	for _, src := range cfg.EnabledSources() {
+		if src.Type != "journald" {
+			continue
+		}
+		ing, err := src.NewIngestor()
+		if err != nil {
+			return nil, err
+		}
+		if lister, ok := ing.(ingest.BootLister); ok {
+			return lister.ListBoots(ctx)
+		}
+	}
+	return nil, errors.New("no journald source")
+}
+
+// withBoot returns a copy of cfg with every journald source showing the
+// whole of one boot instead of its configured starting point.
+func withBoot(cfg *config.Config, id string) *config.Config {
+	switched := *cfg
+	switched.Sources = append([]config.SourceConfig(nil), cfg.Sources...)
+	for i := range switched.Sources {
+		src := &switched.Sources[i]
+		if src.Type == "journald" {
+			src.Boot = id
+			src.Since = ""
+			src.Resume = false
+		}
+	}
+	return &switched
+}
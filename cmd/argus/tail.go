@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/Expert21/argus/internal/aggregate"
+	"github.com/Expert21/argus/internal/config"
+	"github.com/Expert21/argus/internal/ingest"
+	"github.com/Expert21/argus/internal/state"
+	"github.com/Expert21/argus/internal/tui"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+)
+
+// tailOptions holds the parsed flags for `argus tail`.
+type tailOptions struct {
+	configPath string
+	sources    []string
+	minLevel   ingest.LogLevel
+	json       bool
+	color      string
+	follow     bool
+	since      string
+	lines      int
+	resume     bool
+}
+
+// runTail streams the merged log feed to stdout without the TUI.
+func runTail(args []string) error {
+	opts, err := parseTailFlags(args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+
+	selected, err := selectSources(cfg, opts.sources)
+	if err != nil {
+		return err
+	}
+	if opts.resume {
+		resumeAll(selected)
+	}
+	for i := range selected {
+		selected[i].SetHistory(opts.since, opts.lines)
+	}
+
+	// Writes to a closed pipe return EPIPE instead of killing the process,
+	// so `argus tail | head` exits cleanly.
+	signal.Ignore(syscall.SIGPIPE)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	out := bufio.NewWriter(os.Stdout)
+	write := newEntryWriter(cfg, opts, out)
+
+	if !opts.follow {
+		return runQuery(ctx, selected, opts, out, write)
+	}
+
+	store := openState()
+	if store != nil {
+		go store.FlushEvery(ctx, state.FlushInterval)
+		defer store.Flush()
+	}
+
+	agg := aggregate.NewAggregator(cfg.General.MaxBuffer)
+	agg.Start()
+	defer agg.Stop()
+
+	sub := agg.Subscribe("tail")
+
+	for _, src := range selected {
+		ing, err := src.NewIngestor()
+		if err == nil {
+			trackPosition(ing, store)
+			err = agg.AddSource(ing)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "argus: source %q: %v\n", src.Name, err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ignorePipe(out.Flush())
+
+		case entry, ok := <-sub.Ch:
+			if !ok {
+				return ignorePipe(out.Flush())
+			}
+			if !opts.wants(entry) {
+				continue
+			}
+			if err := write(entry); err != nil {
+				return ignorePipe(err)
+			}
+			// Flush once the burst is drained so pipes see lines promptly
+			if len(sub.Ch) == 0 {
+				if err := out.Flush(); err != nil {
+					return ignorePipe(err)
+				}
+			}
+		}
+	}
+}
+
+// runQuery prints the existing history of the selected sources, merged
+// into timestamp order, and exits.
+func runQuery(ctx context.Context, selected []config.SourceConfig, opts *tailOptions, out *bufio.Writer, write entryWriter) error {
+	var history []ingest.LogEntry
+	for _, src := range selected {
+		ing, err := src.NewIngestor()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "argus: source %q: %v\n", src.Name, err)
+			continue
+		}
+		reader, ok := ing.(ingest.HistoryReader)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "argus: source %q: %s sources can only be followed\n", src.Name, src.Type)
+			continue
+		}
+
+		ch := make(chan ingest.LogEntry, 256)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- reader.ReadHistory(ctx, ch)
+			close(ch)
+		}()
+		for entry := range ch {
+			if opts.wants(entry) {
+				history = append(history, entry)
+			}
+		}
+		if err := <-errc; err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "argus: source %q: %v\n", src.Name, err)
+		}
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Timestamp.Before(history[j].Timestamp)
+	})
+	for _, entry := range history {
+		if err := write(entry); err != nil {
+			return ignorePipe(err)
+		}
+	}
+	return ignorePipe(out.Flush())
+}
+
+// parseTailFlags parses the `argus tail` command line.
+func parseTailFlags(args []string) (*tailOptions, error) {
+	fs := flag.NewFlagSet("argus tail", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to config file")
+	source := fs.String("source", "", "comma-separated source names to stream (default: all enabled)")
+	level := fs.String("level", "", "minimum level to print (debug, info, notice, warn, error, crit, alert, emerg); entries without a level are left out")
+	asJSON := fs.Bool("json", false, "print entries as JSON lines")
+	color := fs.String("color", "auto", "colorize output: auto, always, or never")
+	follow := fs.Bool("follow", true, "keep streaming new entries; -follow=false prints history and exits")
+	since := fs.String("since", "", "start with history newer than a duration (2h) or timestamp")
+	lines := fs.Int("n", 0, "start with the last `N` lines of each source")
+	sinceLastRun := fs.Bool("since-last-run", false, "start with everything logged since argus last ran")
+
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: argus tail [flags]")
+		fmt.Fprintln(fs.Output(), "\nStream the merged log feed to stdout, or with -follow=false print")
+		fmt.Fprintln(fs.Output(), "the history of the journal and file sources and exit.")
+		fmt.Fprintln(fs.Output(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("tail: unexpected argument %q", fs.Arg(0))
+	}
+
+	opts := &tailOptions{
+		configPath: *configPath,
+		json:       *asJSON,
+		color:      *color,
+		follow:     *follow,
+		since:      *since,
+		lines:      *lines,
+		resume:     *sinceLastRun,
+	}
+
+	if opts.lines < 0 {
+		return nil, fmt.Errorf("tail: -n must not be negative")
+	}
+
+	if *source != "" {
+		for _, name := range strings.Split(*source, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				opts.sources = append(opts.sources, name)
+			}
+		}
+	}
+
+	if *level != "" {
+		lvl, err := ingest.ParseLevel(*level)
+		if err != nil {
+			return nil, fmt.Errorf("tail: %w", err)
+		}
+		opts.minLevel = lvl
+	}
+
+	switch opts.color {
+	case "auto", "always", "never":
+	default:
+		return nil, fmt.Errorf("tail: -color must be auto, always, or never")
+	}
+
+	return opts, nil
+}
+
+// wants reports whether an entry passes the -level filter. Once a level
+// is given, entries whose level is unknown are left out, as journalctl -p
+// leaves out entries without a priority.
+func (o *tailOptions) wants(entry ingest.LogEntry) bool {
+	return entry.Level >= o.minLevel
+}
+
+// selectSources picks the sources named on the command line, or every
+// enabled source when none were named. Named sources run even if disabled.
+func selectSources(cfg *config.Config, names []string) ([]config.SourceConfig, error) {
+	if len(names) == 0 {
+		return cfg.EnabledSources(), nil
+	}
+
+	selected := make([]config.SourceConfig, 0, len(names))
+	for _, name := range names {
+		src := cfg.GetSource(name)
+		if src == nil {
+			return nil, fmt.Errorf("no source named %q in config", name)
+		}
+		selected = append(selected, *src)
+	}
+	return selected, nil
+}
+
+// entryWriter writes a single entry to the output.
+type entryWriter func(entry ingest.LogEntry) error
+
+// newEntryWriter returns a JSON or formatted-text writer to w for the
+// options.
+func newEntryWriter(cfg *config.Config, opts *tailOptions, w io.Writer) entryWriter {
+	if opts.json {
+		enc := json.NewEncoder(w)
+		return func(entry ingest.LogEntry) error {
+			return enc.Encode(entry)
+		}
+	}
+
+	isTTY := term.IsTerminal(os.Stdout.Fd())
+	switch {
+	case opts.color == "never" || (opts.color == "auto" && !isTTY):
+		lipgloss.SetColorProfile(termenv.Ascii)
+	case opts.color == "always" && !isTTY:
+		lipgloss.SetColorProfile(termenv.ANSI256)
+	}
+
+	// Only truncate messages when writing to a terminal
+	width := 1 << 16
+	if isTTY {
+		if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+			width = w
+		}
+	}
+
+	formatter := tui.NewFormatter(cfg)
+	return func(entry ingest.LogEntry) error {
+		_, err := fmt.Fprintln(w, formatter.FormatEntry(entry, width))
+		return err
+	}
+}
+
+// ignorePipe treats a closed stdout pipe as a normal exit.
+func ignorePipe(err error) error {
+	if errors.Is(err, syscall.EPIPE) {
+		return nil
+	}
+	return err
+}
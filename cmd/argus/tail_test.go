@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Expert21/argus/internal/ingest"
+)
+
+// writeTailConfig writes a config with two file sources whose lines
+// interleave in time, and returns its path.
+func writeTailConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.log": "Oct 11 22:14:15 host app: ERROR disk failed\n" +
+			"Oct 11 22:14:17 host app: started worker\n",
+		"web.log": "Oct 11 22:14:16 host web: WARN slow request\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, "config.yaml")
+	cfg := "sources:\n" +
+		"  - name: app\n    type: file\n    path: " + filepath.Join(dir, "app.log") + "\n    enabled: true\n" +
+		"  - name: web\n    type: file\n    path: " + filepath.Join(dir, "web.log") + "\n    enabled: true\n"
+	if err := os.WriteFile(path, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	return path
+}
+
+// runTailTo runs argus tail with stdout going to a pipe. With closeRead
+// the pipe's reader is gone before anything is written.
+func runTailTo(t *testing.T, args []string, closeRead bool) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = saved })
+
+	output := make(chan string, 1)
+	if closeRead {
+		r.Close()
+		output <- ""
+	} else {
+		go func() {
+			data, _ := io.ReadAll(r)
+			r.Close()
+			output <- string(data)
+		}()
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- runTail(args) }()
+	select {
+	case err = <-errc:
+	case <-time.After(10 * time.Second):
+		t.Fatal("argus tail didn't exit")
+	}
+	w.Close()
+	return <-output, err
+}
+
+// TestParseTailFlags tests the tail command line.
+func TestParseTailFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+		check   func(o *tailOptions) bool
+	}{
+		{nil, false, func(o *tailOptions) bool {
+			return o.follow && o.color == "auto" && o.minLevel == ingest.LevelUnknown
+		}},
+		{[]string{"-level", "warn", "-json", "-source", "a, b,"}, false, func(o *tailOptions) bool {
+			return o.minLevel == ingest.LevelWarning && o.json && strings.Join(o.sources, "|") == "a|b"
+		}},
+		{[]string{"-follow=false", "-n", "5", "-since", "2h"}, false, func(o *tailOptions) bool {
+			return !o.follow && o.lines == 5 && o.since == "2h"
+		}},
+		{[]string{"-level", "loud"}, true, nil},
+		{[]string{"-color", "sometimes"}, true, nil},
+		{[]string{"-n", "-1"}, true, nil},
+		{[]string{"extra"}, true, nil},
+	}
+
+	for _, tt := range tests {
+		opts, err := parseTailFlags(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTailFlags(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !tt.check(opts) {
+			t.Errorf("parseTailFlags(%q) = %+v", tt.args, opts)
+		}
+	}
+}
+
+// TestTailQuery tests printing the merged history and exiting: timestamp
+// order across sources, the level filter and JSON output.
+func TestTailQuery(t *testing.T) {
+	path := writeTailConfig(t)
+
+	tests := []struct {
+		name string
+		args []string
+		want []string // Messages, in order
+	}{
+		{"all", nil, []string{"ERROR disk failed", "WARN slow request", "started worker"}},
+		{"level", []string{"-level", "warn"}, []string{"ERROR disk failed", "WARN slow request"}},
+		{"source", []string{"-source", "web"}, []string{"WARN slow request"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-config", path, "-follow=false", "-n", "10", "-json"}, tt.args...)
+			out, err := runTailTo(t, args, false)
+			if err != nil {
+				t.Fatalf("runTail() error: %v", err)
+			}
+
+			var got []string
+			scanner := bufio.NewScanner(strings.NewReader(out))
+			for scanner.Scan() {
+				var entry ingest.LogEntry
+				if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+					t.Fatalf("line %q: %v", scanner.Text(), err)
+				}
+				got = append(got, entry.Message)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("messages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	out, err := runTailTo(t, []string{"-config", path, "-follow=false", "-n", "10", "-color", "never", "-level", "error"}, false)
+	if err != nil {
+		t.Fatalf("runTail() error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 1 || !strings.Contains(lines[0], "disk failed") {
+		t.Errorf("text output = %q, want the one error line", out)
+	}
+}
+
+// TestTailClosedPipe tests that a reader going away, as with
+// `argus tail | head`, ends tail without an error, following or not.
+func TestTailClosedPipe(t *testing.T) {
+	path := writeTailConfig(t)
+
+	for _, follow := range []string{"-follow=true", "-follow=false"} {
+		if _, err := runTailTo(t, []string{"-config", path, follow, "-n", "10"}, true); err != nil {
+			t.Errorf("runTail(%s) on a closed pipe error = %v, want nil", follow, err)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+// Package configs embeds the default configuration shipped with Argus.
+package configs
+
+import _ "embed"
+
+// DefaultYAML is the commented default config written by `argus config init`.
+//
+//go:embed default.yaml
+var DefaultYAML []byte
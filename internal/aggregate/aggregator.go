@@ -45,10 +45,18 @@ func NewRingBuffer(size int) *RingBuffer {
 }
 
 // Push adds an entry to the buffer, overwriting oldest if full.
+// Historical entries are inserted in timestamp order, so a burst of
+// backfill doesn't push out newer live entries; one older than
+// everything in a full buffer is dropped.
 func (rb *RingBuffer) Push(entry ingest.LogEntry) {
 	rb.mu.Lock()
 	defer rb.mu.Unlock()
 
+	if entry.Historical {
+		rb.insert(entry)
+		return
+	}
+
 	// Write at current position
 	rb.entries[rb.writeAt] = entry
 
@@ -61,6 +69,38 @@ func (rb *RingBuffer) Push(entry ingest.LogEntry) {
 	}
 }
 
+// index maps a logical position (0 = oldest) to a slot in entries.
+func (rb *RingBuffer) index(i int) int {
+	return (rb.writeAt - rb.count + i + rb.size) % rb.size
+}
+
+// insert places an entry after every entry not newer than it. Backfill
+// usually lands just before a few live entries, so the search runs from
+// the newest end.
+func (rb *RingBuffer) insert(entry ingest.LogEntry) {
+	pos := rb.count
+	for pos > 0 && rb.entries[rb.index(pos-1)].Timestamp.After(entry.Timestamp) {
+		pos--
+	}
+
+	if rb.count == rb.size {
+		if pos == 0 {
+			return // Older than everything kept; it would be evicted first
+		}
+		// Evict the oldest to make room
+		rb.count--
+		pos--
+	}
+
+	// Open a slot at the end and shift newer entries into it
+	rb.count++
+	rb.writeAt = (rb.writeAt + 1) % rb.size
+	for i := rb.count - 1; i > pos; i-- {
+		rb.entries[rb.index(i)] = rb.entries[rb.index(i-1)]
+	}
+	rb.entries[rb.index(pos)] = entry
+}
+
 // GetAll returns all entries in chronological order.
 // GO SYNTAX LESSON #36: Slice Copying
 // ===================================
@@ -147,6 +187,50 @@ type Subscriber struct {
 	ID     string
 	closed bool
 	mu     sync.Mutex
+
+	done     chan struct{} // Closed before Ch, to release a waiting send
+	doneOnce sync.Once
+}
+
+// send delivers an entry to the subscriber. A live entry is dropped when
+// the channel is full, so a slow reader can't hold up the feed. Historical
+// entries wait for room instead: a backfill burst is far larger than the
+// channel, and the reader asked for all of it.
+func (s *Subscriber) send(ctx context.Context, entry ingest.LogEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	if entry.Historical {
+		select {
+		case s.Ch <- entry:
+		case <-s.done:
+		case <-ctx.Done():
+		}
+		return
+	}
+
+	// Non-blocking send with select
+	select {
+	case s.Ch <- entry:
+	default:
+		// Subscriber's channel is full, skip
+	}
+}
+
+// close closes the subscriber's channel, first releasing a send waiting
+// on it.
+func (s *Subscriber) close() {
+	s.doneOnce.Do(func() { close(s.done) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.Ch)
+	}
 }
 
 // Aggregator collects logs from multiple sources and distributes them.
@@ -163,6 +247,9 @@ type Aggregator struct {
 	// Internal channel for incoming entries
 	entryChan chan ingest.LogEntry
 
+	// clearChan asks the aggregation loop to drop what it holds
+	clearChan chan chan struct{}
+
 	// Mutex for thread-safe access
 	mu sync.RWMutex
 
@@ -179,6 +266,7 @@ func NewAggregator(bufferSize int) *Aggregator {
 		History:     NewRingBuffer(bufferSize),
 		subscribers: make([]*Subscriber, 0),
 		entryChan:   make(chan ingest.LogEntry, 1000), // Buffered channel
+		clearChan:   make(chan chan struct{}),
 		ctx:         ctx,
 		cancel:      cancel,
 	}
@@ -207,26 +295,41 @@ func (a *Aggregator) aggregationLoop() {
 
 			// Broadcast to all subscribers
 			a.broadcast(entry)
+
+		case done := <-a.clearChan:
+			// Entries still queued came from the stopped sources
+			for len(a.entryChan) > 0 {
+				<-a.entryChan
+			}
+			a.History.Clear()
+			close(done)
 		}
 	}
 }
 
-// broadcast sends an entry to all subscribers.
+// Clear drops the entries in history and those still queued, so sources
+// restarted afterwards (on another journal boot, say) start afresh. Stop
+// the old sources first; entries already sent to subscribers stay there.
+func (a *Aggregator) Clear() {
+	done := make(chan struct{})
+	select {
+	case a.clearChan <- done:
+		<-done
+	case <-a.ctx.Done():
+		a.History.Clear()
+	}
+}
+
+// broadcast sends an entry to all subscribers. The list is copied so a
+// send waiting on a historical entry doesn't hold the lock that
+// Unsubscribe and AddSource need.
 func (a *Aggregator) broadcast(entry ingest.LogEntry) {
 	a.mu.RLock()
-	defer a.mu.RUnlock()
+	subscribers := append([]*Subscriber(nil), a.subscribers...)
+	a.mu.RUnlock()
 
-	for _, sub := range a.subscribers {
-		sub.mu.Lock()
-		if !sub.closed {
-			// Non-blocking send with select
-			select {
-			case sub.Ch <- entry:
-			default:
-				// Subscriber's channel is full, skip
-			}
-		}
-		sub.mu.Unlock()
+	for _, sub := range subscribers {
+		sub.send(a.ctx, entry)
 	}
 }
 
@@ -257,8 +360,9 @@ func (a *Aggregator) RemoveSource(name string) error {
 // Subscribe creates a new subscriber that receives all new entries.
 func (a *Aggregator) Subscribe(id string) *Subscriber {
 	sub := &Subscriber{
-		Ch: make(chan ingest.LogEntry, 100),
-		ID: id,
+		Ch:   make(chan ingest.LogEntry, 100),
+		ID:   id,
+		done: make(chan struct{}),
 	}
 
 	a.mu.Lock()
@@ -275,10 +379,7 @@ func (a *Aggregator) Unsubscribe(id string) {
 
 	for i, sub := range a.subscribers {
 		if sub.ID == id {
-			sub.mu.Lock()
-			sub.closed = true
-			close(sub.Ch)
-			sub.mu.Unlock()
+			sub.close()
 			// Remove from slice
 			a.subscribers = append(a.subscribers[:i], a.subscribers[i+1:]...)
 			return
@@ -331,12 +432,9 @@ func (a *Aggregator) Stop() {
 	a.mu.Unlock()
 
 	// Close all subscriber channels
+	a.mu.RLock()
 	for _, sub := range a.subscribers {
-		sub.mu.Lock()
-		if !sub.closed {
-			sub.closed = true
-			close(sub.Ch)
-		}
-		sub.mu.Unlock()
+		sub.close()
 	}
+	a.mu.RUnlock()
 }
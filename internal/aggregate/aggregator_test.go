@@ -1,6 +1,11 @@
 package aggregate
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -153,3 +158,253 @@ func TestAggregatorEntryBroadcast(t *testing.T) {
 		t.Error("Subscriber channel should be closed after unsubscribe")
 	}
 }
+
+// TestAggregatorClear tests that clearing drops the history and queued
+// entries, and that entries sent afterwards are kept again.
+func TestAggregatorClear(t *testing.T) {
+	agg := NewAggregator(100)
+	for i := 0; i < 3; i++ {
+		agg.History.Push(ingest.LogEntry{Message: fmt.Sprintf("old %d", i)})
+	}
+	agg.entryChan <- ingest.LogEntry{Message: "queued"}
+	agg.Start()
+	defer agg.Stop()
+
+	agg.Clear()
+	if n := agg.EntryCount(); n != 0 {
+		t.Errorf("EntryCount() after Clear() = %d, want 0", n)
+	}
+
+	sub := agg.Subscribe("test")
+	agg.entryChan <- ingest.LogEntry{Message: "new"}
+	select {
+	case entry := <-sub.Ch:
+		if entry.Message != "new" {
+			t.Errorf("first entry after Clear() = %q, want new", entry.Message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no entry after Clear()")
+	}
+	if n := agg.EntryCount(); n != 1 {
+		t.Errorf("EntryCount() = %d, want 1", n)
+	}
+}
+
+// TestRingBufferHistorical tests that a burst of backfill is placed by
+// timestamp and never evicts newer live entries.
+func TestRingBufferHistorical(t *testing.T) {
+	base := time.Date(2024, 1, 18, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	rb := NewRingBuffer(5)
+	for _, sec := range []int{10, 11, 12} {
+		rb.Push(ingest.LogEntry{Timestamp: at(sec)})
+	}
+	for _, sec := range []int{1, 2, 3, 4, 5} {
+		rb.Push(ingest.LogEntry{Timestamp: at(sec), Historical: true})
+	}
+	// Older than everything in the full buffer: dropped
+	rb.Push(ingest.LogEntry{Timestamp: at(0), Historical: true})
+	// Lands between live entries
+	rb.Push(ingest.LogEntry{Timestamp: at(11), Historical: true, PID: 1})
+
+	want := []int{5, 10, 11, 11, 12}
+	got := rb.GetAll()
+	if len(got) != len(want) {
+		t.Fatalf("GetAll() returned %d entries, want %d", len(got), len(want))
+	}
+	for i, sec := range want {
+		if !got[i].Timestamp.Equal(at(sec)) {
+			t.Errorf("entries[%d] = %v, want %v", i, got[i].Timestamp.Sub(base), at(sec).Sub(base))
+		}
+	}
+	// Equal timestamps keep arrival order
+	if got[3].PID != 1 {
+		t.Errorf("historical entry at 11s should follow the live one")
+	}
+
+	// Live entries still append and evict the oldest
+	rb.Push(ingest.LogEntry{Timestamp: at(13)})
+	if last := rb.GetLast(1)[0]; !last.Timestamp.Equal(at(13)) {
+		t.Errorf("GetLast(1) = %v, want 13s", last.Timestamp.Sub(base))
+	}
+	if first := rb.GetAll()[0]; !first.Timestamp.Equal(at(10)) {
+		t.Errorf("oldest = %v, want 10s", first.Timestamp.Sub(base))
+	}
+}
+
+// burstSource is an ingestor that sends a fixed batch of entries.
+type burstSource struct {
+	entries []ingest.LogEntry
+}
+
+func (s *burstSource) Start(ctx context.Context, entries chan<- ingest.LogEntry) error {
+	go func() {
+		for _, entry := range s.entries {
+			select {
+			case entries <- entry:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *burstSource) Stop() error   { return nil }
+func (s *burstSource) Name() string  { return "burst" }
+func (s *burstSource) Healthy() bool { return true }
+
+// TestAggregatorHistoricalBurst tests that a backfill burst far larger
+// than a subscriber's channel reaches the subscriber whole.
+func TestAggregatorHistoricalBurst(t *testing.T) {
+	const n = 5000
+	base := time.Date(2024, 1, 18, 12, 0, 0, 0, time.UTC)
+	src := &burstSource{}
+	for i := 0; i < n; i++ {
+		src.entries = append(src.entries, ingest.LogEntry{PID: i, Timestamp: base.Add(time.Duration(i) * time.Second), Historical: true})
+	}
+	// A live entry after the burst still arrives
+	src.entries = append(src.entries, ingest.LogEntry{PID: n, Timestamp: base.Add(n * time.Second)})
+
+	agg := NewAggregator(n + 1)
+	agg.Start()
+	defer agg.Stop()
+	sub := agg.Subscribe("test")
+	if err := agg.AddSource(src); err != nil {
+		t.Fatal(err)
+	}
+
+	for want := 0; want <= n; want++ {
+		select {
+		case entry := <-sub.Ch:
+			if entry.PID != want {
+				t.Fatalf("entry %d has PID %d", want, entry.PID)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of %d entries", want, n+1)
+		}
+	}
+}
+
+// TestAggregatorUnsubscribeDuringBurst tests that a subscriber that stops
+// reading mid-burst can still unsubscribe, and the others keep receiving.
+func TestAggregatorUnsubscribeDuringBurst(t *testing.T) {
+	src := &burstSource{}
+	for i := 0; i < 500; i++ {
+		src.entries = append(src.entries, ingest.LogEntry{PID: i, Historical: true})
+	}
+
+	agg := NewAggregator(1000)
+	agg.Start()
+	defer agg.Stop()
+	stalled := agg.Subscribe("stalled")
+	reader := agg.Subscribe("reader")
+	if err := agg.AddSource(src); err != nil {
+		t.Fatal(err)
+	}
+
+	// Let the stalled subscriber's channel fill
+	for i := 0; i < 50; i++ {
+		<-reader.Ch
+	}
+	done := make(chan struct{})
+	go func() {
+		agg.Unsubscribe("stalled")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Unsubscribe() blocked behind a full subscriber")
+	}
+	for range stalled.Ch {
+		// Drains what was buffered, then ends
+	}
+
+	for i := 50; i < 500; i++ {
+		select {
+		case <-reader.Ch:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("reader received %d of 500 entries", i)
+		}
+	}
+}
+
+// TestAggregatorJournalBurst tests that a journald source's lines history
+// reaches a subscriber in full. A stand-in journalctl prints the history
+// only when asked for those lines, then waits as -f does.
+func TestAggregatorJournalBurst(t *testing.T) {
+	const lines = 500
+	dir := t.TempDir()
+	script := fmt.Sprintf(`#!/bin/sh
+case "$*" in *--lines=%d*) ;; *) exit 1 ;; esac
+i=0
+while [ $i -lt %d ]; do
+	echo "{\"__CURSOR\":\"c$i\",\"__REALTIME_TIMESTAMP\":\"$((1705575600000000 + i))\",\"MESSAGE\":\"m$i\",\"PRIORITY\":\"6\"}"
+	i=$((i + 1))
+done
+exec sleep 60
+`, lines, lines)
+	if err := os.WriteFile(filepath.Join(dir, "journalctl"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	agg := NewAggregator(1000)
+	agg.Start()
+	defer agg.Stop()
+	sub := agg.Subscribe("test")
+	src := ingest.NewJournalIngestor(ingest.SourceConfig{Name: "Journal", Type: ingest.SourceJournald, Lines: lines, Backend: "journalctl"})
+	if err := agg.AddSource(src); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < lines; i++ {
+		select {
+		case entry := <-sub.Ch:
+			if want := fmt.Sprintf("m%d", i); entry.Message != want || !entry.Historical {
+				t.Fatalf("entry %d = %q (historical %v), want %q", i, entry.Message, entry.Historical, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of %d entries", i, lines)
+		}
+	}
+}
+
+// TestAggregatorArchiveBackfill tests that a file source's backfill,
+// spanning its rotated copies, reaches a subscriber in full.
+func TestAggregatorArchiveBackfill(t *testing.T) {
+	const perFile = 400
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	for i, name := range []string{path + ".2", path + ".1", path} {
+		var b strings.Builder
+		for n := 0; n < perFile; n++ {
+			fmt.Fprintf(&b, "2024-01-%02d 12:00:00 INFO file %d line %d\n", 16+i, i, n)
+		}
+		if err := os.WriteFile(name, []byte(b.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	agg := NewAggregator(3 * perFile)
+	agg.Start()
+	defer agg.Stop()
+	sub := agg.Subscribe("test")
+	src := ingest.NewFileIngestor(ingest.SourceConfig{Name: "app", Type: ingest.SourceFile, Path: path, Archives: true, BackfillLines: 3 * perFile})
+	if err := agg.AddSource(src); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3*perFile; i++ {
+		select {
+		case entry := <-sub.Ch:
+			if want := fmt.Sprintf("file %d line %d", i/perFile, i%perFile); !strings.HasSuffix(entry.Message, want) {
+				t.Fatalf("entry %d = %q, want %q", i, entry.Message, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of %d entries", i, 3*perFile)
+		}
+	}
+}
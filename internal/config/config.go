@@ -2,10 +2,15 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 
+	"github.com/Expert21/argus/internal/ingest"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,6 +25,10 @@ type Config struct {
 	General   GeneralConfig   `yaml:"general"`
 	Sources   []SourceConfig  `yaml:"sources"`
 	Highlight []HighlightRule `yaml:"highlight_rules,omitempty"`
+
+	// unknownKeys are keys in the loaded file that match no setting,
+	// reported by Validate
+	unknownKeys []string
 }
 
 // GeneralConfig holds general application settings.
@@ -42,23 +51,217 @@ type SourceConfig struct {
 	// Name is the human-readable identifier
 	Name string `yaml:"name"`
 
-	// Type is "journald", "file", or "directory"
+	// Type is a registered source type ("journald", "file", "directory", "kmsg", "syslog",
+	// "stdin", "fifo")
 	Type string `yaml:"type"`
 
-	// Path is the file/directory path (not used for journald)
+	// Path is the file/directory path (journald: journal files or a
+	// directory; kmsg: defaults to /dev/kmsg)
 	Path string `yaml:"path,omitempty"`
 
 	// Enabled controls whether this source is active
 	Enabled bool `yaml:"enabled"`
 
-	// Filters are optional journalctl filters
-	Filters []string `yaml:"filters,omitempty"`
+	// Listen is where a syslog source receives messages ("udp://0.0.0.0:514",
+	// "tcp://:6514", "unix:///run/argus/log.sock")
+	Listen string `yaml:"listen,omitempty"`
 
 	// Glob is the pattern for directory sources
 	Glob string `yaml:"glob,omitempty"`
 
+	// Parser is the line format of file, directory, fifo and stdin
+	// sources: a parser name or a chain tried in order (default syslog)
+	Parser ParserChain `yaml:"parser,omitempty"`
+
+	// Multiline joins the lines of stack traces and other multi-line
+	// entries on file, directory, fifo and stdin sources
+	Multiline *MultilineConfig `yaml:"multiline,omitempty"`
+
+	// Levels maps a source's own level names or priorities and
+	// overrides levels for the source or for entries matching a pattern
+	Levels *LevelConfig `yaml:"levels,omitempty"`
+
+	// Timezone is where file-like and syslog sources' timestamps were
+	// written when they don't say ("UTC", "America/New_York"); default local
+	Timezone string `yaml:"timezone,omitempty"`
+
 	// Priority is the minimum log level for journald (0-7)
 	Priority *int `yaml:"priority,omitempty"`
+
+	// BackfillLines is how many existing lines a file source shows at startup
+	BackfillLines int `yaml:"backfill_lines,omitempty"`
+
+	// BackfillSince shows existing lines newer than a duration ("2h") or timestamp
+	BackfillSince string `yaml:"backfill_since,omitempty"`
+
+	// Archives reads rotated copies (.1, .gz, .bz2) of a file source as history
+	Archives bool `yaml:"archives,omitempty"`
+
+	// Resume catches up on everything logged since the last run
+	Resume bool `yaml:"resume,omitempty"`
+
+	// Since opens a journald source with entries newer than a duration or timestamp
+	Since string `yaml:"since,omitempty"`
+
+	// Lines opens a journald source with its last N entries
+	Lines int `yaml:"lines,omitempty"`
+
+	// Boot limits a journald source to one boot: "current", -1, or a boot ID
+	Boot string `yaml:"boot,omitempty"`
+
+	// Units limits a journald source to these systemd units ("nginx", "ssh*")
+	Units []string `yaml:"units,omitempty"`
+
+	// Identifiers limits a journald source to these syslog identifiers
+	Identifiers []string `yaml:"identifiers,omitempty"`
+
+	// PriorityRange limits a journald source to a priority range ("err..warning")
+	PriorityRange string `yaml:"priority_range,omitempty"`
+
+	// Matches limits a journald source to entries with these FIELD=value pairs
+	Matches []string `yaml:"matches,omitempty"`
+
+	// Grep limits a journald source to messages matching this pattern
+	Grep string `yaml:"grep,omitempty"`
+
+	// Backend is "journalctl" or "native" (read journal files directly);
+	// empty uses journalctl when it is installed
+	Backend string `yaml:"backend,omitempty"`
+
+	// Namespace reads a journald namespace instead of the default journal
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// Fields keeps only journal fields matching these patterns ("_SYSTEMD_*")
+	Fields []string `yaml:"fields,omitempty"`
+
+	// ExcludeFields drops journal fields matching these patterns
+	ExcludeFields []string `yaml:"exclude_fields,omitempty"`
+}
+
+// MultilineConfig says which lines belong to the entry before them.
+//
+//	multiline:
+//	  preset: java            # or python, go
+//	multiline:
+//	  start: '^\d{4}-\d{2}-\d{2} '
+//	  max_lines: 200
+//	  timeout: 2s
+type MultilineConfig struct {
+	// Preset is a language's traceback layout: "java", "python" or "go"
+	Preset string `yaml:"preset,omitempty"`
+
+	// Start matches the first line of an entry; other lines continue it
+	Start string `yaml:"start,omitempty"`
+
+	// Continuation matches lines that continue the entry before them
+	Continuation string `yaml:"continuation,omitempty"`
+
+	// MaxLines caps the lines of one entry (default 500)
+	MaxLines int `yaml:"max_lines,omitempty"`
+
+	// Timeout is how long a live entry waits for more lines (default 1s)
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// ingestConfig converts the settings into the ingest package's configuration.
+func (m *MultilineConfig) ingestConfig() *ingest.MultilineConfig {
+	if m == nil {
+		return nil
+	}
+	return &ingest.MultilineConfig{
+		Preset:       m.Preset,
+		Start:        m.Start,
+		Continuation: m.Continuation,
+		MaxLines:     m.MaxLines,
+		Timeout:      m.Timeout,
+	}
+}
+
+// LevelConfig adjusts how a source's entries get their level.
+//
+//	levels:
+//	  names: {W: warning, fatal: critical, "30": info}
+//	  rules:
+//	    - match: 'healthcheck'
+//	      level: debug
+//	levels:
+//	  override: error          # everything this source writes
+type LevelConfig struct {
+	// Names maps the level names the source writes onto levels
+	Names map[string]string `yaml:"names,omitempty"`
+
+	// Override gives every entry of the source this level
+	Override string `yaml:"override,omitempty"`
+
+	// Rules give entries matching a pattern a level; the first match wins
+	Rules []LevelRule `yaml:"rules,omitempty"`
+}
+
+// LevelRule gives the entries matching Match the level Level.
+type LevelRule struct {
+	Match string `yaml:"match"`
+	Level string `yaml:"level"`
+}
+
+// ingestConfig converts the settings into the ingest package's configuration.
+func (l *LevelConfig) ingestConfig() *ingest.LevelConfig {
+	if l == nil {
+		return nil
+	}
+	levels := &ingest.LevelConfig{Names: l.Names, Override: l.Override}
+	for _, rule := range l.Rules {
+		levels.Rules = append(levels.Rules, ingest.LevelRule{Match: rule.Match, Level: rule.Level})
+	}
+	return levels
+}
+
+// IngestConfig converts this source into the ingest package's configuration.
+// Every field is carried across; an unknown type is an error.
+func (s SourceConfig) IngestConfig() (ingest.SourceConfig, error) {
+	sourceType, err := ingest.ParseSourceType(s.Type)
+	if err != nil {
+		return ingest.SourceConfig{}, err
+	}
+
+	return ingest.SourceConfig{
+		Name:        s.Name,
+		Type:        sourceType,
+		Path:        s.Path,
+		Enabled:     s.Enabled,
+		Listen:      s.Listen,
+		GlobPattern: s.Glob,
+		Parsers:     s.Parser.ingestConfig(),
+		Multiline:   s.Multiline.ingestConfig(),
+		Levels:      s.Levels.ingestConfig(),
+		Timezone:    s.Timezone,
+		Priority:    s.Priority,
+
+		BackfillLines: s.BackfillLines,
+		BackfillSince: s.BackfillSince,
+		Archives:      s.Archives,
+		Resume:        s.Resume,
+		Since:         s.Since,
+		Lines:         s.Lines,
+		Boot:          s.Boot,
+		Units:         s.Units,
+		Identifiers:   s.Identifiers,
+		PriorityRange: s.PriorityRange,
+		Matches:       s.Matches,
+		Grep:          s.Grep,
+		Backend:       s.Backend,
+		Namespace:     s.Namespace,
+		Fields:        s.Fields,
+		ExcludeFields: s.ExcludeFields,
+	}, nil
+}
+
+// NewIngestor builds the ingestor for this source via the ingest registry.
+func (s SourceConfig) NewIngestor() (ingest.Ingestor, error) {
+	src, err := s.IngestConfig()
+	if err != nil {
+		return nil, err
+	}
+	return ingest.New(src)
 }
 
 // HighlightRule defines a syntax highlighting rule.
@@ -67,6 +270,27 @@ type HighlightRule struct {
 	Style   string `yaml:"style"`
 }
 
+// SetHistory sets how much history a source opens with, using the
+// journald options (since, lines) or the file ones (backfill_since,
+// backfill_lines) as its type requires. Empty or zero values are ignored.
+func (s *SourceConfig) SetHistory(since string, lines int) {
+	if s.Type == ingest.SourceJournald.String() {
+		if since != "" {
+			s.Since = since
+		}
+		if lines > 0 {
+			s.Lines = lines
+		}
+		return
+	}
+	if since != "" {
+		s.BackfillSince = since
+	}
+	if lines > 0 {
+		s.BackfillLines = lines
+	}
+}
+
 // DefaultConfig returns the default configuration.
 func DefaultConfig() *Config {
 	return &Config{
@@ -120,10 +344,21 @@ func LoadFrom(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 
-	// Parse YAML
+	return parseConfig(data)
+}
+
+// parseConfig parses a config file's contents and applies defaults.
+// Unknown keys (typos, removed settings) are kept for Validate to report
+// rather than silently ignored.
+func parseConfig(data []byte) (*Config, error) {
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %w", err)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		cfg.unknownKeys, err = splitUnknownKeys(err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
 	}
 
 	// Apply defaults for missing fields
@@ -132,6 +367,42 @@ func LoadFrom(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// unknownKeyRegex matches yaml's report of a key with no struct field.
+var unknownKeyRegex = regexp.MustCompile(`^line (\d+): field (.+) not found in type`)
+
+// removedKeys are settings that are no longer read, with what replaced
+// them, so a config written for an older Argus says what to change.
+var removedKeys = map[string]string{
+	"filters": "the journalctl arguments in filters are no longer passed on; " +
+		"use units, identifiers, priority (or priority_range), matches and grep instead",
+}
+
+// splitUnknownKeys separates unknown keys from the other problems in a
+// decode error, which are returned as an error of their own.
+func splitUnknownKeys(err error) ([]string, error) {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return nil, err
+	}
+
+	var unknown, other []string
+	for _, msg := range typeErr.Errors {
+		if m := unknownKeyRegex.FindStringSubmatch(msg); m != nil {
+			if hint, ok := removedKeys[m[2]]; ok {
+				unknown = append(unknown, fmt.Sprintf("line %s: %s", m[1], hint))
+				continue
+			}
+			unknown = append(unknown, fmt.Sprintf("line %s: unknown key %q", m[1], m[2]))
+		} else {
+			other = append(other, msg)
+		}
+	}
+	if len(other) > 0 {
+		return unknown, &yaml.TypeError{Errors: other}
+	}
+	return unknown, nil
+}
+
 // Save writes the configuration to the default location.
 func (c *Config) Save() error {
 	path, err := ConfigPath()
@@ -216,27 +487,53 @@ func (c *Config) EnabledSources() []SourceConfig {
 }
 
 // Validate checks the configuration for errors.
+// Every problem is reported, joined into a single error.
 func (c *Config) Validate() error {
+	var errs []error
+
+	for _, key := range c.unknownKeys {
+		errs = append(errs, errors.New(key))
+	}
+
 	if c.General.MaxBuffer < 100 {
-		return fmt.Errorf("max_buffer must be at least 100")
+		errs = append(errs, fmt.Errorf("max_buffer must be at least 100"))
 	}
 
+	seen := make(map[string]bool, len(c.Sources))
+	stdinSources := 0
 	for i, s := range c.Sources {
 		if s.Name == "" {
-			return fmt.Errorf("source %d: name is required", i)
+			errs = append(errs, fmt.Errorf("source %d: name is required", i))
+			continue
 		}
+		if seen[s.Name] {
+			errs = append(errs, fmt.Errorf("source %q: duplicate name", s.Name))
+		}
+		seen[s.Name] = true
+
 		if s.Type == "" {
-			return fmt.Errorf("source %q: type is required", s.Name)
+			errs = append(errs, fmt.Errorf("source %q: type is required", s.Name))
+			continue
 		}
-		if s.Type != "journald" && s.Type != "file" && s.Type != "directory" {
-			return fmt.Errorf("source %q: invalid type %q (must be journald, file, or directory)", s.Name, s.Type)
+
+		// Type-specific checks live next to each ingestor in the registry
+		src, err := s.IngestConfig()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %q: %w", s.Name, err))
+			continue
+		}
+		if err := ingest.Validate(src); err != nil {
+			errs = append(errs, fmt.Errorf("source %q: %w", s.Name, err))
 		}
-		if s.Type == "file" || s.Type == "directory" {
-			if s.Path == "" {
-				return fmt.Errorf("source %q: path is required for type %s", s.Name, s.Type)
+
+		// Standard input can only be read once
+		if src.Type == ingest.SourceStdin && s.Enabled {
+			stdinSources++
+			if stdinSources == 2 {
+				errs = append(errs, fmt.Errorf("source %q: only one stdin source can be enabled", s.Name))
 			}
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
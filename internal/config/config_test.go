@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/Expert21/argus/internal/ingest"
 )
 
 // TestDefaultConfig tests that DefaultConfig returns valid defaults.
@@ -88,6 +91,68 @@ func TestConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "journald priority out of range",
+			cfg: Config{
+				General: GeneralConfig{MaxBuffer: 1000},
+				Sources: []SourceConfig{
+					{Name: "Test", Type: "journald", Priority: intPtr(8), Enabled: true},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "file source with bad backfill_since",
+			cfg: Config{
+				General: GeneralConfig{MaxBuffer: 1000},
+				Sources: []SourceConfig{
+					{Name: "Test", Type: "file", Path: "/var/log/auth.log", BackfillSince: "last tuesday", Enabled: true},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "file source with unknown multiline preset",
+			cfg: Config{
+				General: GeneralConfig{MaxBuffer: 1000},
+				Sources: []SourceConfig{
+					{Name: "Test", Type: "file", Path: "/var/log/app.log", Multiline: &MultilineConfig{Preset: "cobol"}, Enabled: true},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "file source with unknown level name",
+			cfg: Config{
+				General: GeneralConfig{MaxBuffer: 1000},
+				Sources: []SourceConfig{
+					{Name: "Test", Type: "file", Path: "/var/log/app.log", Levels: &LevelConfig{Names: map[string]string{"W": "warnish"}}, Enabled: true},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "two enabled stdin sources",
+			cfg: Config{
+				General: GeneralConfig{MaxBuffer: 1000},
+				Sources: []SourceConfig{
+					{Name: "A", Type: "stdin", Enabled: true},
+					{Name: "B", Type: "stdin", Enabled: true},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "one of two stdin sources enabled",
+			cfg: Config{
+				General: GeneralConfig{MaxBuffer: 1000},
+				Sources: []SourceConfig{
+					{Name: "A", Type: "stdin", Enabled: true},
+					{Name: "B", Type: "stdin"},
+				},
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -100,6 +165,32 @@ func TestConfigValidation(t *testing.T) {
 	}
 }
 
+// TestConfigValidationReportsAll tests that every problem is reported at once.
+func TestConfigValidationReportsAll(t *testing.T) {
+	cfg := Config{
+		General: GeneralConfig{MaxBuffer: 10},
+		Sources: []SourceConfig{
+			{Name: "A", Type: "file"},
+			{Name: "A", Type: "journald"},
+			{Name: "B", Type: "bogus"},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() returned nil, want errors")
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("Validate() error %T does not wrap multiple errors", err)
+	}
+	// max_buffer, missing path, duplicate name, invalid type
+	if got := len(joined.Unwrap()); got != 4 {
+		t.Errorf("Validate() reported %d problems, want 4:\n%v", got, err)
+	}
+}
+
 // TestConfigLoadNonexistent tests loading from a nonexistent file.
 func TestConfigLoadNonexistent(t *testing.T) {
 	cfg, err := LoadFrom("/nonexistent/path/config.yaml")
@@ -112,6 +203,53 @@ func TestConfigLoadNonexistent(t *testing.T) {
 	}
 }
 
+// TestConfigUnknownKeys tests that Validate reports keys that match no
+// setting, such as the removed journald filters.
+func TestConfigUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `general:
+  max_buffer: 1000
+sources:
+  - name: Journal
+    type: journald
+    enabled: true
+    filters: ["--directory=/tmp"]
+    unts: [nginx]
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom() error: %v", err)
+	}
+	if cfg.General.MaxBuffer != 1000 || len(cfg.Sources) != 1 {
+		t.Errorf("LoadFrom() = %+v, want the known keys loaded", cfg)
+	}
+
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() returned nil, want unknown key errors")
+	}
+	for _, want := range []string{
+		`line 7: the journalctl arguments in filters are no longer passed on; use units, identifiers, priority (or priority_range), matches and grep instead`,
+		`line 8: unknown key "unts"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, want %q", err, want)
+		}
+	}
+
+	// Other type errors still fail the load
+	if err := os.WriteFile(path, []byte("general:\n  max_buffer: lots\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFrom(path); err == nil {
+		t.Error("LoadFrom() with a bad value should fail")
+	}
+}
+
 // TestConfigSaveLoad tests saving and loading config.
 func TestConfigSaveLoad(t *testing.T) {
 	// Create temp file
@@ -204,3 +342,76 @@ func TestConfigEnabledSources(t *testing.T) {
 		t.Errorf("EnabledSources() = %d sources, want 2", len(enabled))
 	}
 }
+
+// TestSourceIngestConfig tests converting a config source to an ingest source.
+func TestSourceIngestConfig(t *testing.T) {
+	src := SourceConfig{
+		Name:     "Docker",
+		Type:     "directory",
+		Path:     "/var/lib/docker/containers",
+		Glob:     "*/*.log",
+		Enabled:  true,
+		Units:    []string{"nginx"},
+		Priority: intPtr(3),
+
+		BackfillLines: 100,
+		BackfillSince: "2h",
+		Backend:       "native",
+		Namespace:     "audit",
+		Fields:        []string{"_SYSTEMD_*"},
+		Multiline:     &MultilineConfig{Preset: "java", MaxLines: 50},
+		Levels:        &LevelConfig{Override: "error", Rules: []LevelRule{{Match: "ping", Level: "debug"}}},
+	}
+
+	got, err := src.IngestConfig()
+	if err != nil {
+		t.Fatalf("IngestConfig() error: %v", err)
+	}
+	if got.Type != ingest.SourceDirectory {
+		t.Errorf("Type = %v, want %v", got.Type, ingest.SourceDirectory)
+	}
+	if got.GlobPattern != "*/*.log" {
+		t.Errorf("GlobPattern = %q, want %q", got.GlobPattern, "*/*.log")
+	}
+	if got.Priority == nil || *got.Priority != 3 {
+		t.Errorf("Priority = %v, want 3", got.Priority)
+	}
+	if got.BackfillLines != 100 || got.BackfillSince != "2h" {
+		t.Errorf("Backfill = %d/%q, want 100/%q", got.BackfillLines, got.BackfillSince, "2h")
+	}
+	if got.Backend != "native" || got.Namespace != "audit" || len(got.Fields) != 1 {
+		t.Errorf("journal options = %q/%q/%v, want native/audit/1 field", got.Backend, got.Namespace, got.Fields)
+	}
+	if len(got.Units) != 1 || got.Units[0] != "nginx" {
+		t.Errorf("Units = %v, want [nginx]", got.Units)
+	}
+	if got.Multiline == nil || got.Multiline.Preset != "java" || got.Multiline.MaxLines != 50 {
+		t.Errorf("Multiline = %+v, want java preset with 50 lines", got.Multiline)
+	}
+	if got.Levels == nil || got.Levels.Override != "error" || len(got.Levels.Rules) != 1 || got.Levels.Rules[0].Level != "debug" {
+		t.Errorf("Levels = %+v, want an error override and one rule", got.Levels)
+	}
+
+	if _, err := (SourceConfig{Name: "x", Type: "bogus"}).IngestConfig(); err == nil {
+		t.Error("IngestConfig() with unknown type should fail")
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// TestSourceSetHistory tests that history options land on the right fields.
+func TestSourceSetHistory(t *testing.T) {
+	journal := SourceConfig{Type: "journald", Lines: 10}
+	journal.SetHistory("2h", 0)
+	if journal.Since != "2h" || journal.Lines != 10 || journal.BackfillSince != "" {
+		t.Errorf("journald SetHistory() = %+v", journal)
+	}
+
+	file := SourceConfig{Type: "file"}
+	file.SetHistory("", 50)
+	if file.BackfillLines != 50 || file.Lines != 0 {
+		t.Errorf("file SetHistory() = %+v", file)
+	}
+}
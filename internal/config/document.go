@@ -0,0 +1,199 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a config file opened for editing. Changes are made to the
+// file's YAML node tree instead of a Config that is marshalled again, so
+// the comments, key order and settings left at their defaults survive.
+// Blank lines are the one thing yaml.v3 doesn't keep.
+type Document struct {
+	root   yaml.Node // The document node
+	indent int       // Indentation the file uses
+}
+
+// LoadDocument reads a config file for editing. A missing file starts
+// from the default config, as LoadFrom does.
+func LoadDocument(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if data, err = yaml.Marshal(DefaultConfig()); err != nil {
+			return nil, fmt.Errorf("failed to marshal config: %w", err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	d := &Document{}
+	if err := yaml.Unmarshal(data, &d.root); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if d.root.Kind == 0 {
+		// An empty file
+		d.root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if d.top().Kind != yaml.MappingNode {
+		return nil, errors.New("failed to parse config: the file isn't a mapping of settings")
+	}
+	d.indent = fileIndent(d.top())
+	return d, nil
+}
+
+// top returns the document's top-level mapping.
+func (d *Document) top() *yaml.Node {
+	return d.root.Content[0]
+}
+
+// fileIndent returns the indentation of the first nested mapping, or 2.
+func fileIndent(top *yaml.Node) int {
+	for i := 1; i < len(top.Content); i += 2 {
+		value := top.Content[i]
+		if value.Kind == yaml.MappingNode && len(value.Content) > 0 && value.Content[0].Line > top.Content[i-1].Line {
+			if indent := value.Content[0].Column - top.Content[i-1].Column; indent >= 2 && indent <= 8 {
+				return indent
+			}
+		}
+	}
+	return 2
+}
+
+// Config parses the document as it stands, with defaults applied.
+func (d *Document) Config() (*Config, error) {
+	data, err := d.encode()
+	if err != nil {
+		return nil, err
+	}
+	return parseConfig(data)
+}
+
+// sources returns the sources sequence, adding an empty one if the file
+// has none.
+func (d *Document) sources() *yaml.Node {
+	top := d.top()
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		if top.Content[i].Value != "sources" {
+			continue
+		}
+		value := top.Content[i+1]
+		if value.Kind != yaml.SequenceNode {
+			// "sources:" with nothing under it
+			*value = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", HeadComment: value.HeadComment, LineComment: value.LineComment}
+		}
+		return value
+	}
+
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	top.Content = append(top.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "sources"}, seq)
+	return seq
+}
+
+// source returns the sources sequence and the index in it of the source
+// named name, or -1.
+func (d *Document) source(name string) (*yaml.Node, int) {
+	seq := d.sources()
+	for i, item := range seq.Content {
+		if value := mappingValue(item, "name"); value != nil && value.Value == name {
+			return seq, i
+		}
+	}
+	return seq, -1
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// AddSource appends a source after the file's existing ones.
+func (d *Document) AddSource(source SourceConfig) error {
+	seq, i := d.source(source.Name)
+	if i >= 0 {
+		return fmt.Errorf("source %q already exists", source.Name)
+	}
+
+	var item yaml.Node
+	if err := item.Encode(source); err != nil {
+		return fmt.Errorf("failed to encode source: %w", err)
+	}
+	seq.Style &^= yaml.FlowStyle // "sources: []" grows into a block list
+	seq.Content = append(seq.Content, &item)
+	return nil
+}
+
+// RemoveSource removes a source by name, with the comments attached to it.
+func (d *Document) RemoveSource(name string) bool {
+	seq, i := d.source(name)
+	if i < 0 {
+		return false
+	}
+	seq.Content = append(seq.Content[:i], seq.Content[i+1:]...)
+	return true
+}
+
+// SetEnabled enables or disables a source by name, keeping any comment
+// on its enabled line.
+func (d *Document) SetEnabled(name string, enabled bool) bool {
+	seq, i := d.source(name)
+	if i < 0 {
+		return false
+	}
+
+	item := seq.Content[i]
+	value := strconv.FormatBool(enabled)
+	if node := mappingValue(item, "enabled"); node != nil {
+		node.Kind, node.Tag, node.Value, node.Style = yaml.ScalarNode, "!!bool", value, 0
+		node.Content = nil
+		return true
+	}
+	item.Content = append(item.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "enabled"},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: value})
+	return true
+}
+
+// encode renders the document in the file's indentation.
+func (d *Document) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(d.indent)
+	if err := enc.Encode(&d.root); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveTo writes the document to path.
+func (d *Document) SaveTo(path string) error {
+	data, err := d.encode()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDocumentEdits tests that editing sources keeps the file's comments,
+// indentation and untouched settings.
+func TestDocumentEdits(t *testing.T) {
+	const original = `# Argus config
+general:
+    max_buffer: 500 # small box
+sources:
+    # The journal
+    - name: "System Journal"
+      type: journald
+      enabled: true # on by default
+    - name: Auth
+      type: file
+      path: /var/log/auth.log
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := LoadDocument(path)
+	if err != nil {
+		t.Fatalf("LoadDocument() error: %v", err)
+	}
+	if err := doc.AddSource(SourceConfig{Name: "App", Type: "file", Path: "/var/log/app.log", Enabled: true}); err != nil {
+		t.Fatalf("AddSource() error: %v", err)
+	}
+	if err := doc.AddSource(SourceConfig{Name: "Auth", Type: "file"}); err == nil {
+		t.Error("AddSource() of an existing name should fail")
+	}
+	if !doc.SetEnabled("System Journal", false) || !doc.SetEnabled("Auth", true) {
+		t.Error("SetEnabled() didn't find the source")
+	}
+	if doc.SetEnabled("Nonexistent", true) || doc.RemoveSource("Nonexistent") {
+		t.Error("editing a nonexistent source should report it")
+	}
+	if err := doc.SaveTo(path); err != nil {
+		t.Fatalf("SaveTo() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := string(data)
+	for _, want := range []string{"# Argus config", "max_buffer: 500 # small box", "# The journal", "enabled: false # on by default", "\n      path: /var/log/app.log"} {
+		if !strings.Contains(saved, want) {
+			t.Errorf("saved file lacks %q:\n%s", want, saved)
+		}
+	}
+	// Defaults the file left out stay out
+	if strings.Contains(saved, "timestamp_format") {
+		t.Errorf("saved file gained defaults:\n%s", saved)
+	}
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom() error: %v", err)
+	}
+	if len(cfg.Sources) != 3 || cfg.Sources[0].Enabled || !cfg.Sources[1].Enabled || cfg.Sources[2].Path != "/var/log/app.log" {
+		t.Errorf("Sources = %+v", cfg.Sources)
+	}
+
+	doc, err = LoadDocument(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !doc.RemoveSource("System Journal") {
+		t.Fatal("RemoveSource() didn't find the source")
+	}
+	cfg, err = doc.Config()
+	if err != nil {
+		t.Fatalf("Config() error: %v", err)
+	}
+	if len(cfg.Sources) != 2 || cfg.Sources[0].Name != "Auth" {
+		t.Errorf("Sources after remove = %+v", cfg.Sources)
+	}
+}
+
+// TestDocumentMissingSources tests adding to a file without sources, and
+// starting from the defaults when there is no file.
+func TestDocumentMissingSources(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content *string
+		want    int // Sources after adding one
+	}{
+		{"no file", nil, len(DefaultConfig().Sources) + 1},
+		{"empty file", new(string), 1},
+		{"no sources key", ptr("general:\n  max_buffer: 100\n"), 1},
+		{"empty sources", ptr("sources:\n"), 1},
+		{"flow sources", ptr("sources: []\n"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".yaml")
+			if tt.content != nil {
+				if err := os.WriteFile(path, []byte(*tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			doc, err := LoadDocument(path)
+			if err != nil {
+				t.Fatalf("LoadDocument() error: %v", err)
+			}
+			if err := doc.AddSource(SourceConfig{Name: "In", Type: "stdin", Enabled: true}); err != nil {
+				t.Fatalf("AddSource() error: %v", err)
+			}
+			if err := doc.SaveTo(path); err != nil {
+				t.Fatalf("SaveTo() error: %v", err)
+			}
+			cfg, err := LoadFrom(path)
+			if err != nil {
+				t.Fatalf("LoadFrom() error: %v", err)
+			}
+			if len(cfg.Sources) != tt.want || cfg.GetSource("In") == nil {
+				t.Errorf("Sources = %+v, want %d with In", cfg.Sources, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Expert21/argus/internal/ingest"
+	"gopkg.in/yaml.v3"
+)
+
+// ParserConfig is one parser of a source's chain. In YAML it is either a
+// parser name or a mapping with the parser's options:
+//
+//	parser: syslog
+//	parser: [regex, syslog]
+//	parser:
+//	  - type: regex
+//	    pattern: '^(?P<level>\w+) (?P<message>.*)$'
+//	  - syslog
+type ParserConfig struct {
+	// Type is a registered parser name ("syslog", "json", "logfmt",
+	// "access", "nginx_error", "haproxy", "regex", "raw")
+	Type string `yaml:"type"`
+
+	// Pattern is the regex parser's expression, with named groups
+	Pattern string `yaml:"pattern,omitempty"`
+
+	// Preset is the json parser's field layout ("zap", "logrus", "slog",
+	// "bunyan", "pino")
+	Preset string `yaml:"preset,omitempty"`
+
+	// TimeKey, LevelKey and MessageKey override the json parser's field
+	// paths ("log.level")
+	TimeKey    string `yaml:"time_key,omitempty"`
+	LevelKey   string `yaml:"level_key,omitempty"`
+	MessageKey string `yaml:"message_key,omitempty"`
+}
+
+// ParserChain is a source's parsers, tried in order until one
+// recognises the line. A single parser may be written without the list.
+type ParserChain []ParserConfig
+
+// parserKeys are the keys a parser mapping may hold, from its yaml tags.
+var parserKeys = func() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(ParserConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		keys[name] = true
+	}
+	return keys
+}()
+
+// UnmarshalYAML accepts a parser name or a mapping.
+func (p *ParserConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*p = ParserConfig{Type: node.Value}
+		return nil
+	}
+	type plain ParserConfig
+	return node.Decode((*plain)(p))
+}
+
+// MarshalYAML writes a parser without options as just its name.
+func (p ParserConfig) MarshalYAML() (interface{}, error) {
+	if p == (ParserConfig{Type: p.Type}) {
+		return p.Type, nil
+	}
+	type plain ParserConfig
+	return plain(p), nil
+}
+
+// UnmarshalYAML accepts a list of parsers or a single one.
+//
+// Parser keys are checked here, since a custom unmarshaler doesn't
+// inherit the strict decoder's setting. The error reads like the
+// decoder's own, so Validate reports it as an unknown key, and the chain
+// is kept; an element returning it would be dropped from the list.
+func (c *ParserChain) UnmarshalYAML(node *yaml.Node) error {
+	items := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		items = node.Content
+	}
+
+	chain := make(ParserChain, len(items))
+	var unknown []string
+	for i, item := range items {
+		if err := item.Decode(&chain[i]); err != nil {
+			return err
+		}
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			if key := item.Content[j]; !parserKeys[key.Value] {
+				unknown = append(unknown, fmt.Sprintf("line %d: field %s not found in type config.ParserConfig", key.Line, key.Value))
+			}
+		}
+	}
+
+	*c = chain
+	if len(unknown) > 0 {
+		return &yaml.TypeError{Errors: unknown}
+	}
+	return nil
+}
+
+// MarshalYAML writes a chain of one parser without the list.
+func (c ParserChain) MarshalYAML() (interface{}, error) {
+	if len(c) == 1 {
+		return c[0], nil
+	}
+	return []ParserConfig(c), nil
+}
+
+// ingestConfig converts the chain into the ingest package's configuration.
+func (c ParserChain) ingestConfig() []ingest.ParserConfig {
+	if len(c) == 0 {
+		return nil
+	}
+	parsers := make([]ingest.ParserConfig, len(c))
+	for i, p := range c {
+		parsers[i] = ingest.ParserConfig{
+			Type:       p.Type,
+			Pattern:    p.Pattern,
+			Preset:     p.Preset,
+			TimeKey:    p.TimeKey,
+			LevelKey:   p.LevelKey,
+			MessageKey: p.MessageKey,
+		}
+	}
+	return parsers
+}
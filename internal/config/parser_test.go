@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestParserChainYAML tests the short and long forms of a parser chain.
+func TestParserChainYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want ParserChain
+	}{
+		{"single name", `parser: syslog`, ParserChain{{Type: "syslog"}}},
+		{"list of names", `parser: [regex, syslog]`, ParserChain{{Type: "regex"}, {Type: "syslog"}}},
+		{
+			name: "mapping and name",
+			yaml: "parser:\n  - type: regex\n    pattern: '^(?P<message>.*)$'\n  - raw",
+			want: ParserChain{{Type: "regex", Pattern: "^(?P<message>.*)$"}, {Type: "raw"}},
+		},
+		{"single mapping", "parser:\n  type: raw", ParserChain{{Type: "raw"}}},
+		{
+			name: "json options",
+			yaml: "parser:\n  type: json\n  preset: pino\n  message_key: event.text",
+			want: ParserChain{{Type: "json", Preset: "pino", MessageKey: "event.text"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var src SourceConfig
+			if err := yaml.Unmarshal([]byte(tt.yaml), &src); err != nil {
+				t.Fatalf("Unmarshal() error: %v", err)
+			}
+			if !equalChains(src.Parser, tt.want) {
+				t.Fatalf("Parser = %+v, want %+v", src.Parser, tt.want)
+			}
+
+			// Writing the config back must read the same
+			data, err := yaml.Marshal(src)
+			if err != nil {
+				t.Fatalf("Marshal() error: %v", err)
+			}
+			var again SourceConfig
+			if err := yaml.Unmarshal(data, &again); err != nil || !equalChains(again.Parser, tt.want) {
+				t.Errorf("round trip = %+v (%v), want %+v:\n%s", again.Parser, err, tt.want, data)
+			}
+		})
+	}
+}
+
+// TestParserUnknownKeys tests that a misspelt parser option is reported
+// like any other unknown key, and a bad parser by Validate.
+func TestParserUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `general:
+  max_buffer: 1000
+sources:
+  - name: App
+    type: file
+    path: /var/log/app.log
+    enabled: true
+    parser:
+      - type: regex
+        patern: '(?P<message>.*)'
+      - syslog
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom() error: %v", err)
+	}
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want errors")
+	}
+	for _, want := range []string{`line 10: unknown key "patern"`, `source "App": parser regex: pattern is required`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, want it to mention %s", err, want)
+		}
+	}
+}
+
+func equalChains(a, b ParserChain) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,672 @@
+// Package doctor diagnoses why log sources can't be read and suggests fixes.
+//
+// Each check produces a Result with a status, what was found, and a
+// concrete command or action that fixes the problem. Checks only read
+// from the system; nothing is ever changed.
+package doctor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/Expert21/argus/internal/config"
+	"github.com/Expert21/argus/internal/ingest"
+)
+
+// Status is the outcome of a single check.
+type Status int
+
+const (
+	// StatusOK means the check passed
+	StatusOK Status = iota
+	// StatusSkip means the check doesn't apply to this setup
+	StatusSkip
+	// StatusWarn means Argus works but with reduced access
+	StatusWarn
+	// StatusFail means a configured source cannot work
+	StatusFail
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusSkip:
+		return "skip"
+	case StatusWarn:
+		return "warn"
+	case StatusFail:
+		return "fail"
+	default:
+		return "unknown"
+	}
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	// Check is a short name for what was checked (e.g. "journalctl")
+	Check string
+
+	// Status is the outcome
+	Status Status
+
+	// Detail describes what was found
+	Detail string
+
+	// Fix is a concrete remedy, empty when nothing needs doing
+	Fix string
+}
+
+// Install locations written by scripts/install.sh
+const (
+	DefaultWrapperPath = "/usr/local/bin/argus"
+	DefaultBinaryPath  = "/usr/local/bin/argus-bin"
+	DefaultSudoersPath = "/etc/sudoers.d/argus"
+	DefaultGroupName   = "argus-users"
+	DefaultInotifyDir  = "/proc/sys/fs/inotify"
+)
+
+// journalGroups can read the system journal without root.
+var journalGroups = []string{"systemd-journal", "adm", "wheel"}
+
+// Doctor runs the diagnostic checks. Paths are fields so tests can point
+// them at temporary files.
+type Doctor struct {
+	Config *config.Config
+
+	WrapperPath string
+	BinaryPath  string
+	SudoersPath string
+	GroupName   string
+	InotifyDir  string
+	JournalDirs []string
+
+	// lookPath finds executables (exec.LookPath)
+	lookPath func(string) (string, error)
+
+	// euid is the effective user id (os.Geteuid)
+	euid int
+}
+
+// New creates a Doctor with the standard system locations.
+func New(cfg *config.Config) *Doctor {
+	return &Doctor{
+		Config:      cfg,
+		WrapperPath: DefaultWrapperPath,
+		BinaryPath:  DefaultBinaryPath,
+		SudoersPath: DefaultSudoersPath,
+		GroupName:   DefaultGroupName,
+		InotifyDir:  DefaultInotifyDir,
+		JournalDirs: []string{"/var/log/journal", "/run/log/journal"},
+		lookPath:    exec.LookPath,
+		euid:        os.Geteuid(),
+	}
+}
+
+// Run executes every check and returns the results in display order.
+func (d *Doctor) Run() []Result {
+	var results []Result
+	results = append(results, d.CheckJournal()...)
+	results = append(results, d.CheckSources()...)
+	results = append(results, d.CheckInotify()...)
+	results = append(results, d.CheckInstall()...)
+	return results
+}
+
+// ============================================================================
+// Journal
+// ============================================================================
+
+// CheckJournal verifies the journal backend each journald source will use
+// is available and the system journal is readable by the current user.
+func (d *Doctor) CheckJournal() []Result {
+	if !d.hasSourceType("journald") {
+		return []Result{{Check: "journal", Status: StatusSkip, Detail: "no journald sources configured"}}
+	}
+
+	// Sources without a backend run journalctl when it is installed and
+	// read the journal files themselves when it isn't
+	var needJournalctl []string
+	native := true
+	for _, src := range d.Config.EnabledSources() {
+		if src.Type != "journald" {
+			continue
+		}
+		switch src.Backend {
+		case "native":
+		case "journalctl":
+			needJournalctl = append(needJournalctl, src.Name)
+			native = false
+		default:
+			native = false
+		}
+	}
+
+	var results []Result
+
+	path, err := d.lookPath("journalctl")
+	switch {
+	case native:
+		results = append(results, Result{Check: "journal backend", Status: StatusOK, Detail: "native: reading journal files directly"})
+	case err == nil:
+		results = append(results, Result{Check: "journalctl", Status: StatusOK, Detail: "found at " + path})
+	case len(needJournalctl) > 0:
+		results = append(results, Result{
+			Check:  "journalctl",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("journalctl not found on PATH, but %s set backend: journalctl", strings.Join(needJournalctl, ", ")),
+			Fix:    "install systemd (journalctl) or set backend: native on those sources",
+		})
+	default:
+		results = append(results, Result{Check: "journal backend", Status: StatusOK, Detail: "journalctl not found on PATH; reading journal files directly"})
+	}
+
+	results = append(results, d.checkJournalAccess())
+	return results
+}
+
+// checkJournalAccess checks group membership and tries to open a journal file.
+func (d *Doctor) checkJournalAccess() Result {
+	// Find a journal file to test against
+	var journalFile string
+	for _, dir := range d.JournalDirs {
+		if f := findJournalFile(dir); f != "" {
+			journalFile = f
+			break
+		}
+	}
+
+	if journalFile == "" {
+		return Result{
+			Check:  "journal access",
+			Status: StatusWarn,
+			Detail: fmt.Sprintf("no journal files found in %s", strings.Join(d.JournalDirs, ", ")),
+			Fix:    "check that systemd-journald is running (systemctl status systemd-journald)",
+		}
+	}
+
+	f, err := os.Open(journalFile)
+	if err == nil {
+		f.Close()
+		detail := "system journal is readable"
+		if d.euid == 0 {
+			detail += " (running as root)"
+		} else if groups := memberOf(journalGroups); len(groups) > 0 {
+			detail += fmt.Sprintf(" (group %s)", groups[0])
+		}
+		return Result{Check: "journal access", Status: StatusOK, Detail: detail}
+	}
+
+	// Not readable: figure out whether a re-login would fix it
+	configured := configuredGroups(journalGroups)
+	if len(configured) > 0 {
+		return Result{
+			Check:  "journal access",
+			Status: StatusWarn,
+			Detail: fmt.Sprintf("you were added to %s but this session predates it; only your own user journal is visible", configured[0]),
+			Fix:    "log out and back in (or run: newgrp " + configured[0] + ")",
+		}
+	}
+
+	return Result{
+		Check:  "journal access",
+		Status: StatusWarn,
+		Detail: fmt.Sprintf("cannot read %s: only your own user journal is visible", journalFile),
+		Fix:    "sudo usermod -aG systemd-journal $USER, then log out and back in (or run via sudo argus)",
+	}
+}
+
+// findJournalFile returns the first *.journal file up to one level deep.
+func findJournalFile(dir string) string {
+	for _, pattern := range []string{"*.journal", "*/*.journal"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		if len(matches) > 0 {
+			return matches[0]
+		}
+	}
+	return ""
+}
+
+// ============================================================================
+// File and directory sources
+// ============================================================================
+
+// CheckSources verifies every file, directory, fifo and kmsg source
+// exists and is readable.
+func (d *Doctor) CheckSources() []Result {
+	var results []Result
+	for _, src := range d.Config.Sources {
+		switch src.Type {
+		case "file", "directory", "fifo":
+			results = append(results, d.checkPathSource(src))
+		case "kmsg":
+			results = append(results, d.checkKmsgSource(src))
+		}
+	}
+	return results
+}
+
+// checkKmsgSource checks that the kernel log can be opened. Reading it
+// needs root (or CAP_SYSLOG) when kernel.dmesg_restrict is set.
+func (d *Doctor) checkKmsgSource(src config.SourceConfig) Result {
+	name := fmt.Sprintf("source %q", src.Name)
+	if !src.Enabled {
+		name += " (disabled)"
+	}
+	path := src.Path
+	if path == "" {
+		path = ingest.DefaultKmsgPath
+	}
+
+	f, err := os.Open(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return Result{
+			Check:  name,
+			Status: StatusFail,
+			Detail: path + " does not exist",
+			Fix:    "containers often have no /dev/kmsg; disable the source: argus config disable \"" + src.Name + "\"",
+		}
+	case err != nil:
+		return Result{
+			Check:  name,
+			Status: StatusFail,
+			Detail: fmt.Sprintf("cannot read %s: %v", path, errors.Unwrap(err)),
+			Fix:    "run via sudo argus, or allow unprivileged reads: sudo sysctl kernel.dmesg_restrict=0",
+		}
+	}
+	f.Close()
+	return Result{Check: name, Status: StatusOK, Detail: path + " is readable"}
+}
+
+// checkPathSource checks a single file or directory source.
+func (d *Doctor) checkPathSource(src config.SourceConfig) Result {
+	name := fmt.Sprintf("source %q", src.Name)
+	if !src.Enabled {
+		name += " (disabled)"
+	}
+
+	info, err := os.Stat(src.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Result{
+				Check:  name,
+				Status: StatusFail,
+				Detail: src.Path + " does not exist",
+				Fix:    "fix the path (argus config remove-source / add-source) or disable it: argus config disable \"" + src.Name + "\"",
+			}
+		}
+		return permissionResult(name, src.Path, err)
+	}
+
+	if src.Type == "file" && info.IsDir() {
+		return Result{
+			Check:  name,
+			Status: StatusFail,
+			Detail: src.Path + " is a directory",
+			Fix:    "use type: directory with a glob for directories",
+		}
+	}
+	if src.Type == "directory" && !info.IsDir() {
+		return Result{
+			Check:  name,
+			Status: StatusFail,
+			Detail: src.Path + " is not a directory",
+			Fix:    "use type: file for single files",
+		}
+	}
+	if src.Type == "fifo" && info.Mode()&os.ModeNamedPipe == 0 {
+		return Result{
+			Check:  name,
+			Status: StatusFail,
+			Detail: src.Path + " is not a named pipe",
+			Fix:    "create it with mkfifo " + src.Path + ", or use type: file for regular files",
+		}
+	}
+
+	// Actually try reading, permission bits alone don't account for ACLs
+	switch {
+	case info.IsDir():
+		_, err = os.ReadDir(src.Path)
+	case info.Mode()&os.ModeNamedPipe != 0:
+		// Without O_NONBLOCK the open would wait for a writer
+		var f *os.File
+		if f, err = os.OpenFile(src.Path, os.O_RDONLY|syscall.O_NONBLOCK, 0); err == nil {
+			f.Close()
+		}
+	default:
+		var f *os.File
+		if f, err = os.Open(src.Path); err == nil {
+			f.Close()
+		}
+	}
+	if err != nil {
+		return permissionResult(name, src.Path, err)
+	}
+
+	return Result{Check: name, Status: StatusOK, Detail: src.Path + " is readable"}
+}
+
+// permissionResult builds a failure with a group-aware fix for unreadable paths.
+func permissionResult(check, path string, err error) Result {
+	fix := "run via sudo argus, or grant read access: sudo setfacl -m u:$USER:r " + path
+	if group := fileGroup(path); group != "" && group != "root" {
+		fix = fmt.Sprintf("sudo usermod -aG %s $USER, then log out and back in (or run via sudo argus)", group)
+	}
+	return Result{
+		Check:  check,
+		Status: StatusFail,
+		Detail: fmt.Sprintf("cannot read %s: %v", path, errors.Unwrap(err)),
+		Fix:    fix,
+	}
+}
+
+// fileGroup returns the name of the group owning path, if it can be determined.
+func fileGroup(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	g, err := user.LookupGroupId(strconv.FormatUint(uint64(st.Gid), 10))
+	if err != nil {
+		return ""
+	}
+	return g.Name
+}
+
+// ============================================================================
+// inotify limits
+// ============================================================================
+
+// CheckInotify compares the inotify limits with the watches Argus needs.
+func (d *Doctor) CheckInotify() []Result {
+	watches, instances := d.estimateWatches()
+	if watches == 0 {
+		return []Result{{Check: "inotify", Status: StatusSkip, Detail: "no file or directory sources enabled"}}
+	}
+
+	var results []Result
+
+	maxWatches, err := readProcInt(filepath.Join(d.InotifyDir, "max_user_watches"))
+	if err != nil {
+		results = append(results, Result{Check: "inotify watches", Status: StatusWarn, Detail: fmt.Sprintf("cannot read limit: %v", err)})
+	} else if watches > maxWatches {
+		results = append(results, Result{
+			Check:  "inotify watches",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("need ~%d watches, limit is %d", watches, maxWatches),
+			Fix:    fmt.Sprintf("sudo sysctl fs.inotify.max_user_watches=%d (persist in /etc/sysctl.d/90-argus.conf)", suggestLimit(watches)),
+		})
+	} else {
+		results = append(results, Result{Check: "inotify watches", Status: StatusOK, Detail: fmt.Sprintf("need ~%d of %d", watches, maxWatches)})
+	}
+
+	maxInstances, err := readProcInt(filepath.Join(d.InotifyDir, "max_user_instances"))
+	if err != nil {
+		results = append(results, Result{Check: "inotify instances", Status: StatusWarn, Detail: fmt.Sprintf("cannot read limit: %v", err)})
+	} else if instances > maxInstances {
+		results = append(results, Result{
+			Check:  "inotify instances",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("need %d instances, limit is %d", instances, maxInstances),
+			Fix:    fmt.Sprintf("sudo sysctl fs.inotify.max_user_instances=%d (persist in /etc/sysctl.d/90-argus.conf)", suggestLimit(instances)),
+		})
+	} else {
+		// Other programs (editors, IDEs) share this per-user limit
+		status := StatusOK
+		if instances*2 > maxInstances {
+			status = StatusWarn
+		}
+		results = append(results, Result{Check: "inotify instances", Status: status, Detail: fmt.Sprintf("need %d of %d (shared with other programs)", instances, maxInstances)})
+	}
+
+	return results
+}
+
+// estimateWatches counts the inotify watches and instances the enabled
+// sources will use: one instance per source, one watch per watched path.
+func (d *Doctor) estimateWatches() (watches, instances int) {
+	for _, src := range d.Config.EnabledSources() {
+		switch src.Type {
+		case "file":
+			watches++
+			instances++
+		case "directory":
+			instances++
+			watches += 1 + countWatchedDirs(src.Path, src.Glob)
+		}
+	}
+	return watches, instances
+}
+
+// countWatchedDirs counts the subdirectories a nested glob like "*/*.log"
+// requires watching.
+func countWatchedDirs(root, glob string) int {
+	dirPart := filepath.Dir(glob)
+	if glob == "" || dirPart == "." {
+		return 0
+	}
+	matches, _ := filepath.Glob(filepath.Join(root, dirPart))
+	return len(matches)
+}
+
+// readProcInt reads a single integer from a /proc file.
+func readProcInt(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// suggestLimit rounds need up to a comfortable power of two.
+func suggestLimit(need int) int {
+	limit := 128
+	for limit < need*2 {
+		limit *= 2
+	}
+	return limit
+}
+
+// ============================================================================
+// Privileged install (scripts/install.sh)
+// ============================================================================
+
+// CheckInstall verifies the wrapper, binary, sudoers rule and group that
+// scripts/install.sh sets up, mirroring the checks in argus-wrapper.sh.
+func (d *Doctor) CheckInstall() []Result {
+	if _, err := os.Lstat(d.WrapperPath); errors.Is(err, fs.ErrNotExist) {
+		return []Result{{
+			Check:  "sudo install",
+			Status: StatusSkip,
+			Detail: "wrapper not installed (only needed for passwordless sudo argus)",
+			Fix:    "sudo make install",
+		}}
+	}
+
+	results := []Result{
+		checkRootOwned("wrapper", d.WrapperPath),
+		checkRootOwned("binary", d.BinaryPath),
+		d.checkSudoers(),
+		d.checkGroup(),
+	}
+	return results
+}
+
+// checkRootOwned applies the wrapper script's security check: the file must
+// exist, be owned by root, and not be writable by anyone else.
+func checkRootOwned(check, path string) Result {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return Result{Check: check, Status: StatusFail, Detail: fmt.Sprintf("%s: %v", path, errors.Unwrap(err)), Fix: "sudo make install"}
+	}
+	if !info.Mode().IsRegular() {
+		return Result{Check: check, Status: StatusFail, Detail: path + " is not a regular file", Fix: "sudo make install"}
+	}
+
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return Result{Check: check, Status: StatusWarn, Detail: "cannot determine owner of " + path}
+	}
+	if st.Uid != 0 {
+		return Result{
+			Check:  check,
+			Status: StatusFail,
+			Detail: fmt.Sprintf("%s is owned by uid %d, not root (the wrapper refuses to run)", path, st.Uid),
+			Fix:    "sudo chown root:root " + path,
+		}
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		return Result{
+			Check:  check,
+			Status: StatusFail,
+			Detail: fmt.Sprintf("%s is group/world writable (%04o)", path, info.Mode().Perm()),
+			Fix:    "sudo chmod 755 " + path,
+		}
+	}
+
+	return Result{Check: check, Status: StatusOK, Detail: fmt.Sprintf("%s owned by root, mode %04o", path, info.Mode().Perm())}
+}
+
+// checkSudoers verifies the sudoers drop-in's ownership, mode and rule.
+func (d *Doctor) checkSudoers() Result {
+	info, err := os.Stat(d.SudoersPath)
+	if err != nil {
+		return Result{
+			Check:  "sudoers",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("%s: %v", d.SudoersPath, errors.Unwrap(err)),
+			Fix:    "sudo install -Dm440 scripts/argus.sudoers " + d.SudoersPath,
+		}
+	}
+
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && st.Uid != 0 {
+		return Result{Check: "sudoers", Status: StatusFail, Detail: d.SudoersPath + " is not owned by root (sudo ignores it)", Fix: "sudo chown root:root " + d.SudoersPath}
+	}
+	if info.Mode().Perm() != 0440 {
+		return Result{
+			Check:  "sudoers",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("%s has mode %04o, sudo expects 0440", d.SudoersPath, info.Mode().Perm()),
+			Fix:    "sudo chmod 440 " + d.SudoersPath,
+		}
+	}
+
+	// Non-root users can't read a 0440 root file; ownership and mode are enough
+	f, err := os.Open(d.SudoersPath)
+	if err != nil {
+		return Result{Check: "sudoers", Status: StatusOK, Detail: fmt.Sprintf("%s owned by root, mode 0440", d.SudoersPath)}
+	}
+	defer f.Close()
+
+	want := "%" + d.GroupName
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, want) && strings.Contains(line, "NOPASSWD:") && strings.Contains(line, d.WrapperPath) {
+			return Result{Check: "sudoers", Status: StatusOK, Detail: "rule for " + want + " found"}
+		}
+	}
+
+	return Result{
+		Check:  "sudoers",
+		Status: StatusFail,
+		Detail: fmt.Sprintf("no %s NOPASSWD rule for %s in %s", want, d.WrapperPath, d.SudoersPath),
+		Fix:    "sudo install -Dm440 scripts/argus.sudoers " + d.SudoersPath,
+	}
+}
+
+// checkGroup verifies the argus-users group exists and the user is in it.
+func (d *Doctor) checkGroup() Result {
+	if _, err := user.LookupGroup(d.GroupName); err != nil {
+		return Result{Check: "group", Status: StatusFail, Detail: "group " + d.GroupName + " does not exist", Fix: "sudo groupadd " + d.GroupName}
+	}
+
+	if d.euid == 0 {
+		return Result{Check: "group", Status: StatusSkip, Detail: "running as root"}
+	}
+
+	if len(memberOf([]string{d.GroupName})) > 0 {
+		return Result{Check: "group", Status: StatusOK, Detail: "you are in " + d.GroupName}
+	}
+	if len(configuredGroups([]string{d.GroupName})) > 0 {
+		return Result{Check: "group", Status: StatusWarn, Detail: "you were added to " + d.GroupName + " but this session predates it", Fix: "log out and back in"}
+	}
+	return Result{Check: "group", Status: StatusWarn, Detail: "you are not in " + d.GroupName, Fix: "sudo usermod -aG " + d.GroupName + " $USER, then log out and back in"}
+}
+
+// ============================================================================
+// Group helpers
+// ============================================================================
+
+// memberOf returns which of names the current process is a member of.
+// This reflects the login session, not /etc/group.
+func memberOf(names []string) []string {
+	gids, err := os.Getgroups()
+	if err != nil {
+		return nil
+	}
+	gids = append(gids, os.Getegid())
+	return filterGroups(names, gids)
+}
+
+// configuredGroups returns which of names the user is listed in /etc/group,
+// even if the current session doesn't have them yet.
+func configuredGroups(names []string) []string {
+	u, err := user.Current()
+	if err != nil {
+		return nil
+	}
+	ids, err := u.GroupIds()
+	if err != nil {
+		return nil
+	}
+	var gids []int
+	for _, id := range ids {
+		if gid, err := strconv.Atoi(id); err == nil {
+			gids = append(gids, gid)
+		}
+	}
+	return filterGroups(names, gids)
+}
+
+// filterGroups returns the names whose group id appears in gids.
+func filterGroups(names []string, gids []int) []string {
+	var found []string
+	for _, name := range names {
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			continue
+		}
+		for _, gid := range gids {
+			if strconv.Itoa(gid) == g.Gid {
+				found = append(found, name)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// hasSourceType reports whether any enabled source has the given type.
+func (d *Doctor) hasSourceType(sourceType string) bool {
+	for _, src := range d.Config.EnabledSources() {
+		if src.Type == sourceType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package doctor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/Expert21/argus/internal/config"
+)
+
+// newTestDoctor returns a Doctor whose system paths point into a temp dir.
+func newTestDoctor(t *testing.T, cfg *config.Config) (*Doctor, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	d := New(cfg)
+	d.WrapperPath = filepath.Join(dir, "argus")
+	d.BinaryPath = filepath.Join(dir, "argus-bin")
+	d.SudoersPath = filepath.Join(dir, "sudoers")
+	d.InotifyDir = filepath.Join(dir, "inotify")
+	d.JournalDirs = []string{filepath.Join(dir, "journal")}
+	return d, dir
+}
+
+func writeFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	// WriteFile is subject to umask; force the exact mode
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCheckSources tests file, directory, fifo and kmsg source checks.
+func TestCheckSources(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "app.log")
+	writeFile(t, logFile, "hello\n", 0644)
+	pipe := filepath.Join(dir, "app.pipe")
+	if err := syscall.Mkfifo(pipe, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Sources: []config.SourceConfig{
+		{Name: "ok", Type: "file", Path: logFile, Enabled: true},
+		{Name: "missing", Type: "file", Path: filepath.Join(dir, "nope.log"), Enabled: true},
+		{Name: "dir as file", Type: "file", Path: dir, Enabled: true},
+		{Name: "file as dir", Type: "directory", Path: logFile, Enabled: true},
+		{Name: "dir", Type: "directory", Path: dir, Enabled: true},
+		{Name: "journal", Type: "journald", Enabled: true},
+		{Name: "kernel", Type: "kmsg", Path: logFile, Enabled: true},
+		{Name: "no kmsg", Type: "kmsg", Path: filepath.Join(dir, "kmsg"), Enabled: true},
+		{Name: "pipe", Type: "fifo", Path: pipe, Enabled: true},
+		{Name: "file as pipe", Type: "fifo", Path: logFile, Enabled: true},
+	}}
+
+	d, _ := newTestDoctor(t, cfg)
+	results := d.CheckSources()
+
+	want := []Status{StatusOK, StatusFail, StatusFail, StatusFail, StatusOK, StatusOK, StatusFail, StatusOK, StatusFail}
+	if len(results) != len(want) {
+		t.Fatalf("CheckSources() returned %d results, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("result %d (%s) = %v, want %v: %s", i, r.Check, r.Status, want[i], r.Detail)
+		}
+		if r.Status == StatusFail && r.Fix == "" {
+			t.Errorf("result %d (%s) failed without a fix", i, r.Check)
+		}
+	}
+}
+
+// TestCheckInotify tests the inotify limit comparison.
+func TestCheckInotify(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"a", "b", "c"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{Sources: []config.SourceConfig{
+		{Name: "f", Type: "file", Path: "/var/log/syslog", Enabled: true},
+		{Name: "d", Type: "directory", Path: dir, Glob: "*/*.log", Enabled: true},
+	}}
+
+	d, tmp := newTestDoctor(t, cfg)
+
+	// 1 file + 1 root dir + 3 subdirs = 5 watches, 2 instances
+	writeFile(t, filepath.Join(tmp, "inotify", "max_user_watches"), "4\n", 0644)
+	writeFile(t, filepath.Join(tmp, "inotify", "max_user_instances"), "128\n", 0644)
+
+	results := d.CheckInotify()
+	if len(results) != 2 {
+		t.Fatalf("CheckInotify() returned %d results, want 2", len(results))
+	}
+	if results[0].Status != StatusFail {
+		t.Errorf("watches = %v, want fail: %s", results[0].Status, results[0].Detail)
+	}
+	if results[1].Status != StatusOK {
+		t.Errorf("instances = %v, want ok: %s", results[1].Status, results[1].Detail)
+	}
+
+	writeFile(t, filepath.Join(tmp, "inotify", "max_user_watches"), "8192\n", 0644)
+	if r := d.CheckInotify()[0]; r.Status != StatusOK {
+		t.Errorf("watches with high limit = %v, want ok: %s", r.Status, r.Detail)
+	}
+}
+
+// TestCheckInstall tests the wrapper/sudoers checks.
+func TestCheckInstall(t *testing.T) {
+	d, _ := newTestDoctor(t, &config.Config{})
+
+	// Nothing installed: skipped, not failed
+	results := d.CheckInstall()
+	if len(results) != 1 || results[0].Status != StatusSkip {
+		t.Fatalf("CheckInstall() without wrapper = %+v, want single skip", results)
+	}
+
+	// A world-writable wrapper must fail whoever owns it
+	writeFile(t, d.WrapperPath, "#!/bin/bash\n", 0777)
+	if r := checkRootOwned("wrapper", d.WrapperPath); r.Status != StatusFail {
+		t.Errorf("checkRootOwned(0777) = %v, want fail", r.Status)
+	}
+
+	// Missing binary fails like the wrapper script would
+	if r := checkRootOwned("binary", d.BinaryPath); r.Status != StatusFail {
+		t.Errorf("checkRootOwned(missing) = %v, want fail", r.Status)
+	}
+
+	// Sudoers with the wrong mode fails
+	writeFile(t, d.SudoersPath, "%argus-users ALL=(ALL) NOPASSWD: "+d.WrapperPath+"\n", 0644)
+	if r := d.checkSudoers(); r.Status != StatusFail {
+		t.Errorf("checkSudoers(0644) = %v, want fail", r.Status)
+	}
+}
+
+// TestCheckJournalSkipped tests that journal checks are skipped without journald sources.
+func TestCheckJournalSkipped(t *testing.T) {
+	d, _ := newTestDoctor(t, &config.Config{Sources: []config.SourceConfig{
+		{Name: "f", Type: "file", Path: "/x", Enabled: true},
+	}})
+
+	results := d.CheckJournal()
+	if len(results) != 1 || results[0].Status != StatusSkip {
+		t.Errorf("CheckJournal() = %+v, want single skip", results)
+	}
+}
+
+// TestCheckJournalBackend tests the journal backend check with and
+// without journalctl on the PATH.
+func TestCheckJournalBackend(t *testing.T) {
+	tests := []struct {
+		name       string
+		backend    string
+		journalctl bool
+		want       Status
+	}{
+		{"journalctl found", "", true, StatusOK},
+		{"native fallback", "", false, StatusOK},
+		{"native configured", "native", false, StatusOK},
+		{"journalctl configured but missing", "journalctl", false, StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, _ := newTestDoctor(t, &config.Config{Sources: []config.SourceConfig{
+				{Name: "j", Type: "journald", Backend: tt.backend, Enabled: true},
+			}})
+			d.lookPath = func(file string) (string, error) {
+				if tt.journalctl {
+					return "/usr/bin/" + file, nil
+				}
+				return "", exec.ErrNotFound
+			}
+
+			results := d.CheckJournal()
+			if len(results) == 0 || results[0].Status != tt.want {
+				t.Errorf("CheckJournal() = %+v, want first result %v", results, tt.want)
+			}
+		})
+	}
+}
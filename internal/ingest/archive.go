@@ -0,0 +1,178 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLineSize bounds a single line read from an archive.
+const maxLineSize = 1 << 20
+
+// archiveSuffix matches what logrotate appends to a rotated file: a
+// rotation count (".1") or a dateext stamp ("-20240118"), optionally
+// followed by a compression extension.
+var archiveSuffix = regexp.MustCompile(`^[.-](\d+)(\.gz|\.bz2)?$`)
+
+// findArchives returns the rotated siblings of path, oldest first.
+//
+// Numbered archives get older as the number grows (auth.log.3.gz is older
+// than auth.log.1); dateext archives sort by their date. Compression
+// formats we can't read (.xz, .zst) don't match and are skipped.
+func findArchives(path string) ([]string, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	items, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+
+	type archive struct {
+		path  string
+		dated bool
+		num   uint64
+	}
+	var found []archive
+	for _, item := range items {
+		name := item.Name()
+		if item.IsDir() || !strings.HasPrefix(name, base) {
+			continue
+		}
+		m := archiveSuffix.FindStringSubmatch(name[len(base):])
+		if m == nil {
+			continue
+		}
+		num, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		found = append(found, archive{
+			path:  filepath.Join(dir, name),
+			dated: len(m[1]) >= 8, // YYYYMMDD or longer
+			num:   num,
+		})
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.dated != b.dated {
+			return a.dated // Mixed naming: treat dated ones as the older scheme
+		}
+		if a.dated {
+			return a.num < b.num
+		}
+		return a.num > b.num
+	})
+
+	paths := make([]string, len(found))
+	for i, a := range found {
+		paths[i] = a.path
+	}
+	return paths, nil
+}
+
+// openArchive opens a rotated file, decompressing it by extension.
+func openArchive(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch filepath.Ext(path) {
+	case ".gz":
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{zr, file}, nil
+	case ".bz2":
+		return struct {
+			io.Reader
+			io.Closer
+		}{bzip2.NewReader(file), file}, nil
+	default:
+		return file, nil
+	}
+}
+
+// scanLines passes every non-empty line of r to emit, stopping early if
+// emit returns false.
+func scanLines(r io.Reader, emit func(line string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if !emit(line) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// readArchive returns the last max lines of an archive that are not older
+// than since (zero means no bound). Archives can't be read backwards, so
+// the whole file is decompressed and only the tail kept. complete is true
+// when nothing was cut off, meaning older archives may hold more history.
+func readArchive(parser *lineParser, path string, max int, since time.Time) (lines []string, complete bool, err error) {
+	r, err := openArchive(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer r.Close()
+
+	complete = true
+	inRange := since.IsZero()
+	err = scanLines(r, func(line string) bool {
+		// Lines without a timestamp follow the line before them
+		if ts, ok := parser.timestamp(line); ok && !since.IsZero() {
+			inRange = !ts.Before(since)
+		}
+		if !inRange {
+			complete = false
+			return true
+		}
+		lines = append(lines, line)
+		// Compact now and then rather than per line
+		if max > 0 && len(lines) >= 2*max {
+			lines = append(lines[:0], lines[len(lines)-max:]...)
+			complete = false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", path, err)
+	}
+	if max > 0 && len(lines) > max {
+		lines = lines[len(lines)-max:]
+		complete = false
+	}
+	return lines, complete, nil
+}
+
+// modTime returns a file's modification time, or now if it can't be read.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Now()
+	}
+	return info.ModTime()
+}
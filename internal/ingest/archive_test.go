@@ -0,0 +1,183 @@
+package ingest
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFindArchives tests that rotated siblings are found oldest first.
+func TestFindArchives(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"auth.log", "auth.log.1", "auth.log.2.gz", "auth.log.3.bz2", "auth.log.10.gz",
+		"auth.log.4.xz", "auth.log.bak", "authx.log.1", "other.log.1",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := findArchives(filepath.Join(dir, "auth.log"))
+	if err != nil {
+		t.Fatalf("findArchives() error: %v", err)
+	}
+	want := []string{"auth.log.10.gz", "auth.log.3.bz2", "auth.log.2.gz", "auth.log.1"}
+	if len(got) != len(want) {
+		t.Fatalf("findArchives() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if filepath.Base(got[i]) != want[i] {
+			t.Errorf("findArchives()[%d] = %s, want %s", i, filepath.Base(got[i]), want[i])
+		}
+	}
+
+	// dateext names sort by date
+	dir = t.TempDir()
+	for _, name := range []string{"syslog", "syslog-20240120.gz", "syslog-20240118", "syslog-20240119.gz"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, _ = findArchives(filepath.Join(dir, "syslog"))
+	if len(got) != 3 || filepath.Base(got[0]) != "syslog-20240118" || filepath.Base(got[2]) != "syslog-20240120.gz" {
+		t.Errorf("findArchives() dateext = %v", got)
+	}
+}
+
+// writeRotated lays out a live file plus .1, .2.gz and .3.bz2 archives,
+// one hour apart per line, and returns the live path.
+func writeRotated(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "auth.log")
+
+	bz, err := os.ReadFile(filepath.Join("testdata", "auth.log.3.bz2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".3.bz2", bz, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Create(path + ".2.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	zw.Write([]byte("Jan 16 08:00:00 host sshd[1]: from gzip\n  continuation in gzip\n"))
+	zw.Close()
+	f.Close()
+
+	appendLine(t, path+".1", "Jan 17 08:00:00 host sshd[1]: from plain archive")
+	appendLine(t, path, "Jan 18 08:00:00 host sshd[1]: live one")
+	appendLine(t, path, "Jan 18 09:00:00 host sshd[1]: live two")
+	return path
+}
+
+// TestFileIngestorReadHistory tests one-shot reads across archives.
+func TestFileIngestorReadHistory(t *testing.T) {
+	year := time.Now().Year()
+	tests := []struct {
+		name string
+		cfg  SourceConfig
+		want []string
+	}{
+		{
+			name: "everything",
+			cfg:  SourceConfig{Archives: true},
+			want: []string{
+				"oldest from bz2", "second from bz2", "from gzip", "  continuation in gzip",
+				"from plain archive", "live one", "live two",
+			},
+		},
+		{
+			name: "live file only",
+			cfg:  SourceConfig{},
+			want: []string{"live one", "live two"},
+		},
+		{
+			name: "last lines span archives",
+			cfg:  SourceConfig{Archives: true, BackfillLines: 4},
+			want: []string{"  continuation in gzip", "from plain archive", "live one", "live two"},
+		},
+		{
+			name: "since inside an archive",
+			cfg:  SourceConfig{Archives: true, BackfillSince: fmt.Sprintf("%d-01-15T08:30:00Z", year)},
+			want: []string{
+				"second from bz2", "from gzip", "  continuation in gzip",
+				"from plain archive", "live one", "live two",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Name, cfg.Type, cfg.Path = "Auth", SourceFile, writeRotated(t)
+			ing := NewFileIngestor(cfg)
+
+			entries := make(chan LogEntry, 20)
+			if err := ing.ReadHistory(context.Background(), entries); err != nil {
+				t.Fatalf("ReadHistory() error: %v", err)
+			}
+			close(entries)
+
+			var got []string
+			var prev LogEntry
+			for e := range entries {
+				got = append(got, e.Message)
+				if e.Timestamp.Before(prev.Timestamp) {
+					t.Errorf("%q is timestamped before %q", e.Message, prev.Message)
+				}
+				prev = e
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("ReadHistory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReadArchive tests limits and since-bounds on a compressed archive.
+func TestReadArchive(t *testing.T) {
+	path := writeRotated(t) + ".3.bz2"
+	year := time.Now().Year()
+
+	tests := []struct {
+		name     string
+		max      int
+		since    time.Time
+		want     []string
+		complete bool
+	}{
+		{"all", 10, time.Time{}, []string{"oldest from bz2", "second from bz2"}, true},
+		{"cut by max", 1, time.Time{}, []string{"second from bz2"}, false},
+		{"cut by since", 10, time.Date(year, 1, 15, 8, 30, 0, 0, time.UTC), []string{"second from bz2"}, false},
+	}
+
+	parser, err := newLineParser(SourceConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, complete, err := readArchive(parser, path, tt.max, tt.since)
+			if err != nil {
+				t.Fatalf("readArchive() error: %v", err)
+			}
+			ok := len(lines) == len(tt.want) && complete == tt.complete
+			for i := 0; ok && i < len(lines); i++ {
+				ok = strings.HasSuffix(lines[i], tt.want[i])
+			}
+			if !ok {
+				t.Errorf("readArchive() = %q, complete %v; want %q, complete %v", lines, complete, tt.want, tt.complete)
+			}
+		})
+	}
+}
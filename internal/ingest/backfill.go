@@ -0,0 +1,247 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// backfillBlockSize is how much is read per step when scanning backwards
+	backfillBlockSize = 8192
+
+	// MaxBackfillLines caps a since-only backfill so a huge file can't
+	// exhaust memory; the newest lines win.
+	MaxBackfillLines = 50000
+)
+
+// ParseSince interprets a backfill_since value relative to now.
+// It accepts a Go duration ("2h", "30m") meaning "that long ago", or an
+// absolute time in RFC 3339 or "2006-01-02 15:04:05" (local time) form.
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			d = -d
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid since value %q (use a duration like 2h or a timestamp)", value)
+}
+
+// validateBackfill checks the backfill options shared by file sources.
+func validateBackfill(config SourceConfig) error {
+	if config.BackfillLines < 0 {
+		return fmt.Errorf("backfill_lines must not be negative")
+	}
+	if config.BackfillSince != "" {
+		if _, err := ParseSince(config.BackfillSince, time.Now()); err != nil {
+			return fmt.Errorf("backfill_since: %w", err)
+		}
+	}
+	return nil
+}
+
+// wantsBackfill reports whether the source asks for history at startup.
+func wantsBackfill(config SourceConfig) bool {
+	return config.BackfillLines > 0 || config.BackfillSince != ""
+}
+
+// readTail returns the last lines of r that end at or before offset end,
+// oldest first. It reads backwards in blocks so only the tail of a large
+// file is touched. Scanning stops once max lines are collected (max <= 0
+// means no limit) or keep returns false for a line; that line and
+// everything before it are dropped. complete reports that the start of
+// the file was reached without stopping.
+func readTail(r io.ReaderAt, end int64, max int, keep func(line string) bool) (lines []string, complete bool, err error) {
+	var (
+		reversed []string // Newest first
+		carry    []byte   // Start of a line that began in an earlier block
+		pos      = end
+		done     bool
+	)
+
+	// add records one line; it returns false when scanning should stop
+	add := func(raw []byte) bool {
+		line := strings.TrimRight(string(raw), "\r")
+		if line == "" {
+			return true
+		}
+		if keep != nil && !keep(line) {
+			return false
+		}
+		reversed = append(reversed, line)
+		return max <= 0 || len(reversed) < max
+	}
+
+	for pos > 0 && !done {
+		size := int64(backfillBlockSize)
+		if pos < size {
+			size = pos
+		}
+		pos -= size
+
+		block := make([]byte, size, int(size)+len(carry))
+		if _, err := r.ReadAt(block, pos); err != nil && err != io.EOF {
+			return nil, false, err
+		}
+		block = append(block, carry...)
+
+		// Peel complete lines off the end of the block
+		for !done {
+			i := bytes.LastIndexByte(block, '\n')
+			if i < 0 {
+				break
+			}
+			done = !add(block[i+1:])
+			block = block[:i]
+		}
+		carry = block
+	}
+
+	// Whatever is left at offset 0 is the file's first line
+	if !done && pos == 0 && len(carry) > 0 {
+		done = !add(carry)
+	}
+
+	// Reverse into chronological order
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	return reversed, !done, nil
+}
+
+// historyChunk is a run of lines read from one file, oldest first.
+type historyChunk struct {
+	path  string
+	lines []string
+}
+
+// backfill reads the history the source asked for from the part of the
+// tailer's file before its current offset and, if the source includes
+// archives and the live file didn't cover it, from rotated copies.
+// Chunks are returned oldest first.
+func (t *tailer) backfill(parser *lineParser) ([]historyChunk, error) {
+	config := parser.config
+	max := config.BackfillLines
+	var (
+		since time.Time
+		keep  func(string) bool
+	)
+
+	if config.BackfillSince != "" {
+		var err error
+		since, err = ParseSince(config.BackfillSince, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if max <= 0 {
+			max = MaxBackfillLines
+		}
+		// Lines without a timestamp are kept; they belong to a newer entry
+		keep = func(line string) bool {
+			ts, ok := parser.timestamp(line)
+			return !ok || !ts.Before(since)
+		}
+	}
+
+	lines, complete, err := readTail(t.file, t.offset, max, keep)
+	if err != nil {
+		return nil, err
+	}
+	chunks := []historyChunk{{path: t.path, lines: lines}}
+	if !config.Archives || !complete {
+		return chunks, nil
+	}
+
+	archives, err := findArchives(t.path)
+	if err != nil {
+		return chunks, nil
+	}
+	need := max - len(lines)
+	for i := len(archives) - 1; i >= 0 && need > 0 && complete; i-- {
+		lines, complete, err = readArchive(parser, archives[i], need, since)
+		if err != nil {
+			// A corrupt archive shouldn't hide the rest of the history
+			complete = true
+			continue
+		}
+		chunks = append([]historyChunk{{path: archives[i], lines: lines}}, chunks...)
+		need -= len(lines)
+	}
+	return chunks, nil
+}
+
+// historyEntries parses historical lines into entries, oldest first,
+// joining multi-line entries if the source asks for it. Entries without
+// a timestamp inherit the previous one's, so continuation lines sort
+// with their entry; leading ones take the first timestamp found, or the
+// first file's modification time if no line has one.
+func historyEntries(parser *lineParser, chunks []historyChunk) []LogEntry {
+	var last time.Time
+	if len(chunks) > 0 {
+		last = modTime(chunks[0].path)
+	}
+	count := 0
+	found := false
+	for _, chunk := range chunks {
+		count += len(chunk.lines)
+		// Only the first timestamp is needed; every line is parsed below
+		for _, line := range chunk.lines {
+			if found {
+				break
+			}
+			if ts, ok := parser.timestamp(line); ok {
+				last, found = ts, true
+			}
+		}
+	}
+
+	result := make([]LogEntry, 0, count)
+	for _, chunk := range chunks {
+		add := func(lines []string) {
+			entry := parser.groupEntry(chunk.path, lines)
+			entry.Historical = true
+			if entry.Timestamp.IsZero() {
+				entry.Timestamp = last
+			} else {
+				last = entry.Timestamp
+			}
+			result = append(result, entry)
+		}
+
+		// An entry doesn't run on from one file into the next
+		group := lineGroup{rules: parser.multiline}
+		for _, line := range chunk.lines {
+			if lines := group.add(line); lines != nil {
+				add(lines)
+			}
+		}
+		if lines := group.flush(); lines != nil {
+			add(lines)
+		}
+	}
+	return result
+}
+
+// sendAll sends entries in order, stopping if the context is cancelled.
+func sendAll(ctx context.Context, entries chan<- LogEntry, batch []LogEntry) bool {
+	for _, entry := range batch {
+		if !sendEntry(ctx, entries, entry) {
+			return false
+		}
+	}
+	return true
+}
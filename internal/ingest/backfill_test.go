@@ -0,0 +1,152 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReadTail tests reading the last lines of a file backwards in blocks.
+func TestReadTail(t *testing.T) {
+	// Enough lines to span several blocks
+	var lines []string
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, fmt.Sprintf("line %04d %s", i, strings.Repeat("x", 20)))
+	}
+	content := strings.Join(lines, "\n") + "\n"
+
+	tests := []struct {
+		name    string
+		content string
+		max     int
+		want    []string
+	}{
+		{"last three", content, 3, lines[1997:]},
+		{"across blocks", content, 900, lines[1100:]},
+		{"whole file", content, 0, lines},
+		{"more than file", "a\nb\n", 10, []string{"a", "b"}},
+		{"no trailing newline", "a\nb\nc", 2, []string{"b", "c"}},
+		{"crlf and blanks", "a\r\n\r\nb\r\n", 5, []string{"a", "b"}},
+		{"empty", "", 5, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := strings.NewReader(tt.content)
+			got, _, err := readTail(r, int64(len(tt.content)), tt.max, nil)
+			if err != nil {
+				t.Fatalf("readTail() error: %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("readTail() = %d lines %v..., want %d lines", len(got), head(got), len(tt.want))
+			}
+		})
+	}
+}
+
+// head returns up to the first three lines, for error messages.
+func head(lines []string) []string {
+	if len(lines) > 3 {
+		return lines[:3]
+	}
+	return lines
+}
+
+// TestParseSince tests durations and absolute backfill_since values.
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2h", now.Add(-2 * time.Hour), false},
+		{"30m", now.Add(-30 * time.Minute), false},
+		{"2026-03-10T09:00:00Z", time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC), false},
+		{"2026-03-10 08:15:00", time.Date(2026, 3, 10, 8, 15, 0, 0, time.Local), false},
+		{"2026-03-09", time.Date(2026, 3, 9, 0, 0, 0, 0, time.Local), false},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSince(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSince(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseSince(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFileIngestorBackfill tests that history is emitted with its own
+// timestamps before live lines.
+func TestFileIngestorBackfill(t *testing.T) {
+	year := time.Now().Year()
+
+	tests := []struct {
+		name  string
+		cfg   SourceConfig
+		want  []string
+		times []int // Seconds past 15:04:00
+	}{
+		{
+			name:  "lines",
+			cfg:   SourceConfig{BackfillLines: 2},
+			want:  []string{"  continuation of second", "third"},
+			times: []int{3, 3}, // No earlier timestamp in reach: take the next one
+		},
+		{
+			name:  "since",
+			cfg:   SourceConfig{BackfillSince: fmt.Sprintf("%d-01-18T15:04:02Z", year)},
+			want:  []string{"second", "  continuation of second", "third"},
+			times: []int{2, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "auth.log")
+			appendLine(t, path, "Jan 18 15:04:01 host sshd[1]: first")
+			appendLine(t, path, "Jan 18 15:04:02 host sshd[1]: second")
+			appendLine(t, path, "  continuation of second")
+			appendLine(t, path, "Jan 18 15:04:03 host sshd[1]: third")
+
+			cfg := tt.cfg
+			cfg.Name, cfg.Type, cfg.Path = "Auth", SourceFile, path
+			ing := NewFileIngestor(cfg)
+
+			entries := make(chan LogEntry, 10)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := ing.Start(ctx, entries); err != nil {
+				t.Fatalf("Start() error: %v", err)
+			}
+			defer ing.Stop()
+
+			for i, msg := range tt.want {
+				e := waitEntry(t, entries)
+				if e.Message != msg {
+					t.Errorf("entry %d Message = %q, want %q", i, e.Message, msg)
+				}
+				want := time.Date(year, 1, 18, 15, 4, tt.times[i], 0, time.UTC)
+				if !e.Timestamp.Equal(want) {
+					t.Errorf("entry %d Timestamp = %v, want %v", i, e.Timestamp, want)
+				}
+			}
+
+			// Live tailing continues after the history
+			line := "Jan 18 15:04:09 host sshd[1]: live"
+			appendLine(t, path, line)
+			if e := waitEntry(t, entries); e.Raw != line {
+				t.Errorf("live entry = %q, want %q", e.Raw, line)
+			}
+		})
+	}
+}
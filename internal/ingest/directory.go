@@ -0,0 +1,369 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultGlobPattern is used when a directory source sets no glob.
+const DefaultGlobPattern = "*.log"
+
+func init() {
+	Register(SourceDirectory, "directory", func(config SourceConfig) (Ingestor, error) {
+		return NewDirectoryIngestor(config), nil
+	}, validateDirectory)
+}
+
+// validateDirectory checks directory-specific fields.
+func validateDirectory(config SourceConfig) error {
+	if err := validatePathSource(config); err != nil {
+		return err
+	}
+	if config.GlobPattern != "" {
+		if filepath.IsAbs(config.GlobPattern) {
+			return fmt.Errorf("glob %q must be relative to path", config.GlobPattern)
+		}
+		if _, err := filepath.Match(config.GlobPattern, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", config.GlobPattern, err)
+		}
+	}
+	return nil
+}
+
+// DirectoryIngestor tails every file under a directory that matches a glob.
+//
+// The glob may span subdirectories ("*/*.log" for Docker containers).
+// A single fsnotify watcher watches the root and every intermediate
+// directory the glob can descend into; inotify reports writes to files
+// inside a watched directory, so files themselves are never watched.
+// This keeps the ingestor to one inotify instance no matter how many
+// files match.
+type DirectoryIngestor struct {
+	config   SourceConfig
+	parser   *lineParser
+	segments []string // Glob split on "/", one element per directory level
+	watcher  *fsnotify.Watcher
+	tailers  map[string]*tailer    // Keyed by file path; written only by the watch goroutine
+	lines    map[string]*assembler // Each file's multi-line entries; watch goroutine only
+	mu       sync.Mutex            // Protects healthy and writes to tailers
+	healthy  bool
+	cancel   context.CancelFunc
+}
+
+// NewDirectoryIngestor creates a new directory-watching ingestor.
+func NewDirectoryIngestor(config SourceConfig) *DirectoryIngestor {
+	if config.GlobPattern == "" {
+		config.GlobPattern = DefaultGlobPattern
+	}
+	// Validated already
+	parser, _ := newLineParser(config)
+	return &DirectoryIngestor{
+		config:   config,
+		parser:   parser,
+		segments: strings.Split(filepath.ToSlash(filepath.Clean(config.GlobPattern)), "/"),
+		tailers:  make(map[string]*tailer),
+		lines:    make(map[string]*assembler),
+	}
+}
+
+// Name returns the human-readable name of this source.
+func (d *DirectoryIngestor) Name() string {
+	return d.config.Name
+}
+
+// Healthy returns true if the ingestor is functioning normally.
+func (d *DirectoryIngestor) Healthy() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.healthy
+}
+
+func (d *DirectoryIngestor) setHealthy(healthy bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.healthy = healthy
+}
+
+// Start scans the directory, starts tailing matching files from their
+// current end, and watches for files created or deleted later.
+func (d *DirectoryIngestor) Start(ctx context.Context, entries chan<- LogEntry) error {
+	ctx, d.cancel = context.WithCancel(ctx)
+
+	info, err := os.Stat(d.config.Path)
+	if err != nil {
+		return fmt.Errorf("directory not accessible: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", d.config.Path)
+	}
+
+	d.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	// Existing files only report new lines, like a FileIngestor
+	if err := d.scanDir(d.config.Path, 0, false); err != nil {
+		d.closeAll()
+		return err
+	}
+
+	d.setHealthy(true)
+	go d.watchLoop(ctx, entries)
+
+	return nil
+}
+
+// scanDir watches dir (at the given depth below the root) and picks up
+// every matching file and subdirectory inside it.
+func (d *DirectoryIngestor) scanDir(dir string, depth int, fromStart bool) error {
+	if err := d.watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	items, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	last := depth == len(d.segments)-1
+	for _, item := range items {
+		if ok, _ := filepath.Match(d.segments[depth], item.Name()); !ok {
+			continue
+		}
+		path := filepath.Join(dir, item.Name())
+
+		switch {
+		case item.IsDir() && !last:
+			// Subdirectories that vanish mid-scan aren't fatal
+			_ = d.scanDir(path, depth+1, fromStart)
+		case !item.IsDir() && last:
+			d.addFile(path, fromStart)
+		}
+	}
+	return nil
+}
+
+// addFile starts tailing a matching file if it isn't tailed already.
+func (d *DirectoryIngestor) addFile(path string, fromStart bool) *tailer {
+	if t, ok := d.tailers[path]; ok {
+		return t
+	}
+	t, err := openTailer(path, fromStart)
+	if err != nil {
+		// Unreadable files are skipped; the rest of the directory still works
+		return nil
+	}
+	d.mu.Lock()
+	d.tailers[path] = t
+	d.mu.Unlock()
+	return t
+}
+
+// removeFile stops tailing a file, first draining any unread lines.
+func (d *DirectoryIngestor) removeFile(ctx context.Context, path string, entries chan<- LogEntry) {
+	t, ok := d.tailers[path]
+	if !ok {
+		return
+	}
+	d.readFile(ctx, t, entries)
+	if lines, ok := d.lines[path]; ok {
+		lines.flush()
+		lines.stop()
+		delete(d.lines, path)
+	}
+	t.close()
+	d.mu.Lock()
+	delete(d.tailers, path)
+	d.mu.Unlock()
+}
+
+// removeDir drops every tailer under a directory that went away.
+func (d *DirectoryIngestor) removeDir(ctx context.Context, dir string, entries chan<- LogEntry) {
+	prefix := dir + string(filepath.Separator)
+	for path := range d.tailers {
+		if strings.HasPrefix(path, prefix) {
+			d.removeFile(ctx, path, entries)
+		}
+	}
+}
+
+// depthOf returns how many directory levels path is below the root,
+// or -1 if it is outside the root.
+func (d *DirectoryIngestor) depthOf(path string) int {
+	rel, err := filepath.Rel(d.config.Path, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return -1
+	}
+	return strings.Count(filepath.ToSlash(rel), "/") + 1
+}
+
+// matches reports whether path (depth levels down) matches the glob
+// segment for its level.
+func (d *DirectoryIngestor) matches(path string, depth int) bool {
+	if depth < 1 || depth > len(d.segments) {
+		return false
+	}
+	ok, _ := filepath.Match(d.segments[depth-1], filepath.Base(path))
+	return ok
+}
+
+// watchLoop handles fsnotify events for the whole tree.
+func (d *DirectoryIngestor) watchLoop(ctx context.Context, entries chan<- LogEntry) {
+	defer d.setHealthy(false)
+	defer d.closeAll()
+
+	if wantsBackfill(d.config) && !d.sendBackfill(ctx, entries) {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-d.watcher.Events:
+			if !ok {
+				return
+			}
+			d.handleEvent(ctx, event, entries)
+
+		case _, ok := <-d.watcher.Errors:
+			if !ok {
+				return
+			}
+			// Overflow or a watch failing; keep going with what we have
+		}
+	}
+}
+
+// handleEvent applies a single fsnotify event.
+func (d *DirectoryIngestor) handleEvent(ctx context.Context, event fsnotify.Event, entries chan<- LogEntry) {
+	path := event.Name
+
+	switch {
+	case event.Op&fsnotify.Create == fsnotify.Create:
+		depth := d.depthOf(path)
+		if !d.matches(path, depth) {
+			return
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return
+		}
+		if info.IsDir() {
+			if depth < len(d.segments) {
+				// New files found while scanning are read from the start
+				_ = d.scanDir(path, depth, true)
+				d.readAll(ctx, entries)
+			}
+			return
+		}
+		if depth == len(d.segments) {
+			if t := d.addFile(path, true); t != nil {
+				d.readFile(ctx, t, entries)
+			}
+		}
+
+	case event.Op&fsnotify.Write == fsnotify.Write:
+		if t, ok := d.tailers[path]; ok {
+			d.readFile(ctx, t, entries)
+		}
+
+	case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		// A renamed file is gone from the glob's point of view too
+		d.removeFile(ctx, path, entries)
+		d.removeDir(ctx, path, entries)
+	}
+}
+
+// sendBackfill emits the requested history of every file found at startup,
+// merged into timestamp order.
+func (d *DirectoryIngestor) sendBackfill(ctx context.Context, entries chan<- LogEntry) bool {
+	var history []LogEntry
+	for _, path := range d.Files() {
+		t := d.tailers[path]
+		chunks, err := t.backfill(d.parser)
+		if err != nil {
+			continue
+		}
+		history = append(history, historyEntries(d.parser, chunks)...)
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Timestamp.Before(history[j].Timestamp)
+	})
+	return sendAll(ctx, entries, history)
+}
+
+// readAll reads pending lines from every tailer, in path order.
+func (d *DirectoryIngestor) readAll(ctx context.Context, entries chan<- LogEntry) {
+	for _, path := range d.Files() {
+		if t, ok := d.tailers[path]; ok {
+			d.readFile(ctx, t, entries)
+		}
+	}
+}
+
+// readFile sends every new line of one file.
+func (d *DirectoryIngestor) readFile(ctx context.Context, t *tailer, entries chan<- LogEntry) {
+	lines, ok := d.lines[t.path]
+	if !ok {
+		lines = d.parser.newAssembler(func(entry LogEntry) bool {
+			return sendEntry(ctx, entries, entry)
+		})
+		d.lines[t.path] = lines
+	}
+	_, _ = t.readLines(func(line string) bool {
+		return lines.add(t.path, line, false)
+	})
+}
+
+// Files returns the paths currently being tailed, sorted.
+func (d *DirectoryIngestor) Files() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	paths := make([]string, 0, len(d.tailers))
+	for path := range d.tailers {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// closeAll closes the watcher and every tailed file.
+func (d *DirectoryIngestor) closeAll() {
+	if d.watcher != nil {
+		d.watcher.Close()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for path, t := range d.tailers {
+		t.close()
+		delete(d.tailers, path)
+	}
+	for path, lines := range d.lines {
+		lines.stop()
+		delete(d.lines, path)
+	}
+}
+
+// Stop gracefully shuts down the ingestor.
+func (d *DirectoryIngestor) Stop() error {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	return nil
+}
+
+// Ensure DirectoryIngestor implements Ingestor
+var _ Ingestor = (*DirectoryIngestor)(nil)
@@ -0,0 +1,137 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// appendLine appends a line to a file, creating it if needed.
+func appendLine(t *testing.T, path, line string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitEntry waits for the next entry or fails the test.
+func waitEntry(t *testing.T, entries <-chan LogEntry) LogEntry {
+	t.Helper()
+	select {
+	case e := <-entries:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for entry")
+		return LogEntry{}
+	}
+}
+
+// waitFor polls cond until it is true or fails the test.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestDirectoryIngestorNestedGlob tests existing, new, and deleted files
+// under a nested glob like Docker's "*/*.log".
+func TestDirectoryIngestorNestedGlob(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, "abc", "abc-json.log")
+	if err := os.Mkdir(filepath.Join(root, "abc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	appendLine(t, existing, "old line before start")
+	appendLine(t, filepath.Join(root, "top.log"), "wrong depth")
+
+	ing := NewDirectoryIngestor(SourceConfig{
+		Name:        "Docker",
+		Type:        SourceDirectory,
+		Path:        root,
+		GlobPattern: "*/*.log",
+	})
+
+	entries := make(chan LogEntry, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := ing.Start(ctx, entries); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer ing.Stop()
+
+	if files := ing.Files(); len(files) != 1 || files[0] != existing {
+		t.Fatalf("Files() = %v, want [%s]", files, existing)
+	}
+
+	// Existing file: only new lines
+	appendLine(t, existing, "new line")
+	e := waitEntry(t, entries)
+	if e.Message != "new line" {
+		t.Errorf("Message = %q, want %q", e.Message, "new line")
+	}
+	if e.Metadata["path"] != existing {
+		t.Errorf("Metadata[path] = %q, want %q", e.Metadata["path"], existing)
+	}
+	if e.SourceType != SourceDirectory || e.IngestorName != "Docker" {
+		t.Errorf("entry source = %v/%q, want directory/Docker", e.SourceType, e.IngestorName)
+	}
+
+	// New container directory with a new file: read from the start
+	newDir := filepath.Join(root, "def")
+	if err := os.Mkdir(newDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Whether the file lands before or after the directory watch is added,
+	// it must be read from the start
+	newFile := filepath.Join(newDir, "def-json.log")
+	appendLine(t, newFile, "from new container")
+
+	e = waitEntry(t, entries)
+	if e.Message != "from new container" || e.Metadata["path"] != newFile {
+		t.Errorf("entry = %q from %q, want new container line from %q", e.Message, e.Metadata["path"], newFile)
+	}
+
+	// Deleted files are dropped
+	if err := os.Remove(existing); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "deleted file to be dropped", func() bool { return len(ing.Files()) == 1 })
+	if files := ing.Files(); files[0] != newFile {
+		t.Errorf("Files() = %v, want [%s]", files, newFile)
+	}
+}
+
+// TestValidateDirectory tests directory source validation.
+func TestValidateDirectory(t *testing.T) {
+	tests := []struct {
+		name    string
+		glob    string
+		wantErr bool
+	}{
+		{"default glob", "", false},
+		{"nested glob", "*/*.log", false},
+		{"absolute glob", "/var/log/*.log", true},
+		{"malformed glob", "[", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(SourceConfig{Name: "d", Type: SourceDirectory, Path: "/tmp", GlobPattern: tt.glob})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FifoIngestor reads lines from a named pipe (mkfifo), for programs that
+// write their log to a path. When the last writer closes the pipe, the
+// pipe is opened again to wait for the next one, so a service restarting
+// or a script run again keeps feeding the same source.
+type FifoIngestor struct {
+	config  SourceConfig
+	parser  *lineParser
+	file    *os.File // Open pipe, if a writer is connected; guarded by mu
+	mu      sync.Mutex
+	healthy bool
+	cancel  context.CancelFunc
+}
+
+func init() {
+	Register(SourceFifo, "fifo", func(config SourceConfig) (Ingestor, error) {
+		return NewFifoIngestor(config), nil
+	}, validateFifo)
+}
+
+// validateFifo checks fifo-specific fields.
+func validateFifo(config SourceConfig) error {
+	if config.Path == "" {
+		return fmt.Errorf("path is required for type %s", config.Type)
+	}
+	return validateParsers(config)
+}
+
+// NewFifoIngestor creates a named pipe ingestor.
+func NewFifoIngestor(config SourceConfig) *FifoIngestor {
+	// Validated already
+	parser, _ := newLineParser(config)
+	return &FifoIngestor{config: config, parser: parser}
+}
+
+// Name returns the human-readable name of this source.
+func (f *FifoIngestor) Name() string {
+	return f.config.Name
+}
+
+// Healthy returns true if the ingestor is functioning normally.
+func (f *FifoIngestor) Healthy() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.healthy
+}
+
+func (f *FifoIngestor) setHealthy(healthy bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.healthy = healthy
+}
+
+// setFile records the open pipe so Stop can close it.
+func (f *FifoIngestor) setFile(file *os.File) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.file = file
+}
+
+// Start checks the path is a named pipe and reads from it, reopening it
+// each time its writers go away, until the context is cancelled.
+func (f *FifoIngestor) Start(ctx context.Context, entries chan<- LogEntry) error {
+	ctx, f.cancel = context.WithCancel(ctx)
+
+	info, err := os.Stat(f.config.Path)
+	if err != nil {
+		return fmt.Errorf("fifo not accessible: %w", err)
+	}
+	if info.Mode()&os.ModeNamedPipe == 0 {
+		return fmt.Errorf("%s is not a named pipe (create it with mkfifo)", f.config.Path)
+	}
+
+	f.setHealthy(true)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer f.setHealthy(false)
+		f.readLoop(ctx, entries)
+	}()
+	go f.closeOnCancel(ctx, done)
+	return nil
+}
+
+// readLoop opens the pipe, reads until every writer has closed it, and
+// starts over.
+func (f *FifoIngestor) readLoop(ctx context.Context, entries chan<- LogEntry) {
+	lines := f.parser.newAssembler(func(entry LogEntry) bool {
+		return sendEntry(ctx, entries, entry)
+	})
+	defer lines.stop()
+
+	for ctx.Err() == nil {
+		// Blocks until a writer opens the other end
+		file, err := os.OpenFile(f.config.Path, os.O_RDONLY, 0)
+		if err != nil {
+			return
+		}
+		f.setFile(file)
+		err = scanLines(file, func(line string) bool {
+			return lines.add(f.config.Path, line, false)
+		})
+		// A writer closing the pipe ends its last entry
+		lines.flush()
+		f.setFile(nil)
+		file.Close()
+		if err != nil && ctx.Err() == nil {
+			return
+		}
+	}
+}
+
+// closeOnCancel wakes readLoop once the context is cancelled: a read is
+// ended by closing the pipe, and an open waiting for a writer by briefly
+// becoming one, until readLoop is done.
+func (f *FifoIngestor) closeOnCancel(ctx context.Context, done <-chan struct{}) {
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+
+	f.mu.Lock()
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.mu.Unlock()
+
+	for {
+		if w, err := os.OpenFile(f.config.Path, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+			w.Close()
+		}
+		select {
+		case <-done:
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// Stop gracefully shuts down the ingestor.
+func (f *FifoIngestor) Stop() error {
+	if f.cancel != nil {
+		f.cancel()
+	}
+	return nil
+}
+
+// Ensure FifoIngestor implements Ingestor
+var _ Ingestor = (*FifoIngestor)(nil)
@@ -0,0 +1,69 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// writeFifo opens the pipe as a writer, writes a line and closes it.
+func writeFifo(t *testing.T, path, line string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestFifoReopen tests that the pipe is read again after its writer
+// closes it, and that Stop doesn't wait for another writer.
+func TestFifoReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.pipe")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+
+	f := NewFifoIngestor(SourceConfig{Name: "App", Type: SourceFifo, Path: path})
+	entries := make(chan LogEntry, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := f.Start(ctx, entries); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+
+	for _, line := range []string{"first writer", "WARN second writer"} {
+		writeFifo(t, path, line)
+		if e := waitEntry(t, entries); e.Message != line {
+			t.Errorf("message = %q, want %q", e.Message, line)
+		}
+	}
+
+	f.Stop()
+	deadline := time.Now().Add(2 * time.Second)
+	for f.Healthy() {
+		if time.Now().After(deadline) {
+			t.Fatal("still reading 2s after Stop")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestFifoNotPipe tests that a regular file is refused.
+func TestFifoNotPipe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFifoIngestor(SourceConfig{Name: "App", Type: SourceFifo, Path: path})
+	if err := f.Start(context.Background(), make(chan LogEntry)); err == nil {
+		f.Stop()
+		t.Error("Start() on a regular file should fail")
+	}
+}
@@ -2,11 +2,10 @@
 package ingest
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
@@ -26,20 +25,56 @@ import (
 //
 // Files implement io.Reader and io.Writer interfaces.
 // Always close files with defer file.Close().
+//
+// Rotation is detected by inode rather than by name. The parent
+// directory is watched, since inotify drops a file's watch once the file
+// is removed. When the path is renamed away, the old descriptor keeps
+// being drained; when a file with a different inode appears at the path
+// (logrotate's create mode, or an application re-creating it in rename
+// mode), the old file is read to EOF before switching. copytruncate keeps
+// the inode and is caught by the size shrinking below the read offset.
 type FileIngestor struct {
 	config  SourceConfig
+	path    string // Cleaned config path, as fsnotify reports it
+	parser  *lineParser
+	lines   *assembler // Joins multi-line entries read by the watch loop
 	watcher *fsnotify.Watcher
-	file    *os.File
+	tail    *tailer
+	rotated bool          // The path no longer names the file being read
+	oldName string        // Where the rotated file went, once seen
+	store   PositionStore // Where the read position is recorded, if anywhere
+	resumed bool          // Start picked up from a saved position
+	catchUp *tailer       // Rotated file still holding unread lines on resume
 	mu      sync.Mutex
 	healthy bool
 	cancel  context.CancelFunc
-	offset  int64 // Current read position in file
+}
+
+func init() {
+	Register(SourceFile, "file", func(config SourceConfig) (Ingestor, error) {
+		return NewFileIngestor(config), nil
+	}, validatePathSource)
+}
+
+// validatePathSource checks the fields shared by file and directory sources.
+func validatePathSource(config SourceConfig) error {
+	if config.Path == "" {
+		return fmt.Errorf("path is required for type %s", config.Type)
+	}
+	if err := validateParsers(config); err != nil {
+		return err
+	}
+	return validateBackfill(config)
 }
 
 // NewFileIngestor creates a new file-watching ingestor.
 func NewFileIngestor(config SourceConfig) *FileIngestor {
+	// Validated already
+	parser, _ := newLineParser(config)
 	return &FileIngestor{
 		config:  config,
+		path:    filepath.Clean(config.Path),
+		parser:  parser,
 		healthy: false,
 	}
 }
@@ -86,34 +121,23 @@ func (f *FileIngestor) Start(ctx context.Context, entries chan<- LogEntry) error
 		return fmt.Errorf("failed to create watcher: %w", err)
 	}
 
-	// Open the file
-	f.file, err = os.Open(f.config.Path)
-	if err != nil {
-		f.watcher.Close()
-		return fmt.Errorf("failed to open file: %w", err)
-	}
-
-	// Seek to end of file (we only want new lines)
-	// GO SYNTAX LESSON #31: File Seeking
-	// ==================================
-	// Seek(offset, whence) moves the read/write position:
-	// - io.SeekStart (0) - relative to start of file
-	// - io.SeekCurrent (1) - relative to current position
-	// - io.SeekEnd (2) - relative to end of file
-	f.offset, err = f.file.Seek(0, io.SeekEnd)
+	// Open the file, positioned at the end or where the last run stopped
+	f.tail, err = f.openTail()
 	if err != nil {
-		f.file.Close()
 		f.watcher.Close()
-		return fmt.Errorf("failed to seek to end: %w", err)
+		return err
 	}
 
-	// Add the file to the watcher
-	if err := f.watcher.Add(f.config.Path); err != nil {
-		f.file.Close()
+	// Watch the directory so the watch survives the file being rotated
+	if err := f.watcher.Add(filepath.Dir(f.path)); err != nil {
+		f.tail.close()
 		f.watcher.Close()
-		return fmt.Errorf("failed to watch file: %w", err)
+		return fmt.Errorf("failed to watch directory: %w", err)
 	}
 
+	f.lines = f.parser.newAssembler(func(entry LogEntry) bool {
+		return sendEntry(ctx, entries, entry)
+	})
 	f.setHealthy(true)
 
 	// Start the file watcher goroutine
@@ -125,8 +149,21 @@ func (f *FileIngestor) Start(ctx context.Context, entries chan<- LogEntry) error
 // watchLoop handles fsnotify events and reads new lines.
 func (f *FileIngestor) watchLoop(ctx context.Context, entries chan<- LogEntry) {
 	defer f.setHealthy(false)
-	defer f.file.Close()
+	defer func() { f.tail.close() }()
 	defer f.watcher.Close()
+	defer f.lines.stop()
+
+	// Emit history before any live line; the tailer's offset marks the
+	// boundary, so nothing is duplicated or skipped.
+	if f.resumed {
+		f.resumeCatchUp(ctx, entries)
+	} else if wantsBackfill(f.config) {
+		if chunks, err := f.tail.backfill(f.parser); err == nil {
+			if !sendAll(ctx, entries, historyEntries(f.parser, chunks)) {
+				return
+			}
+		}
+	}
 
 	for {
 		select {
@@ -137,24 +174,7 @@ func (f *FileIngestor) watchLoop(ctx context.Context, entries chan<- LogEntry) {
 			if !ok {
 				return
 			}
-
-			// We only care about write events
-			// GO SYNTAX LESSON #32: Bitwise Operations
-			// ========================================
-			// fsnotify uses bitmasks for event types.
-			// event.Op & fsnotify.Write checks if the Write bit is set.
-			//
-			// Bitwise operators:
-			// & (AND), | (OR), ^ (XOR), &^ (AND NOT)
-			if event.Op&fsnotify.Write == fsnotify.Write {
-				f.readNewLines(entries)
-			}
-
-			// Handle log rotation (file was renamed/removed and recreated)
-			if event.Op&fsnotify.Remove == fsnotify.Remove ||
-				event.Op&fsnotify.Rename == fsnotify.Rename {
-				f.handleRotation(ctx, entries)
-			}
+			f.handleEvent(ctx, event, entries)
 
 		case err, ok := <-f.watcher.Errors:
 			if !ok {
@@ -168,111 +188,169 @@ func (f *FileIngestor) watchLoop(ctx context.Context, entries chan<- LogEntry) {
 }
 
 // readNewLines reads any new content from the file since last read.
-func (f *FileIngestor) readNewLines(entries chan<- LogEntry) {
-	// Get current file size
-	info, err := f.file.Stat()
+func (f *FileIngestor) readNewLines(ctx context.Context, entries chan<- LogEntry) {
+	f.readLines(ctx, entries, false)
+}
+
+// readLines reads new content, marking the entries historical if they
+// were written before the ingestor started.
+func (f *FileIngestor) readLines(ctx context.Context, entries chan<- LogEntry, historical bool) {
+	_, err := f.tail.readLines(func(line string) bool {
+		return f.lines.add(f.config.Path, line, historical)
+	})
 	if err != nil {
+		// Real error
+		f.setHealthy(false)
 		return
 	}
-
-	// If file was truncated (size < offset), reset to beginning
-	if info.Size() < f.offset {
-		f.offset = 0
-		f.file.Seek(0, io.SeekStart)
+	if f.store != nil {
+		f.store.Save(f.config.Name, f.tail.position())
 	}
+}
 
-	// Read from current offset
-	f.file.Seek(f.offset, io.SeekStart)
-	reader := bufio.NewReader(f.file)
+// TrackPosition records the read position in store as lines are read.
+func (f *FileIngestor) TrackPosition(store PositionStore) {
+	f.store = store
+}
 
-	for {
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if err != io.EOF {
-				// Real error
-				f.setHealthy(false)
-			}
-			break
-		}
+// openTail opens the file at its end or, when resuming, where the last
+// run stopped. If the file was rotated while Argus wasn't running, the
+// old file is looked for among the uncompressed archives by inode; its
+// unread lines are read first and the new file from its start.
+func (f *FileIngestor) openTail() (*tailer, error) {
+	if !f.config.Resume || f.store == nil {
+		return openTailer(f.path, false)
+	}
+	pos, ok := f.store.Load(f.config.Name)
+	if !ok || pos.Inode == 0 {
+		return openTailer(f.path, false)
+	}
+	saved := fileID{dev: pos.Dev, ino: pos.Inode}
 
-		// Update offset
-		f.offset += int64(len(line))
+	tail, err := openTailer(f.path, true)
+	if err != nil {
+		return nil, err
+	}
+	f.resumed = true
+	if tail.id == saved {
+		// Same file; a smaller size means it was truncated meanwhile
+		tail.offset = pos.Offset
+		if info, err := tail.file.Stat(); err == nil && info.Size() < pos.Offset {
+			tail.offset = 0
+		}
+		return tail, nil
+	}
 
-		// Trim newline and skip empty lines
-		line = strings.TrimRight(line, "\r\n")
-		if line == "" {
+	// Rotated: everything in the new file is unread
+	archives, _ := findArchives(f.path)
+	for _, path := range archives {
+		if filepath.Ext(path) == ".gz" || filepath.Ext(path) == ".bz2" {
 			continue
 		}
-
-		// Parse the line and send it
-		entry := f.parseLine(line)
-		select {
-		case entries <- entry:
-		default:
-			// Channel full, skip this entry
+		info, err := os.Stat(path)
+		if err != nil || identify(info) != saved {
+			continue
+		}
+		if old, err := openTailer(path, true); err == nil {
+			old.offset = pos.Offset
+			f.catchUp = old
 		}
+		break
 	}
+	return tail, nil
 }
 
-// handleRotation handles log file rotation.
-func (f *FileIngestor) handleRotation(ctx context.Context, entries chan<- LogEntry) {
-	// Close current file
-	f.file.Close()
+// resumeCatchUp sends what was logged while Argus wasn't running: the
+// rest of the rotated file, if any, then the live file up to its end.
+func (f *FileIngestor) resumeCatchUp(ctx context.Context, entries chan<- LogEntry) {
+	if old := f.catchUp; old != nil {
+		_, _ = old.readLines(func(line string) bool {
+			return f.lines.add(old.path, line, true)
+		})
+		old.close()
+		f.catchUp = nil
+	}
+	f.readLines(ctx, entries, true)
+}
 
-	// Wait a bit for the new file to be created
-	// GO SYNTAX LESSON #33: time.Sleep and time.After
-	// ================================================
-	// time.Sleep blocks the current goroutine.
-	// time.After returns a channel that receives after duration.
-	// time.Tick returns a channel that receives periodically.
-	time.Sleep(100 * time.Millisecond)
+// handleEvent reacts to a change in the watched directory.
+func (f *FileIngestor) handleEvent(ctx context.Context, event fsnotify.Event, entries chan<- LogEntry) {
+	if event.Name != f.path {
+		// After a rename the writer may keep appending to the old file
+		// (now under another name) until it reopens the log. Other files
+		// in the directory are written too; only the old file's writes
+		// are read.
+		if f.rotated && event.Op&fsnotify.Write == fsnotify.Write && f.isOldFile(event.Name) {
+			f.readNewLines(ctx, entries)
+		}
+		return
+	}
 
-	// Try to reopen the file
-	var err error
-	for i := 0; i < 10; i++ {
-		f.file, err = os.Open(f.config.Path)
-		if err == nil {
-			break
+	// GO SYNTAX LESSON #32: Bitwise Operations
+	// ========================================
+	// fsnotify uses bitmasks for event types.
+	// event.Op & fsnotify.Write checks if the Write bit is set.
+	//
+	// Bitwise operators:
+	// & (AND), | (OR), ^ (XOR), &^ (AND NOT)
+	switch {
+	case event.Op&(fsnotify.Create|fsnotify.Write) != 0:
+		if f.tail.replaced() {
+			f.switchFile(ctx, entries)
+		} else {
+			// Same inode: new lines, or a copytruncate the tailer resets on
+			f.readNewLines(ctx, entries)
 		}
-		time.Sleep(100 * time.Millisecond)
+
+	case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		// The descriptor still reads the old file; keep it until a new
+		// file shows up at the path.
+		//
+		// GO SYNTAX LESSON #33: time.Sleep and time.After
+		// ================================================
+		// time.Sleep blocks the current goroutine.
+		// time.After returns a channel that receives after duration.
+		// time.Tick returns a channel that receives periodically.
+		//
+		// Sleeping here would stall every other event. The Create event
+		// for the new file arrives on the same channel, so we just wait.
+		f.readNewLines(ctx, entries)
+		f.rotated = true
 	}
+}
 
+// isOldFile reports whether name is where the file being read was
+// rotated to. The first write under its new name is matched by inode;
+// later ones by name alone.
+func (f *FileIngestor) isOldFile(name string) bool {
+	if f.oldName != "" {
+		return name == f.oldName
+	}
+	info, err := os.Stat(name)
+	if err != nil || identify(info) != f.tail.id {
+		return false
+	}
+	f.oldName = name
+	return true
+}
+
+// switchFile drains the old file to EOF, then follows the new file at the
+// path from its start.
+func (f *FileIngestor) switchFile(ctx context.Context, entries chan<- LogEntry) {
+	f.readNewLines(ctx, entries)
+
+	tail, err := openTailer(f.path, true)
 	if err != nil {
+		// Gone again already; the next Create event retries
 		f.setHealthy(false)
 		return
 	}
 
-	// Reset offset to start of new file
-	f.offset = 0
-}
-
-// parseLine attempts to parse a log line into a LogEntry.
-// It tries common log formats (syslog, timestamp-based, etc.)
-func (f *FileIngestor) parseLine(line string) LogEntry {
-	entry := LogEntry{
-		Source:       f.config.Name,
-		IngestorName: f.config.Name, // Config name for filtering
-		SourceType:   SourceFile,
-		Raw:          line,
-		Message:      line, // Default: whole line is the message
-		Timestamp:    time.Now(),
-		Level:        LevelUnknown,
-		Metadata:     make(map[string]string),
-	}
-
-	// Try to parse syslog format
-	// Example: Jan 18 15:04:05 hostname process[pid]: message
-	if parsed := parseSyslogLine(line); parsed != nil {
-		entry.Timestamp = parsed.timestamp
-		entry.Message = parsed.message
-		entry.Hostname = parsed.hostname
-		entry.Metadata["process"] = parsed.process
-	}
-
-	// Detect log level from content
-	entry.Level = detectLevel(line)
-
-	return entry
+	f.tail.close()
+	f.tail = tail
+	f.rotated, f.oldName = false, ""
+	f.setHealthy(true)
+	f.readNewLines(ctx, entries)
 }
 
 // Stop gracefully shuts down the ingestor.
@@ -283,11 +361,80 @@ func (f *FileIngestor) Stop() error {
 	return nil
 }
 
+// ReadHistory sends the file's existing lines without following it. With
+// backfill options set, only that history is sent; otherwise everything,
+// starting with the archives if the source includes them.
+func (f *FileIngestor) ReadHistory(ctx context.Context, entries chan<- LogEntry) error {
+	tail, err := openTailer(f.path, false)
+	if err != nil {
+		return err
+	}
+	defer tail.close()
+
+	if wantsBackfill(f.config) {
+		chunks, err := tail.backfill(f.parser)
+		if err != nil {
+			return err
+		}
+		sendAll(ctx, entries, historyEntries(f.parser, chunks))
+		return ctx.Err()
+	}
+
+	// Everything: stream rather than hold it all in memory
+	var paths []string
+	if f.config.Archives {
+		if paths, err = findArchives(f.path); err != nil {
+			return err
+		}
+	}
+	for _, path := range append(paths, f.path) {
+		if err := f.streamFile(ctx, path, entries); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// streamFile sends every line of a (possibly compressed) file in order.
+func (f *FileIngestor) streamFile(ctx context.Context, path string, entries chan<- LogEntry) error {
+	r, err := openArchive(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	last := modTime(path)
+	send := func(lines []string) bool {
+		entry := f.parser.groupEntry(path, lines)
+		entry.Historical = true
+		if entry.Timestamp.IsZero() {
+			entry.Timestamp = last
+		} else {
+			last = entry.Timestamp
+		}
+		return sendEntry(ctx, entries, entry)
+	}
+
+	group := lineGroup{rules: f.parser.multiline}
+	sent := true
+	err = scanLines(r, func(line string) bool {
+		if lines := group.add(line); lines != nil {
+			sent = send(lines)
+		}
+		return sent
+	})
+	if lines := group.flush(); lines != nil && sent && err == nil {
+		send(lines)
+	}
+	return err
+}
+
 // syslogParsed holds the result of parsing a syslog line
 type syslogParsed struct {
 	timestamp time.Time
 	hostname  string
 	process   string
+	pid       int
 	message   string
 }
 
@@ -300,59 +447,36 @@ type syslogParsed struct {
 //
 // Store compiled regexes at package level to avoid recompiling.
 var syslogRegex = regexp.MustCompile(
-	`^(\w{3}\s+\d{1,2}\s+\d{2}:\d{2}:\d{2})\s+(\S+)\s+(\S+?)(?:\[\d+\])?:\s*(.*)$`,
+	`^(\w{3}\s+\d{1,2}\s+\d{2}:\d{2}:\d{2}(?:\.\d+)?|\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})?)\s+(\S+)\s+(\S+?)(?:\[(\d+)\])?:\s*(.*)$`,
 )
 
-// parseSyslogLine attempts to parse a syslog-formatted line.
-func parseSyslogLine(line string) *syslogParsed {
+// parseSyslogLine attempts to parse a syslog-formatted line. Timestamps
+// without a zone are read in loc, and the traditional ones, which have
+// no year, are given the latest year not ahead of now.
+func parseSyslogLine(line string, loc *time.Location) *syslogParsed {
 	matches := syslogRegex.FindStringSubmatch(line)
 	if matches == nil {
 		return nil
 	}
 
-	// Parse timestamp (add current year since syslog doesn't include it)
-	ts, err := time.Parse("Jan 2 15:04:05", matches[1])
-	if err != nil {
-		ts = time.Now()
-	} else {
-		// Set year to current year
-		ts = ts.AddDate(time.Now().Year(), 0, 0)
+	// Collapse the padding of single-digit days ("Oct  1") for the layout
+	ts, ok := parseTimestamp(strings.Join(strings.Fields(matches[1]), " "), loc)
+	if !ok {
+		return nil
 	}
 
 	return &syslogParsed{
 		timestamp: ts,
 		hostname:  matches[2],
 		process:   matches[3],
-		message:   matches[4],
-	}
-}
-
-// detectLevel looks for level keywords in the log line.
-func detectLevel(line string) LogLevel {
-	upper := strings.ToUpper(line)
-
-	// Check in order of severity (most severe first)
-	switch {
-	case strings.Contains(upper, "EMERG") || strings.Contains(upper, "EMERGENCY"):
-		return LevelEmergency
-	case strings.Contains(upper, "ALERT"):
-		return LevelAlert
-	case strings.Contains(upper, "CRIT") || strings.Contains(upper, "CRITICAL"):
-		return LevelCritical
-	case strings.Contains(upper, "ERROR") || strings.Contains(upper, "ERR"):
-		return LevelError
-	case strings.Contains(upper, "WARN") || strings.Contains(upper, "WARNING"):
-		return LevelWarning
-	case strings.Contains(upper, "NOTICE"):
-		return LevelNotice
-	case strings.Contains(upper, "INFO"):
-		return LevelInfo
-	case strings.Contains(upper, "DEBUG") || strings.Contains(upper, "TRACE"):
-		return LevelDebug
-	default:
-		return LevelUnknown
+		pid:       parseInt(matches[4]),
+		message:   matches[5],
 	}
 }
 
-// Ensure FileIngestor implements Ingestor
-var _ Ingestor = (*FileIngestor)(nil)
+// Ensure FileIngestor implements Ingestor, HistoryReader and PositionTracker
+var (
+	_ Ingestor        = (*FileIngestor)(nil)
+	_ HistoryReader   = (*FileIngestor)(nil)
+	_ PositionTracker = (*FileIngestor)(nil)
+)
@@ -0,0 +1,150 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFileIngestorRotation tests that no line is lost or repeated across
+// each logrotate mode.
+func TestFileIngestorRotation(t *testing.T) {
+	tests := []struct {
+		name   string
+		rotate func(t *testing.T, path string)
+		want   []string
+	}{
+		{
+			// create: rename away, writer finishes on the old file, new file created
+			name: "create",
+			rotate: func(t *testing.T, path string) {
+				rename(t, path, path+".1")
+				appendLine(t, path+".1", "late write to old file")
+				appendLine(t, path, "first line of new file")
+			},
+			want: []string{"late write to old file", "first line of new file"},
+		},
+		{
+			// copytruncate: same inode, contents copied then truncated in place
+			name: "copytruncate",
+			rotate: func(t *testing.T, path string) {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path+".1", data, 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Truncate(path, 0); err != nil {
+					t.Fatal(err)
+				}
+				appendLine(t, path, "after truncate")
+			},
+			want: []string{"after truncate"},
+		},
+		{
+			// rename: nothing re-creates the file until the writer reopens it
+			name: "rename",
+			rotate: func(t *testing.T, path string) {
+				rename(t, path, path+".1")
+				appendLine(t, path+".1", "old file keeps growing")
+				appendLine(t, path+".1", "still the old file")
+			},
+			want: []string{"old file keeps growing", "still the old file"},
+		},
+		{
+			// remove and re-create, as some applications do on restart
+			name: "remove",
+			rotate: func(t *testing.T, path string) {
+				if err := os.Remove(path); err != nil {
+					t.Fatal(err)
+				}
+				appendLine(t, path, "recreated")
+			},
+			want: []string{"recreated"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			appendLine(t, path, "existing line that is long enough to truncate below")
+
+			ing := NewFileIngestor(SourceConfig{Name: "App", Type: SourceFile, Path: path})
+			entries := make(chan LogEntry, 10)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := ing.Start(ctx, entries); err != nil {
+				t.Fatalf("Start() error: %v", err)
+			}
+			defer ing.Stop()
+
+			appendLine(t, path, "before rotation")
+			if e := waitEntry(t, entries); e.Message != "before rotation" {
+				t.Fatalf("Message = %q, want %q", e.Message, "before rotation")
+			}
+
+			tt.rotate(t, path)
+			for _, want := range tt.want {
+				if e := waitEntry(t, entries); e.Message != want {
+					t.Errorf("Message = %q, want %q", e.Message, want)
+				}
+			}
+
+			// The new file at the path is followed from here on
+			appendLine(t, path, "after rotation")
+			if e := waitEntry(t, entries); e.Message != "after rotation" {
+				t.Errorf("Message = %q, want %q", e.Message, "after rotation")
+			}
+			if !ing.Healthy() {
+				t.Error("Healthy() = false after rotation")
+			}
+
+			select {
+			case e := <-entries:
+				t.Errorf("unexpected extra entry %q", e.Message)
+			default:
+			}
+		})
+	}
+}
+
+// rename moves a file or fails the test.
+func rename(t *testing.T, from, to string) {
+	t.Helper()
+	if err := os.Rename(from, to); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestFileIngestorOldFile tests that after a rename only writes to the
+// file's new name count as the writer finishing the old file, not writes
+// to other files in the directory.
+func TestFileIngestorOldFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendLine(t, path, "first")
+
+	ing := NewFileIngestor(SourceConfig{Name: "App", Type: SourceFile, Path: path})
+	tail, err := openTailer(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tail.close()
+	ing.tail, ing.rotated = tail, true
+
+	rename(t, path, path+".1")
+	appendLine(t, filepath.Join(dir, "other.log"), "unrelated")
+
+	if ing.isOldFile(filepath.Join(dir, "other.log")) {
+		t.Error("isOldFile(other.log) = true")
+	}
+	if !ing.isOldFile(path + ".1") {
+		t.Error("isOldFile(app.log.1) = false")
+	}
+	// Once found, the name is remembered
+	if ing.oldName != path+".1" || ing.isOldFile(filepath.Join(dir, "other.log")) {
+		t.Errorf("oldName = %q", ing.oldName)
+	}
+}
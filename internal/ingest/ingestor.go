@@ -19,6 +19,8 @@ package ingest
 
 import (
 	"context" // For cancellation and timeouts
+	"fmt"     // For error formatting
+	"strings" // For case-insensitive level names
 	"time"    // For timestamps
 )
 
@@ -91,6 +93,31 @@ func (l LogLevel) String() string {
 	}
 }
 
+// ParseLevel converts a level name ("warn", "error", ...) or a syslog
+// priority digit ("0"-"7") into a LogLevel.
+func ParseLevel(name string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug", "trace", "7":
+		return LevelDebug, nil
+	case "info", "6":
+		return LevelInfo, nil
+	case "notice", "5":
+		return LevelNotice, nil
+	case "warn", "warning", "4":
+		return LevelWarning, nil
+	case "err", "error", "3":
+		return LevelError, nil
+	case "crit", "critical", "2":
+		return LevelCritical, nil
+	case "alert", "1":
+		return LevelAlert, nil
+	case "emerg", "emergency", "0":
+		return LevelEmergency, nil
+	default:
+		return LevelUnknown, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
 // SourceType identifies the kind of log source
 type SourceType int
 
@@ -101,6 +128,14 @@ const (
 	SourceFile
 	// SourceDirectory watches all log files in a directory
 	SourceDirectory
+	// SourceKmsg reads the kernel log buffer (/dev/kmsg)
+	SourceKmsg
+	// SourceSyslog receives syslog messages over the network
+	SourceSyslog
+	// SourceStdin reads lines piped into Argus
+	SourceStdin
+	// SourceFifo reads lines from a named pipe
+	SourceFifo
 )
 
 func (s SourceType) String() string {
@@ -111,6 +146,14 @@ func (s SourceType) String() string {
 		return "file"
 	case SourceDirectory:
 		return "directory"
+	case SourceKmsg:
+		return "kmsg"
+	case SourceSyslog:
+		return "syslog"
+	case SourceStdin:
+		return "stdin"
+	case SourceFifo:
+		return "fifo"
 	default:
 		return "unknown"
 	}
@@ -162,6 +205,11 @@ type LogEntry struct {
 
 	// Metadata holds any extra fields from the source
 	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Historical marks entries replayed from before the source started
+	// (backfill, catch-up). They arrive out of order relative to live
+	// entries from other sources and are placed by Timestamp instead.
+	Historical bool `json:"historical,omitempty"`
 }
 
 // SourceConfig holds the configuration for a log source.
@@ -172,17 +220,93 @@ type SourceConfig struct {
 	// Type is the source type (journald, file, directory)
 	Type SourceType `yaml:"type" json:"type"`
 
-	// Path is the file/directory path (not used for journald)
+	// Path is the file/directory path (journald: journal files or a
+	// directory; kmsg: defaults to /dev/kmsg)
 	Path string `yaml:"path,omitempty" json:"path,omitempty"`
 
 	// Enabled controls whether this source is active
 	Enabled bool `yaml:"enabled" json:"enabled"`
 
-	// Filters are optional journalctl filters (e.g., "-u nginx")
-	Filters []string `yaml:"filters,omitempty" json:"filters,omitempty"`
+	// Listen is where a syslog source receives messages: udp://HOST:PORT,
+	// tcp://HOST:PORT or unix:///path/to/socket
+	Listen string `yaml:"listen,omitempty" json:"listen,omitempty"`
 
 	// GlobPattern is used for directory sources (e.g., "*.log")
 	GlobPattern string `yaml:"glob,omitempty" json:"glob,omitempty"`
+
+	// Parsers is the chain of line formats tried on file, directory, fifo
+	// and stdin sources, in order; empty means syslog
+	Parsers []ParserConfig `yaml:"parser,omitempty" json:"parser,omitempty"`
+
+	// Multiline joins continuation lines, such as stack traces, into the
+	// entry they belong to on line-based sources
+	Multiline *MultilineConfig `yaml:"multiline,omitempty" json:"multiline,omitempty"`
+
+	// Levels maps a source's level names and overrides levels by source
+	// or pattern
+	Levels *LevelConfig `yaml:"levels,omitempty" json:"levels,omitempty"`
+
+	// Timezone is the IANA zone ("UTC", "Europe/Berlin") of timestamps
+	// written without one; empty means local time
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+
+	// Priority is the minimum syslog priority for journald (0-7), nil = all
+	Priority *int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// BackfillLines is how many existing lines to emit before live tailing
+	BackfillLines int `yaml:"backfill_lines,omitempty" json:"backfill_lines,omitempty"`
+
+	// BackfillSince emits existing lines newer than this ("2h" or a timestamp)
+	BackfillSince string `yaml:"backfill_since,omitempty" json:"backfill_since,omitempty"`
+
+	// Archives includes rotated copies (auth.log.1, auth.log.2.gz) in history
+	Archives bool `yaml:"archives,omitempty" json:"archives,omitempty"`
+
+	// Resume continues from the position saved by the last run, if any
+	Resume bool `yaml:"resume,omitempty" json:"resume,omitempty"`
+
+	// Since starts a journald source with entries newer than this
+	Since string `yaml:"since,omitempty" json:"since,omitempty"`
+
+	// Lines starts a journald source with its last N entries
+	Lines int `yaml:"lines,omitempty" json:"lines,omitempty"`
+
+	// Boot limits a journald source to one boot ("current", -1, or an ID)
+	Boot string `yaml:"boot,omitempty" json:"boot,omitempty"`
+
+	// Units limits a journald source to these systemd units; a name
+	// without a suffix is a .service, and shell patterns are allowed
+	Units []string `yaml:"units,omitempty" json:"units,omitempty"`
+
+	// Identifiers limits a journald source to these SYSLOG_IDENTIFIERs
+	Identifiers []string `yaml:"identifiers,omitempty" json:"identifiers,omitempty"`
+
+	// PriorityRange limits a journald source to priorities FROM..TO
+	// ("emerg..err", "3..4"); it replaces Priority
+	PriorityRange string `yaml:"priority_range,omitempty" json:"priority_range,omitempty"`
+
+	// Matches limits a journald source to entries with these FIELD=value
+	// pairs; values of one field are alternatives, different fields must
+	// all match
+	Matches []string `yaml:"matches,omitempty" json:"matches,omitempty"`
+
+	// Grep limits a journald source to entries whose MESSAGE matches this
+	// regular expression (case-insensitive when it is all lowercase)
+	Grep string `yaml:"grep,omitempty" json:"grep,omitempty"`
+
+	// Backend is how a journald source reads the journal: "journalctl",
+	// "native" (the journal files directly), or empty to pick
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// Namespace reads a journald namespace instead of the default journal
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+
+	// Fields limits the journal fields kept in Metadata to these patterns
+	// ("_SYSTEMD_*"); empty keeps every field
+	Fields []string `yaml:"fields,omitempty" json:"fields,omitempty"`
+
+	// ExcludeFields drops journal fields matching these patterns
+	ExcludeFields []string `yaml:"exclude_fields,omitempty" json:"exclude_fields,omitempty"`
 }
 
 // GO SYNTAX LESSON #16: Interfaces
@@ -226,6 +350,28 @@ type Ingestor interface {
 	Healthy() bool
 }
 
+// HistoryReader is implemented by sources that can replay what they
+// already hold without following new entries, for one-shot queries.
+type HistoryReader interface {
+	// ReadHistory sends existing entries, oldest first, and returns once
+	// they are all sent. Backfill options narrow what is sent.
+	ReadHistory(ctx context.Context, entries chan<- LogEntry) error
+}
+
+// Boot is one boot recorded in a journal.
+type Boot struct {
+	Index int    // Offset as journalctl counts it: 0 is the last boot, -1 the one before
+	ID    string // 128-bit boot ID in hex
+	First time.Time
+	Last  time.Time
+}
+
+// BootLister is implemented by sources that can list the boots they hold.
+type BootLister interface {
+	// ListBoots returns the boots, oldest first.
+	ListBoots(ctx context.Context) ([]Boot, error)
+}
+
 // GO SYNTAX LESSON #18: Channels
 // ==============================
 // Channels are Go's primary mechanism for goroutine communication.
@@ -32,6 +32,41 @@ func TestLogLevelString(t *testing.T) {
 	}
 }
 
+// TestParseLevel tests parsing level names and syslog priorities.
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected LogLevel
+	}{
+		{"debug", LevelDebug},
+		{"INFO", LevelInfo},
+		{"warn", LevelWarning},
+		{"warning", LevelWarning},
+		{"err", LevelError},
+		{"Error", LevelError},
+		{"crit", LevelCritical},
+		{"emerg", LevelEmergency},
+		{"3", LevelError},
+		{"7", LevelDebug},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.name)
+			if err != nil {
+				t.Fatalf("ParseLevel(%q) error: %v", tt.name, err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseLevel(%q) = %v, want %v", tt.name, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("ParseLevel(loud) should return an error")
+	}
+}
+
 // TestSourceTypeString tests the SourceType.String() method.
 func TestSourceTypeString(t *testing.T) {
 	tests := []struct {
@@ -102,3 +137,77 @@ func TestSourceConfigValidation(t *testing.T) {
 		t.Error("Enabled should be true")
 	}
 }
+
+// TestRegistryParseSourceType tests that every built-in type is registered.
+func TestRegistryParseSourceType(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected SourceType
+	}{
+		{"journald", SourceJournald},
+		{"file", SourceFile},
+		{"directory", SourceDirectory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSourceType(tt.name)
+			if err != nil {
+				t.Fatalf("ParseSourceType(%q) error: %v", tt.name, err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseSourceType(%q) = %v, want %v", tt.name, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := ParseSourceType("bogus"); err == nil {
+		t.Error("ParseSourceType(bogus) should return an error")
+	}
+}
+
+// TestRegistryValidate tests the per-type validators.
+func TestRegistryValidate(t *testing.T) {
+	badPriority := 9
+	goodPriority := 4
+
+	tests := []struct {
+		name    string
+		cfg     SourceConfig
+		wantErr bool
+	}{
+		{"journald", SourceConfig{Name: "j", Type: SourceJournald}, false},
+		{"journald priority", SourceConfig{Name: "j", Type: SourceJournald, Priority: &goodPriority}, false},
+		{"journald bad priority", SourceConfig{Name: "j", Type: SourceJournald, Priority: &badPriority}, true},
+		{"file", SourceConfig{Name: "f", Type: SourceFile, Path: "/var/log/auth.log"}, false},
+		{"file without path", SourceConfig{Name: "f", Type: SourceFile}, true},
+		{"unregistered type", SourceConfig{Name: "x", Type: SourceType(99)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRegistryNew tests building ingestors through the registry.
+func TestRegistryNew(t *testing.T) {
+	ing, err := New(SourceConfig{Name: "Auth", Type: SourceFile, Path: "/var/log/auth.log"})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if _, ok := ing.(*FileIngestor); !ok {
+		t.Errorf("New() returned %T, want *FileIngestor", ing)
+	}
+	if ing.Name() != "Auth" {
+		t.Errorf("Name() = %q, want %q", ing.Name(), "Auth")
+	}
+
+	if _, err := New(SourceConfig{Name: "Auth", Type: SourceFile}); err == nil {
+		t.Error("New() without path should fail validation")
+	}
+}
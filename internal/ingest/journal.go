@@ -6,13 +6,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
+	"path"
+	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
-// JournalIngestor reads logs from systemd-journald via journalctl.
+// JournalIngestor reads logs from systemd-journald via journalctl, or
+// straight from the journal files where journalctl isn't installed (see
+// journal_native.go).
 //
 // GO SYNTAX LESSON #19: Struct Embedding & Composition
 // ====================================================
@@ -40,10 +46,66 @@ type JournalIngestor struct {
 	// healthy tracks whether the ingestor is functioning
 	healthy bool
 
+	// store records the journal cursor of each entry read, if set
+	store PositionStore
+
+	// levels are the source's level names, rules and override
+	levels *levelRules
+
 	// cancel is used to stop the ingestor
 	cancel context.CancelFunc
 }
 
+func init() {
+	Register(SourceJournald, "journald", func(config SourceConfig) (Ingestor, error) {
+		return NewJournalIngestor(config), nil
+	}, validateJournal)
+}
+
+// validateJournal checks journald-specific fields.
+func validateJournal(config SourceConfig) error {
+	if _, err := parseJournalFilter(config); err != nil {
+		return err
+	}
+	if config.Lines < 0 {
+		return fmt.Errorf("lines must not be negative")
+	}
+	if config.Since != "" {
+		if _, err := ParseSince(config.Since, time.Now()); err != nil {
+			return fmt.Errorf("since: %w", err)
+		}
+	}
+	if config.Boot != "" && config.Boot != "current" && !bootRegex.MatchString(config.Boot) {
+		return fmt.Errorf("boot must be \"current\", an offset like -1, or a boot ID, got %q", config.Boot)
+	}
+	for _, pattern := range append(append([]string(nil), config.Fields...), config.ExcludeFields...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid field pattern %q: %w", pattern, err)
+		}
+	}
+	if config.Namespace != "" && !namespaceRegex.MatchString(config.Namespace) {
+		return fmt.Errorf("invalid namespace %q", config.Namespace)
+	}
+	switch config.Backend {
+	case "", backendJournalctl, backendNative:
+	default:
+		return fmt.Errorf("backend must be %q or %q, got %q", backendJournalctl, backendNative, config.Backend)
+	}
+	return validateLevels(config)
+}
+
+// bootRegex matches a journalctl boot offset or 128-bit boot ID.
+var bootRegex = regexp.MustCompile(`^([+-]?\d+|[0-9a-f]{32})$`)
+
+// namespaceRegex matches a journald namespace name.
+var namespaceRegex = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]*$`)
+
+// isJournalFile reports whether a journald source's path names journal
+// files rather than a directory of them.
+func isJournalFile(path string) bool {
+	return strings.HasSuffix(path, ".journal") || strings.HasSuffix(path, ".journal~")
+}
+
 // NewJournalIngestor creates a new journald log ingestor.
 //
 // GO SYNTAX LESSON #21: Constructor Pattern
@@ -58,6 +120,7 @@ func NewJournalIngestor(config SourceConfig) *JournalIngestor {
 	return &JournalIngestor{
 		config:  config,
 		healthy: false,
+		levels:  sourceLevelRules(config),
 	}
 }
 
@@ -95,14 +158,11 @@ func (j *JournalIngestor) Start(ctx context.Context, entries chan<- LogEntry) er
 	// If the parent context is cancelled OR we call j.cancel(), this stops
 	ctx, j.cancel = context.WithCancel(ctx)
 
-	// Build the journalctl command
-	// -o json: Output in JSON format (much easier to parse)
-	// -f: Follow mode (like tail -f)
-	// --no-pager: Don't use less/more
-	args := []string{"-o", "json", "-f", "--no-pager"}
+	if j.backend() == backendNative {
+		return j.startNative(ctx, entries)
+	}
 
-	// Add any custom filters from config
-	args = append(args, j.config.Filters...)
+	args := j.args(true)
 
 	// GO SYNTAX LESSON #23: exec.Command
 	// ==================================
@@ -131,54 +191,13 @@ func (j *JournalIngestor) Start(ctx context.Context, entries chan<- LogEntry) er
 	// ==========================================================
 	// We need to read from journalctl continuously without blocking.
 	// So we spawn a goroutine to handle the reading.
+	started := time.Now()
 	go func() {
 		// Ensure we clean up when done
 		defer j.setHealthy(false)
 		defer stdout.Close()
 
-		// Create a buffered reader for efficient line-by-line reading
-		scanner := bufio.NewScanner(stdout)
-
-		// Read lines until context is cancelled or stream ends
-		for scanner.Scan() {
-			// Check if we should stop
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				// Continue processing
-			}
-
-			line := scanner.Text()
-			if line == "" {
-				continue
-			}
-
-			// Parse the JSON line
-			entry, err := j.parseJournalEntry(line)
-			if err != nil {
-				// Log parse errors but don't stop
-				// In production, we might want to count these
-				continue
-			}
-
-			// Send entry to channel (non-blocking with select)
-			// GO SYNTAX LESSON #25: Select Statement
-			// ======================================
-			// select is like switch but for channel operations.
-			// It waits until one of its cases can proceed.
-			// With a default case, it becomes non-blocking.
-			select {
-			case entries <- entry:
-				// Sent successfully
-			case <-ctx.Done():
-				// Context cancelled, stop sending
-				return
-			}
-		}
-
-		// Check for scanner errors
-		if err := scanner.Err(); err != nil {
+		if err := j.stream(ctx, stdout, entries, started); err != nil {
 			// Could log this error
 			j.setHealthy(false)
 		}
@@ -193,6 +212,219 @@ func (j *JournalIngestor) Start(ctx context.Context, entries chan<- LogEntry) er
 	return nil
 }
 
+// args builds the journalctl command line. Without follow, journalctl
+// prints the requested history and exits.
+func (j *JournalIngestor) args(follow bool) []string {
+	// -o json: Output in JSON format (much easier to parse)
+	// -f: Follow mode (like tail -f)
+	// --no-pager: Don't use less/more
+	// --all: Print fields over 4096 bytes instead of null
+	args := []string{"-o", "json", "--no-pager", "--all"}
+	if follow {
+		args = append(args, "-f")
+	}
+
+	args = append(args, j.sourceArgs()...)
+
+	// Pick up right after the last entry the previous run saw; that
+	// replaces any other starting point
+	resumed := false
+	if j.config.Resume && j.store != nil {
+		if pos, ok := j.store.Load(j.config.Name); ok && pos.Cursor != "" {
+			args = append(args, "--after-cursor", pos.Cursor)
+			resumed = true
+		}
+	}
+
+	if !resumed {
+		args = append(args, j.historyArgs()...)
+	}
+
+	// Units, identifiers, priority, grep and field matches; validated
+	// already
+	filter, _ := parseJournalFilter(j.config)
+	return append(args, filter.args()...)
+}
+
+// sourceArgs selects the journal to read: files or a directory copied
+// from another machine, and a namespace.
+func (j *JournalIngestor) sourceArgs() []string {
+	var args []string
+	switch {
+	case j.config.Path == "":
+	case isJournalFile(j.config.Path):
+		args = append(args, "--file="+j.config.Path)
+	default:
+		args = append(args, "--directory="+j.config.Path)
+	}
+	if j.config.Namespace != "" {
+		args = append(args, "--namespace="+j.config.Namespace)
+	}
+	return args
+}
+
+// ListBoots lists the boots in the journal, oldest first, from
+// journalctl --list-boots or the journal files.
+func (j *JournalIngestor) ListBoots(ctx context.Context) ([]Boot, error) {
+	if j.backend() == backendNative {
+		return j.listNativeBoots()
+	}
+
+	args := append([]string{"--list-boots", "--output=json", "--no-pager"}, j.sourceArgs()...)
+	cmd := exec.CommandContext(ctx, "journalctl", args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("journalctl: %s", msg)
+		}
+		return nil, fmt.Errorf("journalctl: %w", err)
+	}
+	return parseBootList(out)
+}
+
+// parseBootList decodes journalctl --list-boots --output=json.
+func parseBootList(out []byte) ([]Boot, error) {
+	var listed []struct {
+		Index      int    `json:"index"`
+		BootID     string `json:"boot_id"`
+		FirstEntry int64  `json:"first_entry"`
+		LastEntry  int64  `json:"last_entry"`
+	}
+	if err := json.Unmarshal(out, &listed); err != nil {
+		return nil, fmt.Errorf("failed to parse boot list: %w", err)
+	}
+
+	boots := make([]Boot, len(listed))
+	for i, b := range listed {
+		boots[i] = Boot{
+			Index: b.Index,
+			ID:    b.BootID,
+			First: time.UnixMicro(b.FirstEntry),
+			Last:  time.UnixMicro(b.LastEntry),
+		}
+	}
+	return boots, nil
+}
+
+// historyArgs translates the since, lines and boot options. With only
+// since or boot set, every matching line is shown; journalctl would
+// otherwise stop at its default of 10.
+func (j *JournalIngestor) historyArgs() []string {
+	var args []string
+	if j.config.Boot != "" {
+		boot := j.config.Boot
+		if boot == "current" {
+			boot = "0"
+		}
+		args = append(args, "--boot="+boot)
+	}
+	if j.config.Since != "" {
+		// Validated already; journalctl takes local wall-clock time
+		if since, err := ParseSince(j.config.Since, time.Now()); err == nil {
+			args = append(args, "--since="+since.Local().Format("2006-01-02 15:04:05"))
+		}
+	}
+	switch {
+	case j.config.Lines > 0:
+		args = append(args, "--lines="+strconv.Itoa(j.config.Lines))
+	case len(args) > 0:
+		args = append(args, "--lines=all")
+	}
+	return args
+}
+
+// ReadHistory runs journalctl without following and sends what it prints,
+// or reads the same entries from the journal files.
+func (j *JournalIngestor) ReadHistory(ctx context.Context, entries chan<- LogEntry) error {
+	if j.backend() == backendNative {
+		return j.readNativeHistory(ctx, entries)
+	}
+
+	cmd := exec.CommandContext(ctx, "journalctl", j.args(false)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start journalctl: %w", err)
+	}
+
+	// Everything a one-shot read returns is history
+	streamErr := j.stream(ctx, stdout, entries, time.Time{})
+	if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("journalctl: %s", msg)
+		}
+		return fmt.Errorf("journalctl: %w", err)
+	}
+	return streamErr
+}
+
+// stream reads journalctl JSON output and sends each entry until the
+// output ends or ctx is cancelled. Entries logged before started are
+// history (since/lines/boot, or a resumed cursor) and marked as such; a
+// zero started marks every entry.
+func (j *JournalIngestor) stream(ctx context.Context, r io.Reader, entries chan<- LogEntry, started time.Time) error {
+	// Create a buffered reader for efficient line-by-line reading
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	// Read lines until context is cancelled or stream ends
+	for scanner.Scan() {
+		// Check if we should stop
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			// Continue processing
+		}
+
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		// Parse the JSON line
+		entry, err := j.parseJournalEntry(line)
+		if err != nil {
+			// Log parse errors but don't stop
+			// In production, we might want to count these
+			continue
+		}
+		entry.Historical = started.IsZero() || entry.Timestamp.Before(started)
+
+		// Send entry to channel (non-blocking with select)
+		// GO SYNTAX LESSON #25: Select Statement
+		// ======================================
+		// select is like switch but for channel operations.
+		// It waits until one of its cases can proceed.
+		// With a default case, it becomes non-blocking.
+		select {
+		case entries <- entry:
+			// Sent successfully
+		case <-ctx.Done():
+			// Context cancelled, stop sending
+			return nil
+		}
+
+		if j.store != nil && entry.Metadata["cursor"] != "" {
+			j.store.Save(j.config.Name, Position{Cursor: entry.Metadata["cursor"], Time: time.Now()})
+		}
+	}
+
+	// Check for scanner errors
+	return scanner.Err()
+}
+
+// TrackPosition records the cursor of every entry read in store.
+func (j *JournalIngestor) TrackPosition(store PositionStore) {
+	j.store = store
+}
+
 // Stop gracefully shuts down the ingestor.
 func (j *JournalIngestor) Stop() error {
 	if j.cancel != nil {
@@ -205,27 +437,24 @@ func (j *JournalIngestor) Stop() error {
 	return nil
 }
 
-// journalEntry represents the JSON structure from journalctl -o json
+// journalEntry holds every field of one journal entry, from either
+// backend. Values are raw bytes in a string; a field logged more than
+// once has its values joined by newlines.
 // GO SYNTAX LESSON #26: JSON Unmarshaling
 // =======================================
 // To parse JSON into a struct, the field names or json tags must match.
 // Use json.Unmarshal([]byte, &target) to parse.
 //
 // journalctl outputs fields like __REALTIME_TIMESTAMP, PRIORITY, MESSAGE, etc.
-type journalEntry struct {
-	RealtimeTimestamp string `json:"__REALTIME_TIMESTAMP"`
-	Priority          string `json:"PRIORITY"`
-	Message           string `json:"MESSAGE"`
-	SyslogIdentifier  string `json:"SYSLOG_IDENTIFIER"`
-	SystemdUnit       string `json:"_SYSTEMD_UNIT"`
-	PID               string `json:"_PID"`
-	Hostname          string `json:"_HOSTNAME"`
-	Transport         string `json:"_TRANSPORT"`
-}
+// Any field name can appear, so entries are maps rather than structs.
+// parseJournalEntry unmarshals each value as a json.RawMessage first and
+// decodes it by its shape (string, byte array, or an array of either for
+// a repeated field) into the string stored here.
+type journalEntry map[string]string
 
 // parseJournalEntry converts a JSON line from journalctl into a LogEntry.
 func (j *JournalIngestor) parseJournalEntry(line string) (LogEntry, error) {
-	var je journalEntry
+	var raw map[string]json.RawMessage
 
 	// GO SYNTAX LESSON #27: Error Handling Pattern
 	// =============================================
@@ -238,48 +467,154 @@ func (j *JournalIngestor) parseJournalEntry(line string) (LogEntry, error) {
 	//
 	// The %w verb wraps the error, preserving the error chain.
 	// You can unwrap with errors.Unwrap() or errors.Is().
-	if err := json.Unmarshal([]byte(line), &je); err != nil {
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
 		return LogEntry{}, fmt.Errorf("failed to parse journal JSON: %w", err)
 	}
 
+	je := make(journalEntry, len(raw))
+	for name, value := range raw {
+		decoded, ok, err := decodeJournalValue(value)
+		if err != nil {
+			return LogEntry{}, fmt.Errorf("failed to parse journal field %s: %w", name, err)
+		}
+		if ok {
+			je[name] = decoded
+		}
+	}
+	return j.toLogEntry(je, line), nil
+}
+
+// decodeJournalValue decodes one field value of journalctl's JSON. Text
+// is a string; anything else (binary data, invalid UTF-8, control
+// characters) is an array of byte values; a field with several values
+// is an array of those; null means the value was too large to print and
+// ok is false.
+func decodeJournalValue(value json.RawMessage) (decoded string, ok bool, err error) {
+	switch {
+	case len(value) == 0 || string(value) == "null":
+		return "", false, nil
+
+	case value[0] == '"':
+		err = json.Unmarshal(value, &decoded)
+		return decoded, err == nil, err
+
+	case value[0] == '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(value, &items); err != nil {
+			return "", false, err
+		}
+		if len(items) == 0 || (items[0][0] != '"' && items[0][0] != '[' && string(items[0]) != "null") {
+			// A single value as bytes
+			var bytes []uint8
+			var ints []int
+			if err := json.Unmarshal(value, &ints); err != nil {
+				return "", false, err
+			}
+			for _, b := range ints {
+				if b < 0 || b > 255 {
+					return "", false, fmt.Errorf("byte value %d out of range", b)
+				}
+				bytes = append(bytes, uint8(b))
+			}
+			return string(bytes), true, nil
+		}
+
+		// Several values of one field
+		var values []string
+		for _, item := range items {
+			v, ok, err := decodeJournalValue(item)
+			if err != nil {
+				return "", false, err
+			}
+			if ok {
+				values = append(values, v)
+			}
+		}
+		return strings.Join(values, "\n"), len(values) > 0, nil
+
+	default:
+		return "", false, fmt.Errorf("unexpected value %s", value)
+	}
+}
+
+// toLogEntry converts the fields of one journal entry, however they were
+// read, into a LogEntry. raw is the entry as journalctl's JSON.
+//
+// The fields with a LogEntry counterpart are mapped onto it; every other
+// field the fields/exclude_fields options let through goes into Metadata
+// under its journal name, next to the "cursor" and "transport" keys.
+func (j *JournalIngestor) toLogEntry(je journalEntry, raw string) LogEntry {
 	// Parse timestamp
 	// journalctl outputs microseconds since epoch as a string
 	ts := time.Now() // default to now if parsing fails
-	if je.RealtimeTimestamp != "" {
-		if usec, err := strconv.ParseInt(je.RealtimeTimestamp, 10, 64); err == nil {
-			ts = time.UnixMicro(usec)
-		}
+	if usec, err := strconv.ParseInt(je["__REALTIME_TIMESTAMP"], 10, 64); err == nil {
+		ts = time.UnixMicro(usec)
 	}
 
-	// Parse priority (0-7, where 0 is emergency and 7 is debug)
+	// Parse priority (0-7, where 0 is emergency and 7 is debug), unless
+	// the source maps it to a level of its own
 	level := LevelUnknown
-	if je.Priority != "" {
-		if prio, err := strconv.Atoi(je.Priority); err == nil {
-			level = priorityToLevel(prio)
-		}
+	if mapped, ok := j.levels.names.mapped(je["PRIORITY"]); ok {
+		level = mapped
+	} else if prio, err := strconv.Atoi(je["PRIORITY"]); err == nil {
+		level = priorityToLevel(prio)
 	}
 
 	// Determine source name
 	source := j.config.Name
-	if je.SyslogIdentifier != "" {
-		source = je.SyslogIdentifier
+	if je["SYSLOG_IDENTIFIER"] != "" {
+		source = je["SYSLOG_IDENTIFIER"]
 	}
 
-	return LogEntry{
+	metadata := map[string]string{
+		"transport": je["_TRANSPORT"],
+		"cursor":    je["__CURSOR"],
+	}
+	for name, value := range je {
+		switch name {
+		case "MESSAGE", "_TRANSPORT", "__CURSOR":
+			// Already part of the entry
+		default:
+			if j.keepField(name) {
+				metadata[name] = value
+			}
+		}
+	}
+
+	entry := LogEntry{
 		Timestamp:    ts,
 		Source:       source,
 		IngestorName: j.config.Name, // Config name for filtering
 		SourceType:   SourceJournald,
 		Level:        level,
-		Message:      je.Message,
-		Unit:         je.SystemdUnit,
-		Hostname:     je.Hostname,
-		PID:          parseInt(je.PID),
-		Raw:          line,
-		Metadata: map[string]string{
-			"transport": je.Transport,
-		},
-	}, nil
+		Message:      je["MESSAGE"],
+		Unit:         je["_SYSTEMD_UNIT"],
+		Hostname:     je["_HOSTNAME"],
+		PID:          parseInt(je["_PID"]),
+		Raw:          raw,
+		Metadata:     metadata,
+	}
+	j.levels.apply(&entry)
+	return entry
+}
+
+// keepField applies the fields (allow) and exclude_fields (deny) lists
+// to a field name. Both hold shell patterns like "_SYSTEMD_*".
+func (j *JournalIngestor) keepField(name string) bool {
+	if len(j.config.Fields) > 0 && !matchAny(j.config.Fields, name) {
+		return false
+	}
+	return !matchAny(j.config.ExcludeFields, name)
+}
+
+// matchAny reports whether name matches any of patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
 }
 
 // priorityToLevel converts syslog priority (0-7) to LogLevel.
@@ -329,4 +664,9 @@ func parseInt(s string) int {
 //
 // The underscore _ means "discard this value" - we don't need the variable,
 // just the type check.
-var _ Ingestor = (*JournalIngestor)(nil)
+var (
+	_ Ingestor        = (*JournalIngestor)(nil)
+	_ HistoryReader   = (*JournalIngestor)(nil)
+	_ PositionTracker = (*JournalIngestor)(nil)
+	_ BootLister      = (*JournalIngestor)(nil)
+)
@@ -0,0 +1,256 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// journalFilter holds a journald source's entry filters, validated, in
+// a form both backends use: journalctl arguments built by Argus, never
+// copied from the config, and a predicate for the native reader.
+//
+// Each kind of filter narrows the entries, as with journalctl: an entry
+// must be from one of the units, one of the identifiers, in the priority
+// range, match every matched field and match the grep pattern.
+type journalFilter struct {
+	units       []string
+	identifiers []string
+
+	// priorityFrom and priorityTo bound PRIORITY; priorityTo < 0 means
+	// no priority filter
+	priorityFrom int
+	priorityTo   int
+
+	// fields lists the matched fields in config order; matches holds the
+	// accepted values of each
+	fields  []string
+	matches map[string][]string
+
+	// grep is compiled from grepPattern, which journalctl is given
+	grep        *regexp.Regexp
+	grepPattern string
+}
+
+// fieldNameRegex matches a journal field name.
+var fieldNameRegex = regexp.MustCompile(`^[A-Z_][A-Z0-9_]{0,63}$`)
+
+// unitRegex matches a systemd unit name or a shell pattern of one.
+var unitRegex = regexp.MustCompile(`^[A-Za-z0-9:_.@\\*?\[\]-]+$`)
+
+// unitSuffixes are the systemd unit types; a unit named without one is a
+// service, as journalctl -u assumes.
+var unitSuffixes = []string{
+	".service", ".socket", ".target", ".device", ".mount", ".automount",
+	".swap", ".timer", ".path", ".slice", ".scope",
+}
+
+// parseJournalFilter validates the filter options of a journald source.
+func parseJournalFilter(config SourceConfig) (journalFilter, error) {
+	f := journalFilter{priorityTo: -1, matches: make(map[string][]string)}
+
+	for _, unit := range config.Units {
+		if !unitRegex.MatchString(unit) || strings.HasPrefix(unit, "-") {
+			return f, fmt.Errorf("invalid unit %q", unit)
+		}
+		if _, err := path.Match(unit, ""); err != nil {
+			return f, fmt.Errorf("invalid unit pattern %q: %w", unit, err)
+		}
+		f.units = append(f.units, mangleUnit(unit))
+	}
+
+	for _, id := range config.Identifiers {
+		if id == "" || strings.ContainsFunc(id, unicode.IsControl) {
+			return f, fmt.Errorf("invalid identifier %q", id)
+		}
+		f.identifiers = append(f.identifiers, id)
+	}
+
+	switch {
+	case config.Priority != nil && config.PriorityRange != "":
+		return f, fmt.Errorf("priority and priority_range can't both be set")
+	case config.Priority != nil:
+		if *config.Priority < 0 || *config.Priority > 7 {
+			return f, fmt.Errorf("priority must be between 0 and 7, got %d", *config.Priority)
+		}
+		f.priorityFrom, f.priorityTo = 0, *config.Priority
+	case config.PriorityRange != "":
+		from, to, err := parsePriorityRange(config.PriorityRange)
+		if err != nil {
+			return f, fmt.Errorf("priority_range: %w", err)
+		}
+		f.priorityFrom, f.priorityTo = from, to
+	}
+
+	for _, match := range config.Matches {
+		field, value, ok := strings.Cut(match, "=")
+		if !ok || !fieldNameRegex.MatchString(field) {
+			return f, fmt.Errorf("match %q must be FIELD=value with an uppercase field name", match)
+		}
+		if _, seen := f.matches[field]; !seen {
+			f.fields = append(f.fields, field)
+		}
+		f.matches[field] = append(f.matches[field], value)
+	}
+
+	if config.Grep != "" {
+		pattern := config.Grep
+		// Like journalctl, a pattern without capitals ignores case
+		if !strings.ContainsFunc(pattern, unicode.IsUpper) {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return f, fmt.Errorf("invalid grep pattern: %w", err)
+		}
+		f.grep, f.grepPattern = re, config.Grep
+	}
+
+	return f, nil
+}
+
+// mangleUnit adds the .service suffix journalctl assumes for a unit
+// named without a type.
+func mangleUnit(unit string) string {
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(unit, suffix) {
+			return unit
+		}
+	}
+	if strings.ContainsAny(unit, "*?[") {
+		return unit
+	}
+	return unit + ".service"
+}
+
+// parsePriorityRange parses "FROM..TO" or a single priority, each a
+// name ("err", "warning") or a number 0-7. A single priority means it
+// and everything more severe.
+func parsePriorityRange(s string) (from, to int, err error) {
+	fromName, toName, isRange := strings.Cut(s, "..")
+	if !isRange {
+		to, err = parsePriority(s)
+		return 0, to, err
+	}
+	if from, err = parsePriority(fromName); err != nil {
+		return 0, 0, err
+	}
+	if to, err = parsePriority(toName); err != nil {
+		return 0, 0, err
+	}
+	if from > to {
+		from, to = to, from
+	}
+	return from, to, nil
+}
+
+// parsePriority converts a level name or syslog priority digit into a
+// syslog priority (0 = emergency, 7 = debug).
+func parsePriority(name string) (int, error) {
+	level, err := ParseLevel(name)
+	if err != nil {
+		return 0, fmt.Errorf("unknown priority %q", name)
+	}
+	// The LogLevel constants run the other way, from debug (1) to emergency (8)
+	return int(LevelEmergency - level), nil
+}
+
+// empty reports whether the filter lets every entry through.
+func (f journalFilter) empty() bool {
+	return len(f.units) == 0 && len(f.identifiers) == 0 && f.priorityTo < 0 &&
+		len(f.fields) == 0 && f.grep == nil
+}
+
+// args returns the journalctl arguments for the filter. Options carry
+// their value after "=" and field matches start with a field name, so
+// no value can be read as another option.
+func (f journalFilter) args() []string {
+	var args []string
+	for _, unit := range f.units {
+		args = append(args, "--unit="+unit)
+	}
+	for _, id := range f.identifiers {
+		args = append(args, "--identifier="+id)
+	}
+	switch {
+	case f.priorityTo < 0:
+	case f.priorityFrom == 0:
+		args = append(args, "--priority="+strconv.Itoa(f.priorityTo))
+	default:
+		args = append(args, fmt.Sprintf("--priority=%d..%d", f.priorityFrom, f.priorityTo))
+	}
+	if f.grep != nil {
+		args = append(args, "--grep="+f.grepPattern)
+	}
+	for _, field := range f.fields {
+		for _, value := range f.matches[field] {
+			args = append(args, field+"="+value)
+		}
+	}
+	return args
+}
+
+// match reports whether an entry's fields pass the filter, the way
+// journalctl applies the same arguments.
+func (f journalFilter) match(fields map[string]string) bool {
+	if len(f.units) > 0 && !f.matchUnit(fields) {
+		return false
+	}
+	if len(f.identifiers) > 0 && !anyValue(fields["SYSLOG_IDENTIFIER"], func(v string) bool {
+		return slices.Contains(f.identifiers, v)
+	}) {
+		return false
+	}
+	if f.priorityTo >= 0 {
+		// Like journalctl -p, entries without a priority don't match
+		prio, err := strconv.Atoi(fields["PRIORITY"])
+		if err != nil || prio < f.priorityFrom || prio > f.priorityTo {
+			return false
+		}
+	}
+	for _, field := range f.fields {
+		value, ok := fields[field]
+		if !ok || !anyValue(value, func(v string) bool { return slices.Contains(f.matches[field], v) }) {
+			return false
+		}
+	}
+	if f.grep != nil && !f.grep.MatchString(fields["MESSAGE"]) {
+		return false
+	}
+	return true
+}
+
+// matchUnit checks the fields journalctl -u matches: the unit that
+// logged the entry, and systemd's and coredump's messages about the unit.
+func (f journalFilter) matchUnit(fields map[string]string) bool {
+	candidates := []string{fields["_SYSTEMD_UNIT"], fields["COREDUMP_UNIT"]}
+	if fields["_PID"] == "1" {
+		candidates = append(candidates, fields["UNIT"])
+	}
+	if fields["_UID"] == "0" {
+		candidates = append(candidates, fields["OBJECT_SYSTEMD_UNIT"])
+	}
+	for _, unit := range candidates {
+		if unit != "" && anyValue(unit, func(v string) bool { return matchAny(f.units, v) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyValue reports whether accept takes a field's value or, for a field
+// logged more than once, any one of the values joined in it.
+func anyValue(value string, accept func(string) bool) bool {
+	if accept(value) {
+		return true
+	}
+	if !strings.Contains(value, "\n") {
+		return false
+	}
+	return slices.ContainsFunc(strings.Split(value, "\n"), accept)
+}
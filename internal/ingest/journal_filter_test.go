@@ -0,0 +1,128 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestJournalFilterArgs tests the journalctl arguments built from the
+// filter options.
+func TestJournalFilterArgs(t *testing.T) {
+	warning := 4
+
+	tests := []struct {
+		name string
+		cfg  SourceConfig
+		want string
+	}{
+		{"none", SourceConfig{}, ""},
+		{"units", SourceConfig{Units: []string{"nginx", "ssh*", "cron.timer"}},
+			"--unit=nginx.service --unit=ssh* --unit=cron.timer"},
+		{"identifiers", SourceConfig{Identifiers: []string{"sudo", "-D"}}, "--identifier=sudo --identifier=-D"},
+		{"priority", SourceConfig{Priority: &warning}, "--priority=4"},
+		{"priority range", SourceConfig{PriorityRange: "warning..err"}, "--priority=3..4"},
+		{"priority range from emerg", SourceConfig{PriorityRange: "emerg..3"}, "--priority=3"},
+		{"grep", SourceConfig{Grep: "Failed password"}, "--grep=Failed password"},
+		{"matches", SourceConfig{Matches: []string{"_UID=0", "_COMM=sshd", "_UID=1000"}},
+			"_UID=0 _UID=1000 _COMM=sshd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parseJournalFilter(tt.cfg)
+			if err != nil {
+				t.Fatalf("parseJournalFilter() error: %v", err)
+			}
+			if got := strings.Join(f.args(), " "); got != tt.want {
+				t.Errorf("args() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestJournalFilterValidate tests that filter values can't smuggle in
+// journalctl options.
+func TestJournalFilterValidate(t *testing.T) {
+	priority := 3
+
+	tests := []struct {
+		name    string
+		cfg     SourceConfig
+		wantErr bool
+	}{
+		{"unit pattern", SourceConfig{Units: []string{"user@1000.service", "ssh*"}}, false},
+		{"unit option", SourceConfig{Units: []string{"--directory=/tmp"}}, true},
+		{"unit with space", SourceConfig{Units: []string{"nginx -f"}}, true},
+		{"bad unit pattern", SourceConfig{Units: []string{"ssh["}}, true},
+		{"empty identifier", SourceConfig{Identifiers: []string{""}}, true},
+		{"priority range names", SourceConfig{PriorityRange: "crit..warning"}, false},
+		{"priority range unknown", SourceConfig{PriorityRange: "err..loud"}, true},
+		{"priority and range", SourceConfig{Priority: &priority, PriorityRange: "0..3"}, true},
+		{"match", SourceConfig{Matches: []string{"MESSAGE_ID=fc2e22bc6ee647b6b90729ab34a250b1"}}, false},
+		{"match option", SourceConfig{Matches: []string{"--merge"}}, true},
+		{"match lowercase field", SourceConfig{Matches: []string{"comm=sshd"}}, true},
+		{"match option field", SourceConfig{Matches: []string{"--file=/etc/shadow"}}, true},
+		{"bad grep", SourceConfig{Grep: "(unclosed"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseJournalFilter(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("parseJournalFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestJournalFilterMatch tests the native backend's filtering.
+func TestJournalFilterMatch(t *testing.T) {
+	sshd := map[string]string{
+		"_SYSTEMD_UNIT": "ssh.service", "SYSLOG_IDENTIFIER": "sshd", "PRIORITY": "4",
+		"_UID": "0", "MESSAGE": "Failed password for root",
+	}
+	pid1 := map[string]string{
+		"_PID": "1", "UNIT": "ssh.service", "SYSLOG_IDENTIFIER": "systemd", "PRIORITY": "6",
+		"MESSAGE": "Started OpenSSH server",
+	}
+	// Fields logged twice, joined as both backends read them
+	repeated := map[string]string{
+		"_SYSTEMD_UNIT": "app.service", "SYSLOG_IDENTIFIER": "app\nworker", "TAG": "a\nb",
+		"MESSAGE": "started",
+	}
+
+	tests := []struct {
+		name   string
+		cfg    SourceConfig
+		fields map[string]string
+		want   bool
+	}{
+		{"no filter", SourceConfig{}, sshd, true},
+		{"unit", SourceConfig{Units: []string{"ssh"}}, sshd, true},
+		{"unit pattern", SourceConfig{Units: []string{"ss*"}}, sshd, true},
+		{"unit from systemd", SourceConfig{Units: []string{"ssh"}}, pid1, true},
+		{"other unit", SourceConfig{Units: []string{"nginx"}}, sshd, false},
+		{"identifier", SourceConfig{Identifiers: []string{"sshd"}}, sshd, true},
+		{"unit and other identifier", SourceConfig{Units: []string{"ssh"}, Identifiers: []string{"cron"}}, sshd, false},
+		{"priority range", SourceConfig{PriorityRange: "err..warning"}, sshd, true},
+		{"outside priority range", SourceConfig{PriorityRange: "err..warning"}, pid1, false},
+		{"match", SourceConfig{Matches: []string{"_UID=1000", "_UID=0"}}, sshd, true},
+		{"missing field", SourceConfig{Matches: []string{"_UID=0"}}, pid1, false},
+		{"repeated identifier", SourceConfig{Identifiers: []string{"worker"}}, repeated, true},
+		{"repeated field", SourceConfig{Matches: []string{"TAG=b"}}, repeated, true},
+		{"repeated field other value", SourceConfig{Matches: []string{"TAG=c"}}, repeated, false},
+		{"grep ignores case", SourceConfig{Grep: "failed"}, sshd, true},
+		{"grep with capitals", SourceConfig{Grep: "FAILED"}, sshd, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parseJournalFilter(tt.cfg)
+			if err != nil {
+				t.Fatalf("parseJournalFilter() error: %v", err)
+			}
+			if got := f.match(tt.fields); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
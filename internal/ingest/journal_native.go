@@ -0,0 +1,400 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Expert21/argus/internal/journal"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Journal backends.
+const (
+	backendJournalctl = "journalctl"
+	backendNative     = "native"
+)
+
+// defaultJournalLines is how many entries journalctl -f shows at startup
+// without other history options; the native backend does the same.
+const defaultJournalLines = 10
+
+// bootIDPath holds the current boot's ID.
+const bootIDPath = "/proc/sys/kernel/random/boot_id"
+
+// journalDirs are read by the native backend; tests point it elsewhere.
+var journalDirs = journal.DefaultDirs
+
+// backend returns the configured backend, or journalctl if it is on the
+// PATH and native otherwise.
+func (j *JournalIngestor) backend() string {
+	if j.config.Backend != "" {
+		return j.config.Backend
+	}
+	if _, err := exec.LookPath("journalctl"); err != nil {
+		return backendNative
+	}
+	return backendJournalctl
+}
+
+// openNative opens the journal files and positions them where reading
+// should begin. It returns the filter for entries to send and how many of
+// the entries read before following are kept (0 for all).
+func (j *JournalIngestor) openNative() (*journal.Dir, func(*journal.Entry) bool, int, error) {
+	d, err := j.openJournal()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if len(d.Files()) == 0 && len(d.Skipped()) > 0 {
+		d.Close()
+		return nil, nil, 0, fmt.Errorf("no readable journal files (%s): %w", d.Skipped()[0], os.ErrPermission)
+	}
+
+	keep, err := j.seekNative(d)
+	if err != nil {
+		d.Close()
+		return nil, nil, 0, err
+	}
+	match, err := j.nativeFilter(d)
+	if err != nil {
+		d.Close()
+		return nil, nil, 0, err
+	}
+	return d, match, keep, nil
+}
+
+// openJournal opens the configured journal file or directory, or the
+// system journal, in the configured namespace.
+func (j *JournalIngestor) openJournal() (*journal.Dir, error) {
+	switch {
+	case j.config.Path == "":
+		return journal.OpenNamespace(j.config.Namespace, journalDirs...)
+	case isJournalFile(j.config.Path):
+		return journal.OpenFiles(j.config.Path)
+	default:
+		return journal.OpenNamespace(j.config.Namespace, j.config.Path)
+	}
+}
+
+// seekNative applies a resumed cursor or the since and lines options,
+// mirroring args and historyArgs.
+func (j *JournalIngestor) seekNative(d *journal.Dir) (int, error) {
+	if j.config.Resume && j.store != nil {
+		if pos, ok := j.store.Load(j.config.Name); ok && pos.Cursor != "" {
+			return 0, d.SeekCursor(pos.Cursor)
+		}
+	}
+
+	if j.config.Since != "" {
+		since, err := ParseSince(j.config.Since, time.Now())
+		if err != nil {
+			return 0, fmt.Errorf("since: %w", err)
+		}
+		d.SeekRealtime(since)
+	}
+
+	lines := j.config.Lines
+	if lines == 0 && j.config.Since == "" && j.config.Boot == "" {
+		lines = defaultJournalLines
+	}
+	filter, err := parseJournalFilter(j.config)
+	if err != nil {
+		return 0, err
+	}
+	if lines > 0 && filter.empty() && j.config.Boot == "" {
+		// Without filters the last N entries are within N of each file's end
+		if err := d.Tail(lines); err != nil {
+			return 0, err
+		}
+	}
+	return lines, nil
+}
+
+// nativeFilter returns a predicate for the boot option and the filters
+// journalctl would be given.
+func (j *JournalIngestor) nativeFilter(d *journal.Dir) (func(*journal.Entry) bool, error) {
+	filter, err := parseJournalFilter(j.config)
+	if err != nil {
+		return nil, err
+	}
+	boot, err := j.nativeBoot(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(e *journal.Entry) bool {
+		if boot != "" && fmt.Sprintf("%x", e.BootID) != boot {
+			return false
+		}
+		return filter.match(e.Fields)
+	}, nil
+}
+
+// nativeBoot resolves the boot option to a boot ID, "" for every boot.
+// The current boot of the live system is the running kernel's; in a
+// journal from elsewhere it is the last boot recorded.
+func (j *JournalIngestor) nativeBoot(d *journal.Dir) (string, error) {
+	switch {
+	case j.config.Boot == "":
+		return "", nil
+	case len(j.config.Boot) == 32:
+		return j.config.Boot, nil
+	case j.config.Path == "" && (j.config.Boot == "current" || j.config.Boot == "0"):
+		id, err := os.ReadFile(bootIDPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read boot ID: %w", err)
+		}
+		return strings.ReplaceAll(strings.TrimSpace(string(id)), "-", ""), nil
+	}
+
+	offset := 0
+	if j.config.Boot != "current" {
+		var err error
+		if offset, err = strconv.Atoi(j.config.Boot); err != nil {
+			return "", fmt.Errorf("invalid boot %q", j.config.Boot)
+		}
+	}
+	boots, err := d.Boots()
+	if err != nil {
+		return "", err
+	}
+	boot, err := journal.ResolveBoot(boots, offset)
+	if err != nil {
+		return "", err
+	}
+	return boot.IDString(), nil
+}
+
+// listNativeBoots lists the boots in the journal files.
+func (j *JournalIngestor) listNativeBoots() ([]Boot, error) {
+	d, err := j.openJournal()
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	found, err := d.Boots()
+	if err != nil {
+		return nil, err
+	}
+	boots := make([]Boot, len(found))
+	for i, b := range found {
+		boots[i] = Boot{Index: i - (len(found) - 1), ID: b.IDString(), First: b.First, Last: b.Last}
+	}
+	return boots, nil
+}
+
+// sendNative sends every entry written so far that match accepts, in
+// order, recording the cursor of each. Entries go out as they are read,
+// as journalctl streams them, so a boot or since read over a whole
+// journal isn't held in memory; only with keep > 0 are the entries kept
+// back until the last keep are known. It returns false once ctx is done.
+func (j *JournalIngestor) sendNative(ctx context.Context, entries chan<- LogEntry, d *journal.Dir, match func(*journal.Entry) bool, keep int, historical bool) bool {
+	var kept []LogEntry
+	for ctx.Err() == nil {
+		e, err := d.Next()
+		if e == nil {
+			if err == nil || errors.Is(err, io.EOF) {
+				break
+			}
+			// A damaged file is dropped; the others carry on
+			continue
+		}
+		if !match(e) {
+			continue
+		}
+		entry := j.nativeEntry(e, err)
+		entry.Historical = historical
+		if keep == 0 {
+			if !j.sendNativeEntry(ctx, entries, entry) {
+				return false
+			}
+			continue
+		}
+		kept = append(kept, entry)
+		if len(kept) > 2*keep {
+			kept = append(kept[:0], kept[len(kept)-keep:]...)
+		}
+	}
+	if len(kept) > keep {
+		kept = kept[len(kept)-keep:]
+	}
+	for _, entry := range kept {
+		if !j.sendNativeEntry(ctx, entries, entry) {
+			return false
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// nativeEntry converts an entry read from a journal file. Raw is the
+// entry as journalctl -o json would print it.
+func (j *JournalIngestor) nativeEntry(e *journal.Entry, readErr error) LogEntry {
+	je := make(journalEntry, len(e.Fields)+3)
+	for name, value := range e.Fields {
+		je[name] = value
+	}
+	je["__CURSOR"] = e.Cursor()
+	je["__REALTIME_TIMESTAMP"] = strconv.FormatInt(e.Realtime.UnixMicro(), 10)
+	je["__MONOTONIC_TIMESTAMP"] = strconv.FormatInt(e.Monotonic.Microseconds(), 10)
+
+	entry := j.toLogEntry(je, encodeJournalJSON(je))
+
+	// Say why the message is missing rather than showing a blank line
+	var cerr *journal.CompressionError
+	if _, ok := e.Fields["MESSAGE"]; !ok && errors.As(readErr, &cerr) {
+		entry.Message = "[" + cerr.Error() + "]"
+	}
+	return entry
+}
+
+// encodeJournalJSON encodes fields the way journalctl -o json does:
+// printable UTF-8 as strings, anything else as an array of bytes.
+func encodeJournalJSON(je journalEntry) string {
+	out := make(map[string]any, len(je))
+	for name, value := range je {
+		if printable(value) {
+			out[name] = value
+			continue
+		}
+		bytes := make([]int, len(value))
+		for i := 0; i < len(value); i++ {
+			bytes[i] = int(value[i])
+		}
+		out[name] = bytes
+	}
+	raw, _ := json.Marshal(out)
+	return string(raw)
+}
+
+// printable reports whether s is valid UTF-8 without control characters
+// other than newline and tab, which journalctl prints as a string.
+func printable(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// sendNativeEntry sends an entry and records its cursor.
+func (j *JournalIngestor) sendNativeEntry(ctx context.Context, entries chan<- LogEntry, entry LogEntry) bool {
+	if !sendEntry(ctx, entries, entry) {
+		return false
+	}
+	if j.store != nil {
+		j.store.Save(j.config.Name, Position{Cursor: entry.Metadata["cursor"], Time: time.Now()})
+	}
+	return true
+}
+
+// startNative follows the journal files directly. journald truncates a
+// file to its own size after writing, which makes inotify report the
+// write even though the data went through mmap.
+func (j *JournalIngestor) startNative(ctx context.Context, entries chan<- LogEntry) error {
+	d, match, keep, err := j.openNative()
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		d.Close()
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	switch {
+	case j.config.Path == "":
+		for _, dir := range journalDirs {
+			watchJournalDir(watcher, dir)
+		}
+	case isJournalFile(j.config.Path):
+		watchJournalDir(watcher, filepath.Dir(j.config.Path))
+	default:
+		watchJournalDir(watcher, j.config.Path)
+	}
+
+	j.setHealthy(true)
+	go func() {
+		defer j.setHealthy(false)
+		defer d.Close()
+		defer watcher.Close()
+
+		if !j.sendNative(ctx, entries, d, match, keep, true) {
+			return
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&fsnotify.Create == fsnotify.Create {
+					// A new machine directory
+					watchJournalDir(watcher, event.Name)
+				}
+				if strings.HasSuffix(event.Name, ".journal") && !d.HasFile(event.Name) {
+					// journald started a new file; its header may only
+					// be complete by a later write
+					_ = d.Refresh()
+				}
+				if !j.sendNative(ctx, entries, d, match, 0, false) {
+					return
+				}
+
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// watchJournalDir watches dir and, for the top-level journal directories,
+// the per-machine directories inside it. Paths that aren't directories
+// are ignored.
+func watchJournalDir(watcher *fsnotify.Watcher, dir string) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return
+	}
+	_ = watcher.Add(dir)
+	subdirs, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, sub := range subdirs {
+		if info, err := os.Stat(sub); err == nil && info.IsDir() {
+			_ = watcher.Add(sub)
+		}
+	}
+}
+
+// readNativeHistory sends the requested history from the journal files.
+func (j *JournalIngestor) readNativeHistory(ctx context.Context, entries chan<- LogEntry) error {
+	d, match, keep, err := j.openNative()
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	j.sendNative(ctx, entries, d, match, keep, true)
+	return nil
+}
@@ -0,0 +1,256 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useJournalFixtures points the native backend at a directory holding
+// copies of the named journal fixtures and returns the directory.
+func useJournalFixtures(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		copyJournalFixture(t, name, dir)
+	}
+	saved := journalDirs
+	journalDirs = []string{dir}
+	t.Cleanup(func() { journalDirs = saved })
+	return dir
+}
+
+// copyJournalFixture copies one of the journal package's fixtures into dir.
+func copyJournalFixture(t *testing.T, name, dir string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "journal", "testdata", name+".journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write under a temporary name so the watcher never sees half a file
+	tmp := filepath.Join(dir, "."+name)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, name+".journal")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// messages returns the message of each entry.
+func messages(entries []LogEntry) []string {
+	got := make([]string, len(entries))
+	for i, e := range entries {
+		got[i] = e.Message
+	}
+	return got
+}
+
+// TestJournalNativeHistory tests the history options against the journal
+// files directly.
+func TestJournalNativeHistory(t *testing.T) {
+	useJournalFixtures(t, "regular")
+	warning := 4
+
+	tests := []struct {
+		name string
+		cfg  SourceConfig
+		want []string
+	}{
+		{"default last 10", SourceConfig{Lines: 0}, []string{
+			"Received SIGTERM from PID 13783 (mkjournal.sh).", "Journal started",
+			"Runtime Journal (/run/log/journal/fed6b2924c424cf1b9a322f606b4de6d) is 512.0K, max 2.0M, 1.5M free.",
+			"sshd started", "Failed password for root from 10.0.0.5", "disk failure", "last entry", "Journal stopped",
+		}},
+		{"lines", SourceConfig{Lines: 2}, []string{"last entry", "Journal stopped"}},
+		{"priority", SourceConfig{Priority: &warning}, []string{
+			"Failed password for root from 10.0.0.5", "disk failure",
+		}},
+		{"priority range", SourceConfig{PriorityRange: "crit..warning"}, []string{
+			"Failed password for root from 10.0.0.5", "disk failure",
+		}},
+		{"identifiers", SourceConfig{Identifiers: []string{"sshd", "app"}}, []string{
+			"sshd started", "Failed password for root from 10.0.0.5", "last entry",
+		}},
+		{"matches", SourceConfig{Matches: []string{"SYSLOG_IDENTIFIER=sshd", "PRIORITY=4", "PRIORITY=6"}}, []string{
+			"sshd started", "Failed password for root from 10.0.0.5",
+		}},
+		{"grep", SourceConfig{Grep: "journal (started|stopped)"}, []string{"Journal started", "Journal stopped"}},
+		{"since", SourceConfig{Since: time.UnixMicro(1792154492720000).Format(time.RFC3339Nano)}, []string{
+			"disk failure", "last entry", "Journal stopped",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Name = "J"
+			tt.cfg.Backend = backendNative
+			j := NewJournalIngestor(tt.cfg)
+
+			entries := make(chan LogEntry, 100)
+			if err := j.ReadHistory(context.Background(), entries); err != nil {
+				t.Fatalf("ReadHistory() error: %v", err)
+			}
+			close(entries)
+			var got []LogEntry
+			for e := range entries {
+				got = append(got, e)
+			}
+
+			if msgs := messages(got); strings.Join(msgs, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("messages = %q, want %q", msgs, tt.want)
+			}
+
+			for _, e := range got {
+				if !e.Historical || e.Metadata["cursor"] == "" || !strings.Contains(e.Raw, `"__CURSOR"`) {
+					t.Errorf("entry %q: Historical = %v, cursor = %q, Raw = %s",
+						e.Message, e.Historical, e.Metadata["cursor"], e.Raw)
+				}
+			}
+		})
+	}
+}
+
+// TestJournalNativeFollow tests resuming from a cursor and picking up
+// a journal file that appears while following.
+func TestJournalNativeFollow(t *testing.T) {
+	dir := useJournalFixtures(t, "regular")
+
+	// Resume after "disk failure", the sixth entry
+	store := newMemStore()
+	store.Save("J", Position{
+		Cursor: "s=1922460be9184d16a05be1a9201f3db1;i=6;b=525d261e6f794711881ab53b8f3bd1b4;m=0;t=0;x=0",
+	})
+	j := NewJournalIngestor(SourceConfig{Name: "J", Backend: backendNative, Resume: true})
+	j.TrackPosition(store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	entries := make(chan LogEntry, 100)
+	if err := j.Start(ctx, entries); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer j.Stop()
+
+	receive := func(n int) []string {
+		t.Helper()
+		var got []LogEntry
+		for len(got) < n {
+			select {
+			case e := <-entries:
+				got = append(got, e)
+			case <-time.After(2 * time.Second):
+				t.Fatalf("received %q, want %d entries", messages(got), n)
+			}
+		}
+		return messages(got)
+	}
+
+	if got := receive(2); got[0] != "last entry" || got[1] != "Journal stopped" {
+		t.Errorf("resumed entries = %q", got)
+	}
+
+	copyJournalFixture(t, "zstd", dir)
+	got := receive(9)
+	if got[3] != "sshd started" {
+		t.Errorf("new file entries = %q", got)
+	}
+	if !strings.Contains(got[6], "zstd") {
+		t.Errorf("compressed message = %q, want it to name zstd", got[6])
+	}
+
+	if pos, _ := store.Load("J"); !strings.Contains(pos.Cursor, "i=9;") {
+		t.Errorf("saved cursor = %q, want the last entry's", pos.Cursor)
+	}
+}
+
+// TestJournalValidateBackend tests the backend option.
+func TestJournalValidateBackend(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SourceConfig
+		wantErr bool
+	}{
+		{"journalctl", SourceConfig{Backend: "journalctl", Units: []string{"ssh"}}, false},
+		{"native", SourceConfig{Backend: "native", Boot: "current"}, false},
+		{"native filters", SourceConfig{Backend: "native", Units: []string{"ssh"}, Grep: "fail"}, false},
+		{"native boot offset", SourceConfig{Backend: "native", Boot: "-1"}, false},
+		{"namespace", SourceConfig{Namespace: "audit"}, false},
+		{"bad namespace", SourceConfig{Namespace: "../etc"}, true},
+		{"unknown", SourceConfig{Backend: "sd-journal"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateJournal(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateJournal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestEncodeJournalJSON tests that native entries get the same Raw JSON
+// journalctl prints, binary values included.
+func TestEncodeJournalJSON(t *testing.T) {
+	je := journalEntry{"MESSAGE": "multi\nline\ttext", "BIN": "a\x00b", "BAD": "\xff"}
+	raw := encodeJournalJSON(je)
+	want := `{"BAD":[255],"BIN":[97,0,98],"MESSAGE":"multi\nline\ttext"}`
+	if raw != want {
+		t.Errorf("encodeJournalJSON() = %s, want %s", raw, want)
+	}
+
+	entry, err := NewJournalIngestor(SourceConfig{}).parseJournalEntry(raw)
+	if err != nil {
+		t.Fatalf("parseJournalEntry() error: %v", err)
+	}
+	if entry.Message != je["MESSAGE"] || entry.Metadata["BIN"] != je["BIN"] || entry.Metadata["BAD"] != je["BAD"] {
+		t.Errorf("round trip = %q, %q, %q", entry.Message, entry.Metadata["BIN"], entry.Metadata["BAD"])
+	}
+}
+
+// TestJournalNativeBoots tests listing and selecting boots in a copied
+// journal directory.
+func TestJournalNativeBoots(t *testing.T) {
+	dir := t.TempDir()
+	copyJournalFixture(t, "regular", dir)
+
+	// The fixture holds a single boot; the journal package's tests cover
+	// telling several apart
+	j := NewJournalIngestor(SourceConfig{Name: "J", Backend: backendNative, Path: dir})
+	boots, err := j.ListBoots(context.Background())
+	if err != nil || len(boots) != 1 || boots[0].Index != 0 {
+		t.Fatalf("ListBoots() = %+v, %v; want one boot", boots, err)
+	}
+
+	tests := []struct {
+		boot string
+		want int
+	}{
+		{"", 8},
+		{"current", 8},
+		{"0", 8},
+		{boots[0].ID, 8},
+		{"-1", -1},
+	}
+	for _, tt := range tests {
+		j := NewJournalIngestor(SourceConfig{Name: "J", Backend: backendNative, Path: dir, Boot: tt.boot})
+		entries := make(chan LogEntry, 100)
+		err := j.ReadHistory(context.Background(), entries)
+		close(entries)
+		if tt.want < 0 {
+			if err == nil {
+				t.Errorf("boot %q: ReadHistory() succeeded, want error", tt.boot)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("boot %q: ReadHistory() error: %v", tt.boot, err)
+		}
+		if len(entries) != tt.want {
+			t.Errorf("boot %q: read %d entries, want %d", tt.boot, len(entries), tt.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package ingest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestJournalHistoryArgs tests translating since, lines and boot.
+func TestJournalHistoryArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  SourceConfig
+		want string
+	}{
+		{"none", SourceConfig{}, ""},
+		{"lines", SourceConfig{Lines: 500}, "--lines=500"},
+		{"current boot", SourceConfig{Boot: "current"}, "--boot=0 --lines=all"},
+		{"previous boot capped", SourceConfig{Boot: "-1", Lines: 20}, "--boot=-1 --lines=20"},
+		{"since", SourceConfig{Since: "2024-01-18 09:30:00"}, "--since=2024-01-18 09:30:00 --lines=all"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewJournalIngestor(tt.cfg)
+			if got := strings.Join(j.historyArgs(), " "); got != tt.want {
+				t.Errorf("historyArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// A resumed cursor replaces the configured starting point
+	store := newMemStore()
+	store.Save("J", Position{Cursor: "c"})
+	j := NewJournalIngestor(SourceConfig{Name: "J", Lines: 500, Resume: true})
+	j.TrackPosition(store)
+	if args := strings.Join(j.args(true), " "); strings.Contains(args, "--lines") {
+		t.Errorf("args() = %q, want no --lines when resuming", args)
+	}
+}
+
+// TestJournalValidateHistory tests validation of the history options.
+func TestJournalValidateHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SourceConfig
+		wantErr bool
+	}{
+		{"since duration", SourceConfig{Since: "2h"}, false},
+		{"bad since", SourceConfig{Since: "last week"}, true},
+		{"negative lines", SourceConfig{Lines: -1}, true},
+		{"boot id", SourceConfig{Boot: "0123456789abcdef0123456789abcdef"}, false},
+		{"boot offset", SourceConfig{Boot: "-2"}, false},
+		{"bad boot", SourceConfig{Boot: "yesterday"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateJournal(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateJournal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestJournalStream tests that entries from before startup are marked
+// historical and their cursors recorded.
+func TestJournalStream(t *testing.T) {
+	started := time.Date(2024, 1, 18, 12, 0, 0, 0, time.UTC)
+	output := strings.Join([]string{
+		`{"__CURSOR":"c1","__REALTIME_TIMESTAMP":"1705575600000000","MESSAGE":"from history","PRIORITY":"6"}`,
+		`not json`,
+		`{"__CURSOR":"c2","__REALTIME_TIMESTAMP":"1705579300000000","MESSAGE":"live","PRIORITY":"3"}`,
+	}, "\n")
+
+	store := newMemStore()
+	j := NewJournalIngestor(SourceConfig{Name: "J", Type: SourceJournald})
+	j.TrackPosition(store)
+
+	entries := make(chan LogEntry, 10)
+	if err := j.stream(context.Background(), strings.NewReader(output), entries, started); err != nil {
+		t.Fatalf("stream() error: %v", err)
+	}
+	close(entries)
+
+	var got []LogEntry
+	for e := range entries {
+		got = append(got, e)
+	}
+	if len(got) != 2 {
+		t.Fatalf("stream() sent %d entries, want 2", len(got))
+	}
+	if !got[0].Historical || got[1].Historical {
+		t.Errorf("Historical = %v, %v; want true, false", got[0].Historical, got[1].Historical)
+	}
+	if got[1].Level != LevelError {
+		t.Errorf("Level = %v, want %v", got[1].Level, LevelError)
+	}
+	if pos, _ := store.Load("J"); pos.Cursor != "c2" {
+		t.Errorf("saved cursor = %q, want c2", pos.Cursor)
+	}
+}
+
+// TestJournalParseFields tests capturing every field, decoding byte
+// arrays and applying the field allow and deny lists.
+func TestJournalParseFields(t *testing.T) {
+	line := `{"__CURSOR":"c1","__REALTIME_TIMESTAMP":"1705575600000000","PRIORITY":"4",` +
+		`"MESSAGE":[104,105,27,91,48,109],"_COMM":"sshd","_EXE":"/usr/sbin/sshd",` +
+		`"_SYSTEMD_UNIT":"ssh.service","_SYSTEMD_SLICE":"system.slice","CODE_FILE":"auth.c",` +
+		`"TAG":["a",[98,255]],"BIG":null,"_TRANSPORT":"syslog"}`
+
+	tests := []struct {
+		name    string
+		cfg     SourceConfig
+		want    []string // Metadata keys, besides cursor and transport
+		notWant []string
+	}{
+		{"all fields", SourceConfig{},
+			[]string{"_COMM", "_EXE", "_SYSTEMD_UNIT", "_SYSTEMD_SLICE", "CODE_FILE", "TAG", "PRIORITY", "__REALTIME_TIMESTAMP"},
+			[]string{"MESSAGE", "BIG", "__CURSOR", "_TRANSPORT"}},
+		{"allow list", SourceConfig{Fields: []string{"_SYSTEMD_*", "_COMM"}},
+			[]string{"_COMM", "_SYSTEMD_UNIT", "_SYSTEMD_SLICE"},
+			[]string{"_EXE", "CODE_FILE", "TAG"}},
+		{"deny list", SourceConfig{Fields: []string{"_*"}, ExcludeFields: []string{"_SYSTEMD_SLICE"}},
+			[]string{"_COMM", "_EXE", "_SYSTEMD_UNIT"},
+			[]string{"_SYSTEMD_SLICE", "CODE_FILE"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewJournalIngestor(tt.cfg)
+			entry, err := j.parseJournalEntry(line)
+			if err != nil {
+				t.Fatalf("parseJournalEntry() error: %v", err)
+			}
+			if entry.Message != "hi\x1b[0m" {
+				t.Errorf("Message = %q, want the decoded bytes", entry.Message)
+			}
+			if entry.Unit != "ssh.service" || entry.Level != LevelWarning {
+				t.Errorf("Unit = %q, Level = %v", entry.Unit, entry.Level)
+			}
+			if entry.Metadata["cursor"] != "c1" || entry.Metadata["transport"] != "syslog" {
+				t.Errorf("cursor = %q, transport = %q", entry.Metadata["cursor"], entry.Metadata["transport"])
+			}
+			for _, key := range tt.want {
+				if _, ok := entry.Metadata[key]; !ok {
+					t.Errorf("Metadata missing %s", key)
+				}
+			}
+			for _, key := range tt.notWant {
+				if _, ok := entry.Metadata[key]; ok {
+					t.Errorf("Metadata has %s", key)
+				}
+			}
+			if tag, ok := entry.Metadata["TAG"]; ok && tag != "a\nb\xff" {
+				t.Errorf("TAG = %q, want both values", tag)
+			}
+		})
+	}
+
+	if _, err := NewJournalIngestor(SourceConfig{}).parseJournalEntry(`{"MESSAGE":[1,256]}`); err == nil {
+		t.Error("parseJournalEntry() with a byte over 255 should fail")
+	}
+	if err := validateJournal(SourceConfig{Fields: []string{"[_SYSTEMD"}}); err == nil {
+		t.Error("validateJournal() with a bad field pattern should fail")
+	}
+}
+
+// TestJournalSourceArgs tests selecting copied journals and namespaces.
+func TestJournalSourceArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  SourceConfig
+		want string
+	}{
+		{"system journal", SourceConfig{}, ""},
+		{"directory", SourceConfig{Path: "/srv/postmortem/journal"}, "--directory=/srv/postmortem/journal"},
+		{"file", SourceConfig{Path: "/tmp/system@1.journal"}, "--file=/tmp/system@1.journal"},
+		{"corrupt file", SourceConfig{Path: "/tmp/system@1.journal~"}, "--file=/tmp/system@1.journal~"},
+		{"namespace", SourceConfig{Namespace: "audit"}, "--namespace=audit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewJournalIngestor(tt.cfg)
+			if got := strings.Join(j.sourceArgs(), " "); got != tt.want {
+				t.Errorf("sourceArgs() = %q, want %q", got, tt.want)
+			}
+			if tt.want != "" && !strings.Contains(strings.Join(j.args(true), " "), tt.want) {
+				t.Errorf("args() = %q, want %q in it", j.args(true), tt.want)
+			}
+		})
+	}
+}
+
+// TestParseBootList tests decoding journalctl --list-boots --output=json.
+func TestParseBootList(t *testing.T) {
+	out := `[{"index":-1,"boot_id":"aabb0102030405060708090a0b0c0d0e","first_entry":1792154492219709,"last_entry":1792154492219764},` +
+		`{"index":0,"boot_id":"525d261e6f794711881ab53b8f3bd1b4","first_entry":1792154492716980,"last_entry":1792154494238478}]`
+
+	boots, err := parseBootList([]byte(out))
+	if err != nil {
+		t.Fatalf("parseBootList() error: %v", err)
+	}
+	if len(boots) != 2 || boots[0].Index != -1 || boots[1].ID != "525d261e6f794711881ab53b8f3bd1b4" {
+		t.Fatalf("parseBootList() = %+v", boots)
+	}
+	if got := boots[1].Last.UnixMicro(); got != 1792154494238478 {
+		t.Errorf("Last = %d, want 1792154494238478", got)
+	}
+
+	if _, err := parseBootList([]byte("Failed to open")); err == nil {
+		t.Error("parseBootList() of non-JSON should fail")
+	}
+}
@@ -0,0 +1,481 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// DefaultKmsgPath is the kernel's log buffer device.
+const DefaultKmsgPath = "/dev/kmsg"
+
+// kmsgBufferSize fits the largest record /dev/kmsg hands out; a read
+// into a smaller buffer fails with EINVAL.
+const kmsgBufferSize = 16 * 1024
+
+// kmsgPollInterval is how often a plain file standing in for /dev/kmsg
+// is checked for new records; the device itself wakes the reader.
+var kmsgPollInterval = 250 * time.Millisecond
+
+// kmsgBootTime returns the wall-clock time the kernel's clock started,
+// which record timestamps count from. Tests replace it.
+var kmsgBootTime = func() (time.Time, error) {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read monotonic clock: %w", err)
+	}
+	return time.Now().Add(-time.Duration(ts.Nano())), nil
+}
+
+// facilityNames are the syslog facilities by number.
+var facilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// facilityName returns the name of a syslog facility number.
+func facilityName(facility int) string {
+	if facility >= 0 && facility < len(facilityNames) {
+		return facilityNames[facility]
+	}
+	return strconv.Itoa(facility)
+}
+
+// KmsgIngestor reads the kernel log buffer from /dev/kmsg, so kernel
+// messages arrive even where journald isn't running.
+//
+// Each record carries a sequence number; a gap means the kernel
+// overwrote records before they were read, and is reported as an entry
+// of its own rather than passing silently.
+type KmsgIngestor struct {
+	config   SourceConfig
+	path     string
+	bootTime time.Time // Wall-clock time of the kernel clock's zero
+	lastSeq  uint64    // Sequence number of the last record read
+	seen     bool      // lastSeq is set
+	levels   *levelRules
+	mu       sync.Mutex
+	healthy  bool
+	cancel   context.CancelFunc
+}
+
+func init() {
+	Register(SourceKmsg, "kmsg", func(config SourceConfig) (Ingestor, error) {
+		return NewKmsgIngestor(config), nil
+	}, validateKmsg)
+}
+
+// validateKmsg checks a kmsg source's backfill and level settings.
+func validateKmsg(config SourceConfig) error {
+	if err := validateBackfill(config); err != nil {
+		return err
+	}
+	return validateLevels(config)
+}
+
+// NewKmsgIngestor creates a kernel log ingestor. The path defaults to
+// /dev/kmsg; a plain file of records can stand in for it in tests.
+func NewKmsgIngestor(config SourceConfig) *KmsgIngestor {
+	path := config.Path
+	if path == "" {
+		path = DefaultKmsgPath
+	}
+	return &KmsgIngestor{config: config, path: path, levels: sourceLevelRules(config)}
+}
+
+// Name returns the human-readable name of this source.
+func (k *KmsgIngestor) Name() string {
+	return k.config.Name
+}
+
+// Healthy returns true if the ingestor is functioning normally.
+func (k *KmsgIngestor) Healthy() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.healthy
+}
+
+func (k *KmsgIngestor) setHealthy(healthy bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.healthy = healthy
+}
+
+// Start opens the kernel log and sends new records. With backfill
+// options, the records already in the buffer are sent first.
+func (k *KmsgIngestor) Start(ctx context.Context, entries chan<- LogEntry) error {
+	ctx, k.cancel = context.WithCancel(ctx)
+
+	r, err := k.open()
+	if err != nil {
+		return err
+	}
+
+	// History is what the buffer holds now; anything logged from here
+	// on is live
+	var history []LogEntry
+	if wantsBackfill(k.config) {
+		if history, err = k.readBuffer(r); err != nil {
+			r.file.Close()
+			return err
+		}
+		history = trimHistory(k.config, history)
+	} else if _, err := r.file.Seek(0, io.SeekEnd); err != nil {
+		// The device seeks past the last record; only new ones are read
+		r.file.Close()
+		return fmt.Errorf("failed to seek %s: %w", k.path, err)
+	}
+
+	k.setHealthy(true)
+
+	poll := kmsgPollInterval
+
+	// Closing the file wakes a read waiting for the next record
+	go func() {
+		<-ctx.Done()
+		r.file.Close()
+	}()
+
+	go func() {
+		defer k.setHealthy(false)
+
+		if !sendAll(ctx, entries, history) {
+			return
+		}
+
+		for {
+			records, err := r.read(true)
+			switch {
+			case errors.Is(err, io.EOF):
+				// A plain file: wait for more to be appended
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(poll):
+				}
+				continue
+			case err != nil:
+				return
+			}
+			for _, entry := range k.toEntries(records) {
+				if !sendEntry(ctx, entries, entry) {
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// open opens the kernel log and notes when the kernel clock started.
+func (k *KmsgIngestor) open() (*kmsgReader, error) {
+	bootTime, err := kmsgBootTime()
+	if err != nil {
+		return nil, err
+	}
+	k.bootTime = bootTime
+
+	// Non-blocking, so reading the buffer can stop once it is exhausted
+	file, err := os.OpenFile(k.path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open kernel log: %w", err)
+	}
+	return newKmsgReader(file)
+}
+
+// readBuffer reads every record already in the buffer, as historical
+// entries, and stops once there are no more.
+func (k *KmsgIngestor) readBuffer(r *kmsgReader) ([]LogEntry, error) {
+	var history []LogEntry
+	for {
+		records, err := r.read(false)
+		if errors.Is(err, io.EOF) {
+			return history, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range k.toEntries(records) {
+			entry.Historical = true
+			history = append(history, entry)
+		}
+	}
+}
+
+// ReadHistory sends the records in the kernel's buffer without waiting
+// for new ones. Backfill options narrow what is sent.
+func (k *KmsgIngestor) ReadHistory(ctx context.Context, entries chan<- LogEntry) error {
+	r, err := k.open()
+	if err != nil {
+		return err
+	}
+	defer r.file.Close()
+
+	history, err := k.readBuffer(r)
+	if err != nil {
+		return err
+	}
+	if wantsBackfill(k.config) {
+		history = trimHistory(k.config, history)
+	}
+	sendAll(ctx, entries, history)
+	return ctx.Err()
+}
+
+// trimHistory applies the backfill options to entries read oldest
+// first: only those newer than backfill_since, and at most the last
+// backfill_lines (MaxBackfillLines for since alone).
+func trimHistory(config SourceConfig, history []LogEntry) []LogEntry {
+	if config.BackfillSince != "" {
+		// Validated already
+		if since, err := ParseSince(config.BackfillSince, time.Now()); err == nil {
+			for len(history) > 0 && history[0].Timestamp.Before(since) {
+				history = history[1:]
+			}
+		}
+	}
+	max := config.BackfillLines
+	if max <= 0 {
+		max = MaxBackfillLines
+	}
+	if len(history) > max {
+		history = history[len(history)-max:]
+	}
+	return history
+}
+
+// toEntries converts raw records into entries, reporting sequence gaps
+// before the record that follows them. Records that don't parse are
+// skipped.
+func (k *KmsgIngestor) toEntries(records []string) []LogEntry {
+	var result []LogEntry
+	for _, raw := range records {
+		rec, err := parseKmsgRecord(raw)
+		if err != nil {
+			continue
+		}
+		timestamp := k.bootTime.Add(time.Duration(rec.usec) * time.Microsecond)
+
+		if k.seen && rec.seq > k.lastSeq+1 {
+			dropped := rec.seq - k.lastSeq - 1
+			result = append(result, LogEntry{
+				Timestamp:    timestamp,
+				Source:       "kernel",
+				IngestorName: k.config.Name,
+				SourceType:   SourceKmsg,
+				Level:        LevelWarning,
+				Message:      fmt.Sprintf("%d kernel messages dropped (overwritten before they were read)", dropped),
+				Metadata:     map[string]string{"dropped": strconv.FormatUint(dropped, 10)},
+			})
+		}
+		k.lastSeq, k.seen = rec.seq, true
+
+		result = append(result, k.toLogEntry(rec, timestamp, raw))
+	}
+	return result
+}
+
+// syslogTagRegex matches the "ident[pid]: " prefix syslog senders put on
+// messages, as userspace programs (systemd, for one) do on /dev/kmsg.
+var syslogTagRegex = regexp.MustCompile(`^([^\s:\[]+)(?:\[(\d+)\])?: (.*)$`)
+
+// toLogEntry converts a parsed record into a LogEntry.
+func (k *KmsgIngestor) toLogEntry(rec kmsgRecord, timestamp time.Time, raw string) LogEntry {
+	entry := LogEntry{
+		Timestamp:    timestamp,
+		Source:       "kernel",
+		IngestorName: k.config.Name,
+		SourceType:   SourceKmsg,
+		Level:        k.levels.names.priority(rec.priority),
+		Message:      rec.message,
+		Raw:          strings.TrimRight(raw, "\n"),
+		Metadata: map[string]string{
+			"seq":      strconv.FormatUint(rec.seq, 10),
+			"facility": facilityName(rec.facility),
+		},
+	}
+	if rec.facility != 0 {
+		if m := syslogTagRegex.FindStringSubmatch(rec.message); m != nil {
+			entry.Source, entry.PID, entry.Message = m[1], parseInt(m[2]), m[3]
+		}
+	}
+	for key, value := range rec.fields {
+		entry.Metadata[key] = value
+	}
+	k.levels.apply(&entry)
+	return entry
+}
+
+// Stop gracefully shuts down the ingestor.
+func (k *KmsgIngestor) Stop() error {
+	if k.cancel != nil {
+		k.cancel()
+	}
+	return nil
+}
+
+// kmsgRecord is one record of the kernel log buffer.
+type kmsgRecord struct {
+	priority int    // Syslog level, 0-7
+	facility int    // Syslog facility; 0 for the kernel itself
+	seq      uint64 // Sequence number, one per record
+	usec     int64  // Microseconds since boot
+	flags    string // "-", or "c"/"+" for continued messages
+	message  string
+
+	// fields are the KEY=value continuation lines, such as SUBSYSTEM
+	// and DEVICE for messages about a device
+	fields map[string]string
+}
+
+// parseKmsgRecord parses one record:
+//
+//	prio,seq,usec,flags[,...];message
+//	 KEY=value
+//
+// Bytes the kernel can't print as they are are escaped as \xNN.
+func parseKmsgRecord(raw string) (kmsgRecord, error) {
+	var rec kmsgRecord
+
+	lines := strings.Split(strings.TrimRight(raw, "\n"), "\n")
+	header, message, ok := strings.Cut(lines[0], ";")
+	if !ok {
+		return rec, fmt.Errorf("kmsg record without a header: %q", lines[0])
+	}
+	parts := strings.Split(header, ",")
+	if len(parts) < 4 {
+		return rec, fmt.Errorf("kmsg header %q has %d fields, want at least 4", header, len(parts))
+	}
+
+	prio, err := strconv.Atoi(parts[0])
+	if err != nil || prio < 0 {
+		return rec, fmt.Errorf("invalid kmsg priority %q", parts[0])
+	}
+	if rec.seq, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+		return rec, fmt.Errorf("invalid kmsg sequence number %q", parts[1])
+	}
+	if rec.usec, err = strconv.ParseInt(parts[2], 10, 64); err != nil {
+		return rec, fmt.Errorf("invalid kmsg timestamp %q", parts[2])
+	}
+	rec.priority, rec.facility = prio&7, prio>>3
+	rec.flags = parts[3]
+	rec.message = unescapeKmsg(message)
+
+	for _, line := range lines[1:] {
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, " "), "=")
+		if !ok || key == "" {
+			continue
+		}
+		if rec.fields == nil {
+			rec.fields = make(map[string]string)
+		}
+		rec.fields[key] = unescapeKmsg(value)
+	}
+	return rec, nil
+}
+
+// unescapeKmsg decodes the kernel's \xNN escapes.
+func unescapeKmsg(s string) string {
+	if !strings.Contains(s, `\x`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && s[i+1] == 'x' {
+			if v, err := strconv.ParseUint(s[i+2:i+4], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// kmsgReader splits what the kernel log hands out into records.
+// /dev/kmsg returns exactly one record per read; a plain file returns
+// many, and a record is complete once its last line is.
+type kmsgReader struct {
+	file    *os.File
+	conn    syscall.RawConn
+	buf     []byte
+	partial []byte // Start of a line not yet terminated
+}
+
+// newKmsgReader wraps an open kernel log.
+func newKmsgReader(file *os.File) (*kmsgReader, error) {
+	conn, err := file.SyscallConn()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to access kernel log: %w", err)
+	}
+	return &kmsgReader{file: file, conn: conn, buf: make([]byte, kmsgBufferSize)}, nil
+}
+
+// read returns the next records. It returns io.EOF when none are
+// available: at the end of a plain file, or, unless wait is set, once
+// the kernel's buffer has been read. With wait the device is waited on.
+func (r *kmsgReader) read(wait bool) ([]string, error) {
+	var (
+		n       int
+		readErr error
+	)
+	err := r.conn.Read(func(fd uintptr) bool {
+		n, readErr = syscall.Read(int(fd), r.buf)
+		// Returning false waits until the descriptor is readable
+		return !(wait && readErr == syscall.EAGAIN)
+	})
+	if err == nil {
+		err = readErr
+	}
+	switch {
+	case errors.Is(err, syscall.EAGAIN):
+		return nil, io.EOF
+	case errors.Is(err, syscall.EPIPE):
+		// Records were overwritten since the last read; reading goes
+		// on from the oldest left, and the sequence gap reports them
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read kernel log: %w", err)
+	case n == 0:
+		return nil, io.EOF
+	}
+
+	data := append(r.partial, r.buf[:n]...)
+	end := bytes.LastIndexByte(data, '\n')
+	r.partial = append([]byte(nil), data[end+1:]...)
+
+	// A line starting with a space continues the record before it
+	var records []string
+	for _, line := range strings.SplitAfter(string(data[:end+1]), "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, " ") && len(records) > 0:
+			records[len(records)-1] += line
+		default:
+			records = append(records, line)
+		}
+	}
+	return records, nil
+}
+
+// Ensure KmsgIngestor implements Ingestor and HistoryReader
+var (
+	_ Ingestor      = (*KmsgIngestor)(nil)
+	_ HistoryReader = (*KmsgIngestor)(nil)
+)
@@ -0,0 +1,194 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// kmsgRecords is a kernel log as /dev/kmsg reads it, with records 4
+// and 5 overwritten before they were read.
+const kmsgRecords = `6,1,1000000,-;Linux version 6.8.0
+4,2,2500000,-;usb 1-1: device descriptor read/64, error -71
+ SUBSYSTEM=usb
+ DEVICE=c189:0
+3,3,3000000,-,caller=T1;EXT4-fs error: bad\x20block\x5c
+30,6,4000000,-;systemd[1]: Started Journal Service.
+`
+
+// useKmsgBootTime pins the kernel clock's start for the test.
+func useKmsgBootTime(t *testing.T, boot time.Time) {
+	t.Helper()
+	old := kmsgBootTime
+	kmsgBootTime = func() (time.Time, error) { return boot, nil }
+	t.Cleanup(func() { kmsgBootTime = old })
+}
+
+// TestParseKmsgRecord tests the record header, escapes and continuation lines.
+func TestParseKmsgRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    kmsgRecord
+		wantErr bool
+	}{
+		{
+			name: "plain",
+			raw:  "6,1,1000000,-;Linux version 6.8.0\n",
+			want: kmsgRecord{priority: 6, seq: 1, usec: 1000000, flags: "-", message: "Linux version 6.8.0"},
+		},
+		{
+			name: "facility and extra header fields",
+			raw:  "30,6,4000000,-,caller=T1;systemd[1]: Started\n",
+			want: kmsgRecord{priority: 6, facility: 3, seq: 6, usec: 4000000, flags: "-", message: "systemd[1]: Started"},
+		},
+		{
+			name: "escapes",
+			raw:  `3,3,3000000,-;bad\x20block\x5c\xzz` + "\n",
+			want: kmsgRecord{priority: 3, seq: 3, usec: 3000000, flags: "-", message: `bad block\\xzz`},
+		},
+		{
+			name: "continuation lines",
+			raw:  "4,2,2500000,-;usb 1-1: error\n SUBSYSTEM=usb\n DEVICE=c189:0\n",
+			want: kmsgRecord{priority: 4, seq: 2, usec: 2500000, flags: "-", message: "usb 1-1: error",
+				fields: map[string]string{"SUBSYSTEM": "usb", "DEVICE": "c189:0"}},
+		},
+		{name: "no header", raw: "just text\n", wantErr: true},
+		{name: "short header", raw: "6,1;text\n", wantErr: true},
+		{name: "bad sequence", raw: "6,x,1,-;text\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseKmsgRecord(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseKmsgRecord() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.priority != tt.want.priority || got.facility != tt.want.facility || got.seq != tt.want.seq ||
+				got.usec != tt.want.usec || got.flags != tt.want.flags || got.message != tt.want.message {
+				t.Errorf("parseKmsgRecord() = %+v, want %+v", got, tt.want)
+			}
+			for key, value := range tt.want.fields {
+				if got.fields[key] != value {
+					t.Errorf("field %s = %q, want %q", key, got.fields[key], value)
+				}
+			}
+		})
+	}
+}
+
+// TestKmsgHistory tests reading the buffer: wall-clock timestamps, the
+// dropped-message report and userspace identifiers.
+func TestKmsgHistory(t *testing.T) {
+	boot := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	useKmsgBootTime(t, boot)
+	path := filepath.Join(t.TempDir(), "kmsg")
+	if err := os.WriteFile(path, []byte(kmsgRecords), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	k := NewKmsgIngestor(SourceConfig{Name: "Kernel", Type: SourceKmsg, Path: path})
+	entries := make(chan LogEntry, 10)
+	if err := k.ReadHistory(context.Background(), entries); err != nil {
+		t.Fatalf("ReadHistory() error: %v", err)
+	}
+	close(entries)
+	var got []LogEntry
+	for e := range entries {
+		got = append(got, e)
+	}
+
+	want := []struct {
+		source  string
+		level   LogLevel
+		message string
+	}{
+		{"kernel", LevelInfo, "Linux version 6.8.0"},
+		{"kernel", LevelWarning, "usb 1-1: device descriptor read/64, error -71"},
+		{"kernel", LevelError, `EXT4-fs error: bad block\`},
+		{"kernel", LevelWarning, "2 kernel messages dropped (overwritten before they were read)"},
+		{"systemd", LevelInfo, "Started Journal Service."},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d: %v", len(got), len(want), messages(got))
+	}
+	for i, w := range want {
+		if got[i].Source != w.source || got[i].Level != w.level || got[i].Message != w.message || !got[i].Historical {
+			t.Errorf("entry %d = %s/%v/%q (historical %v), want %s/%v/%q",
+				i, got[i].Source, got[i].Level, got[i].Message, got[i].Historical, w.source, w.level, w.message)
+		}
+	}
+
+	if ts := got[1].Timestamp; !ts.Equal(boot.Add(2500 * time.Millisecond)) {
+		t.Errorf("timestamp = %v, want boot + 2.5s", ts)
+	}
+	if got[1].Metadata["SUBSYSTEM"] != "usb" || got[1].Metadata["DEVICE"] != "c189:0" {
+		t.Errorf("metadata = %v, want SUBSYSTEM and DEVICE", got[1].Metadata)
+	}
+	if got[3].Metadata["dropped"] != "2" {
+		t.Errorf("dropped metadata = %v, want 2", got[3].Metadata)
+	}
+	if got[4].PID != 1 || got[4].Metadata["facility"] != "daemon" {
+		t.Errorf("systemd entry PID = %d, facility = %q; want 1, daemon", got[4].PID, got[4].Metadata["facility"])
+	}
+}
+
+// TestKmsgFollow tests that only records logged after Start are sent,
+// unless backfill asks for some of the buffer.
+func TestKmsgFollow(t *testing.T) {
+	useKmsgBootTime(t, time.Now().Add(-time.Hour))
+	old := kmsgPollInterval
+	kmsgPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { kmsgPollInterval = old })
+
+	tests := []struct {
+		name string
+		cfg  SourceConfig
+		want []string
+	}{
+		{"new only", SourceConfig{}, []string{"fresh"}},
+		{"backfill", SourceConfig{BackfillLines: 1}, []string{"Started Journal Service.", "fresh"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "kmsg")
+			if err := os.WriteFile(path, []byte(kmsgRecords), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.cfg.Name, tt.cfg.Type, tt.cfg.Path = "Kernel", SourceKmsg, path
+			k := NewKmsgIngestor(tt.cfg)
+			entries := make(chan LogEntry, 10)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := k.Start(ctx, entries); err != nil {
+				t.Fatalf("Start() error: %v", err)
+			}
+			defer k.Stop()
+
+			appendLine(t, path, "6,7,5000000,-;fresh")
+
+			var got []string
+			for len(got) < len(tt.want) {
+				select {
+				case e := <-entries:
+					got = append(got, e.Message)
+				case <-time.After(2 * time.Second):
+					t.Fatalf("got %q, want %q", got, tt.want)
+				}
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("messages = %q, want %q", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
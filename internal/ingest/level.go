@@ -0,0 +1,245 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// An entry's level comes from the first of:
+//
+//  1. the source's level rules: a pattern matching the entry, or an
+//     override for the whole source
+//  2. a level field the parser read ("level=warn", a syslog priority),
+//     looked up in the source's own names before the built-in ones
+//  3. a level named in the first words of the message
+
+// LevelConfig adjusts how a source's entries get their level.
+type LevelConfig struct {
+	// Names maps the level names the source writes onto levels, ahead of
+	// the built-in names: {"W": "warning", "fatal": "critical", "30": "info"}
+	Names map[string]string `yaml:"names,omitempty" json:"names,omitempty"`
+
+	// Override gives every entry of the source this level
+	Override string `yaml:"override,omitempty" json:"override,omitempty"`
+
+	// Rules give entries matching a pattern a level; the first match
+	// wins, over Override too
+	Rules []LevelRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// LevelRule gives the entries whose text matches Match the level Level.
+type LevelRule struct {
+	Match string `yaml:"match" json:"match"`
+	Level string `yaml:"level" json:"level"`
+}
+
+// LevelNames maps level names a source uses onto levels. Keys are lower
+// case; lookups ignore case. A nil LevelNames knows only the built-in
+// names.
+type LevelNames map[string]LogLevel
+
+// Level reads a level field: one of the source's names, or a built-in one.
+func (n LevelNames) Level(value string) LogLevel {
+	if level, ok := n.mapped(value); ok {
+		return level
+	}
+	return levelFromName(value)
+}
+
+// mapped looks value up in the source's names only.
+func (n LevelNames) mapped(value string) (LogLevel, bool) {
+	level, ok := n[strings.ToLower(strings.TrimSpace(value))]
+	return level, ok
+}
+
+// priority returns the level of a syslog priority, looked up in the
+// source's names first.
+func (n LevelNames) priority(priority int) LogLevel {
+	if level, ok := n.mapped(strconv.Itoa(priority)); ok {
+		return level
+	}
+	return priorityToLevel(priority)
+}
+
+// extraLevelNames are level names recognised beyond ParseLevel's: the
+// fatal levels of application loggers and log15's four-letter names
+// (Grafana).
+var extraLevelNames = map[string]LogLevel{
+	"fatal":  LevelCritical,
+	"panic":  LevelCritical,
+	"dpanic": LevelCritical,
+	"eror":   LevelError,
+	"dbug":   LevelDebug,
+	"trce":   LevelDebug,
+}
+
+// namedLevel returns the level a name stands for, or LevelUnknown.
+func namedLevel(name string) LogLevel {
+	if level, err := ParseLevel(name); err == nil {
+		return level
+	}
+	return extraLevelNames[strings.ToLower(name)]
+}
+
+// levelFromName reads a level field: a level name or, failing that, one
+// marked in it ("WARNING:", "[error]").
+func levelFromName(value string) LogLevel {
+	if level := namedLevel(value); level != LevelUnknown {
+		return level
+	}
+	return detectLevel(value, nil)
+}
+
+// levelPosition is how many words into a message a level may stand:
+// enough for "[main] ERROR" and Ruby's "I, [2026-10-16T12:00:00 #42]  INFO",
+// few enough that the message's own words don't count.
+const levelPosition = 4
+
+// levelPunct is trimmed from a word before it is read as a level.
+const levelPunct = "[](){}<>;,|-*\"'."
+
+// detectLevel finds a level named in the first words of a message. A
+// word names one as a whole, once brackets and punctuation are trimmed,
+// or in a colon-separated part ("ERROR:root:msg" from Python's logging,
+// "[core:error]" from Apache), so "stderr", "ERRNO" and "error_count=0"
+// don't. The first word may be in any case; later ones count when they
+// are capitals, bracketed or end in a colon, so "no error found" isn't an
+// error. Numbers only name a level the source maps them to.
+func detectLevel(text string, names LevelNames) LogLevel {
+	words := strings.Fields(text)
+	if len(words) > levelPosition {
+		words = words[:levelPosition]
+	}
+
+	for i, word := range words {
+		marked := i == 0 || strings.HasSuffix(word, ":") || strings.IndexAny(word, "[<(") == 0
+		for _, part := range strings.Split(word, ":") {
+			part = strings.Trim(part, levelPunct)
+			if part == "" || (!marked && strings.ToUpper(part) != part) {
+				continue
+			}
+			if level, ok := names.mapped(part); ok {
+				return level
+			}
+			if isDigits(part) {
+				continue
+			}
+			if level := namedLevel(part); level != LevelUnknown {
+				return level
+			}
+		}
+	}
+	return LevelUnknown
+}
+
+// isDigits reports whether s is all ASCII digits.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// levelRules are a source's compiled level settings.
+type levelRules struct {
+	names    LevelNames
+	override LogLevel
+	rules    []levelRule
+}
+
+// levelRule is a compiled LevelRule.
+type levelRule struct {
+	re    *regexp.Regexp
+	level LogLevel
+}
+
+// newLevelRules compiles a source's level settings; nil settings change
+// nothing.
+func newLevelRules(config *LevelConfig) (*levelRules, error) {
+	r := &levelRules{}
+	if config == nil {
+		return r, nil
+	}
+
+	level := func(what, name string) (LogLevel, error) {
+		if level := namedLevel(name); level != LevelUnknown {
+			return level, nil
+		}
+		return LevelUnknown, fmt.Errorf("%s: unknown level %q (use debug, info, notice, warning, error, critical, alert or emergency)", what, name)
+	}
+
+	if len(config.Names) > 0 {
+		r.names = make(LevelNames, len(config.Names))
+		for name, value := range config.Names {
+			if strings.TrimSpace(name) == "" {
+				return nil, fmt.Errorf("names: empty name")
+			}
+			l, err := level(fmt.Sprintf("names[%s]", name), value)
+			if err != nil {
+				return nil, err
+			}
+			r.names[strings.ToLower(strings.TrimSpace(name))] = l
+		}
+	}
+
+	if config.Override != "" {
+		l, err := level("override", config.Override)
+		if err != nil {
+			return nil, err
+		}
+		r.override = l
+	}
+
+	for i, rule := range config.Rules {
+		if rule.Match == "" {
+			return nil, fmt.Errorf("rules[%d]: match is required", i)
+		}
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: invalid match: %w", i, err)
+		}
+		l, err := level(fmt.Sprintf("rules[%d]", i), rule.Level)
+		if err != nil {
+			return nil, err
+		}
+		r.rules = append(r.rules, levelRule{re: re, level: l})
+	}
+	return r, nil
+}
+
+// sourceLevelRules returns the level rules of a source that has passed
+// validation; settings that don't compile, already reported there,
+// change nothing.
+func sourceLevelRules(config SourceConfig) *levelRules {
+	if r, err := newLevelRules(config.Levels); err == nil {
+		return r
+	}
+	return &levelRules{}
+}
+
+// validateLevels checks a source's level settings.
+func validateLevels(config SourceConfig) error {
+	if _, err := newLevelRules(config.Levels); err != nil {
+		return fmt.Errorf("levels: %w", err)
+	}
+	return nil
+}
+
+// apply gives an entry the level of the first rule matching its text,
+// or else the source's override, if either is set.
+func (r *levelRules) apply(entry *LogEntry) {
+	for _, rule := range r.rules {
+		if rule.re.MatchString(entry.Raw) {
+			entry.Level = rule.level
+			return
+		}
+	}
+	if r.override != LevelUnknown {
+		entry.Level = r.override
+	}
+}
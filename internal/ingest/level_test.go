@@ -0,0 +1,164 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDetectLevel tests finding a level in the first words of a message.
+func TestDetectLevel(t *testing.T) {
+	names := LevelNames{"w": LevelWarning, "30": LevelInfo}
+
+	tests := []struct {
+		text  string
+		names LevelNames
+		want  LogLevel
+	}{
+		{"ERROR db: connection lost", nil, LevelError},
+		{"error loading config", nil, LevelError},
+		{"ERROR:root:job failed", nil, LevelError},
+		{"[core:error] [pid 42] AH00124: request exceeded", nil, LevelError},
+		{"[main] WARN c.e.App - slow query", nil, LevelWarning},
+		{"I, [2026-10-16T12:00:00.000 #42]  INFO -- : started", nil, LevelInfo},
+		{"worker 3: warning: queue is full", nil, LevelWarning},
+		{"panic: runtime error", nil, LevelCritical},
+		{"stderr redirected to /dev/null", nil, LevelUnknown},
+		{"ERRNO 13 permission denied", nil, LevelUnknown},
+		{"job done error_count=0", nil, LevelUnknown},
+		{"information about the release", nil, LevelUnknown},
+		{"finished with no error found", nil, LevelUnknown},
+		{"request took 3 ms", nil, LevelUnknown},
+		{"sent to ERROR queue at the end of the run", nil, LevelError},
+		{"one two three four ERROR", nil, LevelUnknown},
+		{"W disk nearly full", names, LevelWarning},
+		{"30 listening on :80", names, LevelInfo},
+		{"30 listening on :80", nil, LevelUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := detectLevel(tt.text, tt.names); got != tt.want {
+			t.Errorf("detectLevel(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+// TestLevelConfig tests a source's level names, rules and override, and
+// their validation.
+func TestLevelConfig(t *testing.T) {
+	levels := &LevelConfig{
+		Names: map[string]string{"W": "warning", "Fatal": "alert", "50": "error"},
+		Rules: []LevelRule{{Match: `healthcheck`, Level: "debug"}},
+	}
+
+	tests := []struct {
+		name   string
+		parser ParserConfig
+		levels *LevelConfig
+		line   string
+		want   LogLevel
+	}{
+		{"mapped word", ParserConfig{Type: "raw"}, levels, "W disk nearly full", LevelWarning},
+		{"mapped over built-in", ParserConfig{Type: "raw"}, levels, "FATAL: out of memory", LevelAlert},
+		{"hostname isn't a level", ParserConfig{Type: "syslog"}, nil, "Oct 16 12:00:00 error-host app: started", LevelUnknown},
+		{"path isn't a level", ParserConfig{Type: "raw"}, nil, "GET /api/errors 200", LevelUnknown},
+		{"logfmt field", ParserConfig{Type: "logfmt"}, levels, `level=W msg="disk nearly full"`, LevelWarning},
+		{"json number", ParserConfig{Type: "json"}, levels, `{"level":50,"msg":"boom"}`, LevelError},
+		{"json number unmapped", ParserConfig{Type: "json"}, nil, `{"level":50,"msg":"boom"}`, LevelError},
+		{"rule wins", ParserConfig{Type: "logfmt"}, levels, `level=error msg="healthcheck failed"`, LevelDebug},
+		{"override", ParserConfig{Type: "raw"}, &LevelConfig{Override: "notice"}, "ERROR anything", LevelNotice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newLineParser(SourceConfig{Name: "app", Parsers: []ParserConfig{tt.parser}, Levels: tt.levels})
+			if err != nil {
+				t.Fatalf("newLineParser() error: %v", err)
+			}
+			if got := p.groupEntry("", []string{tt.line}).Level; got != tt.want {
+				t.Errorf("level = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []struct {
+		levels  LevelConfig
+		wantErr string
+	}{
+		{LevelConfig{Names: map[string]string{"W": "warnish"}}, `unknown level "warnish"`},
+		{LevelConfig{Override: "loud"}, "override"},
+		{LevelConfig{Rules: []LevelRule{{Match: `(`, Level: "debug"}}}, "rules[0]: invalid match"},
+		{LevelConfig{Rules: []LevelRule{{Level: "debug"}}}, "match is required"},
+	} {
+		err := validateParsers(SourceConfig{Levels: &bad.levels})
+		if err == nil || !strings.Contains(err.Error(), bad.wantErr) {
+			t.Errorf("validateParsers(%+v) error = %v, want one containing %q", bad.levels, err, bad.wantErr)
+		}
+		if err := validateSyslog(SourceConfig{Listen: "udp://:514", Levels: &bad.levels}); err == nil {
+			t.Errorf("validateSyslog(%+v) accepted bad levels", bad.levels)
+		}
+		if err := validateJournal(SourceConfig{Levels: &bad.levels}); err == nil {
+			t.Errorf("validateJournal(%+v) accepted bad levels", bad.levels)
+		}
+		if err := validateKmsg(SourceConfig{Levels: &bad.levels}); err == nil {
+			t.Errorf("validateKmsg(%+v) accepted bad levels", bad.levels)
+		}
+	}
+}
+
+// TestLevelConfigPriorities tests level settings on sources whose entries
+// carry a syslog priority: journald, kmsg, syslog and syslog files.
+func TestLevelConfigPriorities(t *testing.T) {
+	tests := []struct {
+		name   string
+		levels *LevelConfig
+		want   LogLevel
+	}{
+		{"priority", nil, LevelWarning},
+		{"mapped priority", &LevelConfig{Names: map[string]string{"4": "notice"}}, LevelNotice},
+		{"rule", &LevelConfig{Rules: []LevelRule{{Match: `link flapping`, Level: "debug"}}}, LevelDebug},
+		{"rule not matching", &LevelConfig{Rules: []LevelRule{{Match: `healthcheck`, Level: "debug"}}}, LevelWarning},
+		{"override", &LevelConfig{Override: "info"}, LevelInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := SourceConfig{Name: "src", Levels: tt.levels}
+
+			j := NewJournalIngestor(config)
+			entry, err := j.parseJournalEntry(`{"PRIORITY":"4","MESSAGE":"eth0: link flapping"}`)
+			if err != nil {
+				t.Fatalf("parseJournalEntry() error: %v", err)
+			}
+			if entry.Level != tt.want {
+				t.Errorf("journald level = %v, want %v", entry.Level, tt.want)
+			}
+
+			k := NewKmsgIngestor(config)
+			raw := "4,1,1000000,-;eth0: link flapping\n"
+			rec, err := parseKmsgRecord(raw)
+			if err != nil {
+				t.Fatalf("parseKmsgRecord() error: %v", err)
+			}
+			if got := k.toLogEntry(rec, time.Now(), raw).Level; got != tt.want {
+				t.Errorf("kmsg level = %v, want %v", got, tt.want)
+			}
+
+			config.Listen = "udp://127.0.0.1:514"
+			s := NewSyslogIngestor(config)
+			message := "<12>Oct 11 22:14:15 router kernel: eth0: link flapping"
+			if got := s.toLogEntry(message, time.Now()).Level; got != tt.want {
+				t.Errorf("syslog level = %v, want %v", got, tt.want)
+			}
+
+			config.Parsers = []ParserConfig{{Type: "syslog"}}
+			p, err := newLineParser(config)
+			if err != nil {
+				t.Fatalf("newLineParser() error: %v", err)
+			}
+			if got := p.groupEntry("", []string{message}).Level; got != tt.want {
+				t.Errorf("syslog file level = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MultilineConfig joins the lines of one logical entry, such as a stack
+// trace, into a single entry. A line continues the entry before it when
+// it matches Continuation (or the preset's), or when Start is set and it
+// doesn't match Start.
+type MultilineConfig struct {
+	// Preset is a continuation pattern for a language's tracebacks:
+	// "java", "python" or "go"
+	Preset string `yaml:"preset,omitempty" json:"preset,omitempty"`
+
+	// Start matches the first line of an entry
+	Start string `yaml:"start,omitempty" json:"start,omitempty"`
+
+	// Continuation matches the lines that belong to the entry before them
+	Continuation string `yaml:"continuation,omitempty" json:"continuation,omitempty"`
+
+	// MaxLines caps an entry's lines; 0 means DefaultMultilineMaxLines
+	MaxLines int `yaml:"max_lines,omitempty" json:"max_lines,omitempty"`
+
+	// Timeout is how long an entry may wait for more lines ("500ms");
+	// empty means DefaultMultilineTimeout
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+const (
+	// DefaultMultilineMaxLines is the line cap of an entry when a source
+	// sets none; a runaway continuation pattern can't swallow the log
+	DefaultMultilineMaxLines = 500
+
+	// DefaultMultilineTimeout is how long a live entry waits for another
+	// line before it is sent as it stands
+	DefaultMultilineTimeout = time.Second
+)
+
+// multilinePresets are the continuation patterns of common tracebacks.
+var multilinePresets = map[string]string{
+	// Indented "at ..." frames, "... 5 more", "Caused by:" and the
+	// exception line below a logged message:
+	//
+	//	java.lang.IllegalStateException: boom
+	//		at com.example.App.run(App.java:42)
+	//	Caused by: java.io.IOException: closed
+	//		... 3 more
+	"java": `^(?:\s|Caused by: |Suppressed: |[\w$]+(?:\.[\w$]+)+(?:Exception|Error|Throwable)\b)`,
+
+	// The traceback header, indented frames and source lines, chaining
+	// notes and the closing exception:
+	//
+	//	Traceback (most recent call last):
+	//	  File "app.py", line 3, in <module>
+	//	    main()
+	//	ValueError: bad input
+	"python": `^(?:\s|Traceback \(most recent call last\):|During handling of the above exception|` +
+		`The above exception was the direct cause|\w+(?:\.\w+)*(?:Error|Exception|Warning|Exit|Interrupt)(?::|$))`,
+
+	// Everything after "panic:" or "fatal error:": goroutine headers,
+	// function lines, indented file lines and the exit status:
+	//
+	//	panic: runtime error: index out of range [5] with length 3
+	//	goroutine 1 [running]:
+	//	main.main()
+	//		/src/main.go:8 +0x1d
+	"go": `^(?:\s|goroutine \d+ \[|created by |\[signal |exit status \d+$|\S+\(.*\)$)`,
+}
+
+// multilineRules are a source's compiled multiline settings.
+type multilineRules struct {
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+	maxLines     int
+	timeout      time.Duration
+}
+
+// newMultilineRules compiles a source's multiline settings; nil settings
+// give nil rules, and every line is an entry of its own.
+func newMultilineRules(config *MultilineConfig) (*multilineRules, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	continuation := config.Continuation
+	if config.Preset != "" {
+		preset, ok := multilinePresets[config.Preset]
+		if !ok {
+			return nil, fmt.Errorf("unknown preset %q (must be %s)", config.Preset, joinOr(multilinePresetNames()))
+		}
+		if continuation != "" {
+			return nil, errors.New("preset and continuation can't both be set")
+		}
+		continuation = preset
+	}
+	if continuation == "" && config.Start == "" {
+		return nil, errors.New("one of preset, start or continuation is required")
+	}
+
+	rules := &multilineRules{maxLines: config.MaxLines, timeout: DefaultMultilineTimeout}
+	for _, pattern := range []struct {
+		name, expr string
+		dest       **regexp.Regexp
+	}{
+		{"start", config.Start, &rules.start},
+		{"continuation", continuation, &rules.continuation},
+	} {
+		if pattern.expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern.expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern: %w", pattern.name, err)
+		}
+		*pattern.dest = re
+	}
+
+	switch {
+	case rules.maxLines < 0:
+		return nil, errors.New("max_lines must not be negative")
+	case rules.maxLines == 0:
+		rules.maxLines = DefaultMultilineMaxLines
+	}
+	if config.Timeout != "" {
+		timeout, err := time.ParseDuration(config.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q (use a duration like 500ms)", config.Timeout)
+		}
+		rules.timeout = timeout
+	}
+	return rules, nil
+}
+
+// multilinePresetNames returns the preset names in sorted order.
+func multilinePresetNames() []string {
+	names := make([]string, 0, len(multilinePresets))
+	for name := range multilinePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// continues reports whether line belongs to the entry before it.
+func (r *multilineRules) continues(line string) bool {
+	if r.continuation != nil && r.continuation.MatchString(line) {
+		return true
+	}
+	return r.start != nil && !r.start.MatchString(line)
+}
+
+// lineGroup gathers the lines of one entry at a time. Without rules
+// every line is a group of its own.
+type lineGroup struct {
+	rules *multilineRules
+	lines []string
+}
+
+// add takes the next line. When it starts a new entry, or the current
+// one is full, the finished group is returned.
+func (g *lineGroup) add(line string) []string {
+	if g.rules == nil {
+		return []string{line}
+	}
+	if len(g.lines) > 0 && len(g.lines) < g.rules.maxLines && g.rules.continues(line) {
+		g.lines = append(g.lines, line)
+		return nil
+	}
+	done := g.lines
+	g.lines = []string{line}
+	return done
+}
+
+// flush returns the group being gathered, if any, and starts afresh.
+func (g *lineGroup) flush() []string {
+	done := g.lines
+	g.lines = nil
+	return done
+}
+
+// groupEntry parses an assembled group read from path: the first line
+// is parsed as usual and becomes the message, and the whole text is kept
+// in Raw for the detail view and the source's level rules.
+func (p *lineParser) groupEntry(path string, lines []string) LogEntry {
+	entry := p.entry(path, lines[0])
+	if len(lines) > 1 {
+		entry.Raw = strings.Join(lines, "\n")
+	}
+	p.levels.apply(&entry)
+	return entry
+}
+
+// assembler turns the lines of a live stream into entries and sends
+// them. The lines of an entry are held until the next entry starts or,
+// since a stack trace may be the last thing written for a while, until
+// no line has come for the timeout. It is safe for the reading goroutine
+// and its flush timer to use at once.
+type assembler struct {
+	parser *lineParser
+	send   func(entry LogEntry) bool
+
+	mu         sync.Mutex
+	group      lineGroup
+	path       string    // Where the pending lines were read
+	historical bool      // The pending lines were written before the start
+	last       time.Time // When the last line arrived
+	timer      *time.Timer
+}
+
+// newAssembler builds an assembler sending entries with send.
+func (p *lineParser) newAssembler(send func(entry LogEntry) bool) *assembler {
+	return &assembler{parser: p, send: send, group: lineGroup{rules: p.multiline}}
+}
+
+// add takes a line read from path. It returns false once send does.
+func (a *assembler) add(path, line string, historical bool) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.group.rules == nil {
+		a.path, a.historical = path, historical
+		return a.sendLocked([]string{line})
+	}
+
+	// Lines of another file never continue this one's entry
+	if path != a.path && !a.flushLocked() {
+		return false
+	}
+	if done := a.group.add(line); done != nil && !a.sendLocked(done) {
+		return false
+	}
+	if len(a.group.lines) == 1 {
+		a.path, a.historical = path, historical
+	}
+
+	a.last = time.Now()
+	if a.timer == nil {
+		a.timer = time.AfterFunc(a.group.rules.timeout, a.idle)
+	} else {
+		a.timer.Reset(a.group.rules.timeout)
+	}
+	return true
+}
+
+// idle sends the pending entry once no line has come for the timeout.
+// A timer that fired just as a line came in finds it too recent and
+// leaves it to the timer add reset.
+func (a *assembler) idle() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if time.Since(a.last) >= a.group.rules.timeout {
+		a.flushLocked()
+	}
+}
+
+// flush sends the pending entry, as when the stream has ended.
+func (a *assembler) flush() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.flushLocked()
+}
+
+// stop cancels the idle timer, for when nothing more can be sent.
+func (a *assembler) stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+}
+
+// flushLocked is flush for callers holding mu.
+func (a *assembler) flushLocked() bool {
+	if lines := a.group.flush(); lines != nil {
+		return a.sendLocked(lines)
+	}
+	return true
+}
+
+// sendLocked sends a group as one entry, stamped with the current time
+// if it carries no timestamp of its own.
+func (a *assembler) sendLocked(lines []string) bool {
+	entry := a.parser.groupEntry(a.path, lines)
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	entry.Historical = a.historical
+	return a.send(entry)
+}
@@ -0,0 +1,194 @@
+package ingest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMultilineRules tests the validation of multiline settings.
+func TestMultilineRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  MultilineConfig
+		wantErr string
+	}{
+		{name: "preset", config: MultilineConfig{Preset: "java"}},
+		{name: "start with limits", config: MultilineConfig{Start: `^\d{4}-`, MaxLines: 10, Timeout: "250ms"}},
+		{name: "preset and start", config: MultilineConfig{Preset: "python", Start: `^\d{4}-`}},
+		{name: "nothing to match", config: MultilineConfig{MaxLines: 10}, wantErr: "one of preset"},
+		{name: "unknown preset", config: MultilineConfig{Preset: "cobol"}, wantErr: "unknown preset"},
+		{name: "preset and continuation", config: MultilineConfig{Preset: "go", Continuation: `^\s`}, wantErr: "both"},
+		{name: "bad start", config: MultilineConfig{Start: `(`}, wantErr: "invalid start pattern"},
+		{name: "negative max_lines", config: MultilineConfig{Start: `^\S`, MaxLines: -1}, wantErr: "max_lines"},
+		{name: "bad timeout", config: MultilineConfig{Start: `^\S`, Timeout: "soon"}, wantErr: "invalid timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateParsers(SourceConfig{Multiline: &tt.config})
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("validateParsers() error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("validateParsers() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestLineGroup tests how the presets and patterns group lines.
+func TestLineGroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		config MultilineConfig
+		input  string
+		want   []int // Lines per entry
+	}{
+		{
+			name:   "java",
+			config: MultilineConfig{Preset: "java"},
+			input: `2026-10-16 12:00:00 ERROR request failed
+java.lang.IllegalStateException: boom
+	at com.example.App.run(App.java:42)
+	at com.example.App.main(App.java:7)
+Caused by: java.io.IOException: closed
+	... 2 more
+2026-10-16 12:00:01 INFO next request`,
+			want: []int{6, 1},
+		},
+		{
+			name:   "python",
+			config: MultilineConfig{Preset: "python"},
+			input: `2026-10-16 12:00:00,001 ERROR job crashed
+Traceback (most recent call last):
+  File "job.py", line 3, in <module>
+    main()
+ValueError: bad input
+2026-10-16 12:00:01,002 INFO retrying`,
+			want: []int{5, 1},
+		},
+		{
+			name:   "go",
+			config: MultilineConfig{Preset: "go"},
+			input: `panic: runtime error: index out of range [5] with length 3
+goroutine 1 [running]:
+main.main()
+	/src/main.go:8 +0x1d
+exit status 2
+server restarted`,
+			want: []int{5, 1},
+		},
+		{
+			name:   "start pattern",
+			config: MultilineConfig{Start: `^\[\d+\]`},
+			input:  "continued from before\n[1] first\n  more\nand more\n[2] second",
+			want:   []int{1, 3, 1},
+		},
+		{
+			name:   "max lines",
+			config: MultilineConfig{Continuation: `^\s`, MaxLines: 2},
+			input:  "a\n 1\n 2\n 3\nb",
+			want:   []int{2, 2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := newMultilineRules(&tt.config)
+			if err != nil {
+				t.Fatalf("newMultilineRules() error: %v", err)
+			}
+			group := lineGroup{rules: rules}
+			var got []int
+			for _, line := range strings.Split(tt.input, "\n") {
+				if lines := group.add(line); lines != nil {
+					got = append(got, len(lines))
+				}
+			}
+			if lines := group.flush(); lines != nil {
+				got = append(got, len(lines))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("groups = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("groups = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+// TestAssembler tests that a live entry keeps its first line as the
+// message, the whole text as Raw, and is sent once input goes idle.
+func TestAssembler(t *testing.T) {
+	p, err := newLineParser(SourceConfig{
+		Name:      "app",
+		Multiline: &MultilineConfig{Preset: "java", Timeout: "20ms"},
+	})
+	if err != nil {
+		t.Fatalf("newLineParser() error: %v", err)
+	}
+	entries := make(chan LogEntry, 10)
+	a := p.newAssembler(func(entry LogEntry) bool {
+		return sendEntry(context.Background(), entries, entry)
+	})
+	defer a.stop()
+
+	trace := []string{
+		"2026-10-16 12:00:00 ERROR request failed",
+		"java.lang.IllegalStateException: boom",
+		"\tat com.example.App.run(App.java:42)",
+	}
+	for _, line := range trace {
+		a.add("/var/log/app.log", line, false)
+	}
+	// Another file's line can't continue the trace
+	a.add("/var/log/other.log", "\tat nothing", true)
+
+	got := waitEntry(t, entries)
+	if got.Message != "ERROR request failed" || got.Raw != strings.Join(trace, "\n") || got.Historical {
+		t.Errorf("entry = %q / %q / historical %v", got.Message, got.Raw, got.Historical)
+	}
+	if got.Level != LevelError || got.Metadata["path"] != "/var/log/app.log" {
+		t.Errorf("entry level %v, path %q", got.Level, got.Metadata["path"])
+	}
+
+	// The idle timeout sends the last one
+	start := time.Now()
+	last := waitEntry(t, entries)
+	if last.Raw != "\tat nothing" || !last.Historical || last.Timestamp.IsZero() {
+		t.Errorf("last = %+v", last)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("idle entry took %v", time.Since(start))
+	}
+}
+
+// TestHistoryEntriesMultiline tests that history joins multi-line
+// entries, without running one on from a file into the next.
+func TestHistoryEntriesMultiline(t *testing.T) {
+	p, err := newLineParser(SourceConfig{Name: "app", Multiline: &MultilineConfig{Preset: "python"}})
+	if err != nil {
+		t.Fatalf("newLineParser() error: %v", err)
+	}
+	got := historyEntries(p, []historyChunk{
+		{path: "app.log.1", lines: []string{"2026-10-16 12:00:00 ERROR boom", "Traceback (most recent call last):"}},
+		{path: "app.log", lines: []string{"  File \"x.py\", line 1", "2026-10-16 12:00:01 INFO ok"}},
+	})
+
+	if len(got) != 3 {
+		t.Fatalf("historyEntries() = %d entries, want 3", len(got))
+	}
+	if got[0].Raw != "2026-10-16 12:00:00 ERROR boom\nTraceback (most recent call last):" {
+		t.Errorf("first Raw = %q", got[0].Raw)
+	}
+	// The orphaned frame takes the previous entry's time
+	if !got[1].Timestamp.Equal(got[0].Timestamp) || got[1].Metadata["path"] != "app.log" {
+		t.Errorf("second = %v at %s, want the first's time", got[1].Timestamp, got[1].Metadata["path"])
+	}
+}
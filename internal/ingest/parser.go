@@ -0,0 +1,331 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Parser reads one line format into a LogEntry.
+//
+// Parse is handed an entry holding the whole line as its message, the
+// source's name and the path read from, with no timestamp. It fills in
+// what the line carries and reports whether the line was in its format;
+// when it returns false it must leave the entry untouched, so the next
+// parser of the chain starts clean.
+type Parser interface {
+	Parse(line string, entry *LogEntry) bool
+}
+
+// ParserConfig configures one parser of a source's chain.
+type ParserConfig struct {
+	// Type is a registered parser name ("syslog", "json", "logfmt",
+	// "access", "nginx_error", "haproxy", "regex", "raw")
+	Type string `yaml:"type" json:"type"`
+
+	// Pattern is the regex parser's expression; named groups become fields
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+
+	// Preset is the json parser's field layout: "zap", "logrus", "slog",
+	// "bunyan" or "pino"; empty tries the usual key names
+	Preset string `yaml:"preset,omitempty" json:"preset,omitempty"`
+
+	// TimeKey, LevelKey and MessageKey override where the json parser
+	// finds those fields; dotted paths reach into nested objects
+	TimeKey    string `yaml:"time_key,omitempty" json:"time_key,omitempty"`
+	LevelKey   string `yaml:"level_key,omitempty" json:"level_key,omitempty"`
+	MessageKey string `yaml:"message_key,omitempty" json:"message_key,omitempty"`
+
+	// Location is the zone of times written without one, and Levels the
+	// source's own level names; both are set when the chain is built
+	Location *time.Location `yaml:"-" json:"-"`
+	Levels   LevelNames     `yaml:"-" json:"-"`
+}
+
+// ParserFactory builds a parser from its configuration. Like a
+// Validator, it must not touch the filesystem.
+type ParserFactory func(config ParserConfig) (Parser, error)
+
+// Parsers register themselves from init(), as source types do.
+var (
+	parsersMu sync.RWMutex
+	parsers   = make(map[string]ParserFactory)
+)
+
+// RegisterParser makes a parser available to source configs by name.
+// It panics on duplicate registration, which is always a programming error.
+func RegisterParser(name string, factory ParserFactory) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+
+	if _, exists := parsers[name]; exists {
+		panic(fmt.Sprintf("ingest: parser %q registered twice", name))
+	}
+	parsers[name] = factory
+}
+
+// ParserNames returns the registered parser names in sorted order.
+func ParserNames() []string {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newParser builds one parser of a chain, reading times in local time
+// unless the config says otherwise.
+func newParser(config ParserConfig) (Parser, error) {
+	if config.Location == nil {
+		config.Location = time.Local
+	}
+	parsersMu.RLock()
+	factory, ok := parsers[config.Type]
+	parsersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("invalid parser %q (must be %s)", config.Type, joinOr(ParserNames()))
+	}
+	return factory(config)
+}
+
+func init() {
+	RegisterParser("syslog", func(config ParserConfig) (Parser, error) {
+		return syslogLineParser{loc: config.Location, levels: config.Levels}, nil
+	})
+	RegisterParser("raw", func(ParserConfig) (Parser, error) { return rawParser{}, nil })
+	RegisterParser("regex", newRegexParser)
+}
+
+// defaultParsers is the chain of a source without a parser setting.
+var defaultParsers = []ParserConfig{{Type: "syslog"}}
+
+// lineParser turns the lines of a file-like source into entries. Each
+// parser of the source's chain is tried in turn and the first to
+// recognise the line wins; a line none of them recognise is kept whole.
+type lineParser struct {
+	config    SourceConfig
+	chain     []Parser
+	loc       *time.Location
+	multiline *multilineRules // nil: every line is an entry
+	levels    *levelRules
+}
+
+// newLineParser builds the parser chain a source's config asks for.
+func newLineParser(config SourceConfig) (*lineParser, error) {
+	loc, err := sourceLocation(config)
+	if err != nil {
+		return nil, err
+	}
+	multiline, err := newMultilineRules(config.Multiline)
+	if err != nil {
+		return nil, fmt.Errorf("multiline: %w", err)
+	}
+	levels, err := newLevelRules(config.Levels)
+	if err != nil {
+		return nil, fmt.Errorf("levels: %w", err)
+	}
+	configs := config.Parsers
+	if len(configs) == 0 {
+		configs = defaultParsers
+	}
+
+	p := &lineParser{config: config, loc: loc, multiline: multiline, levels: levels}
+	for _, pc := range configs {
+		pc.Location, pc.Levels = loc, levels.names
+		parser, err := newParser(pc)
+		if err != nil {
+			return nil, fmt.Errorf("parser %s: %w", pc.Type, err)
+		}
+		p.chain = append(p.chain, parser)
+	}
+	return p, nil
+}
+
+// validateParsers checks the parser chain, multiline and level settings
+// of a line-based source.
+func validateParsers(config SourceConfig) error {
+	_, err := newLineParser(config)
+	return err
+}
+
+// entry parses a line read from path. The timestamp is left zero when
+// the line carries none.
+func (p *lineParser) entry(path, line string) LogEntry {
+	entry := LogEntry{
+		Source:       p.config.Name,
+		IngestorName: p.config.Name, // Config name for filtering
+		SourceType:   p.config.Type,
+		Raw:          line,
+		Message:      line, // Default: whole line is the message
+		Level:        LevelUnknown,
+		Metadata:     map[string]string{"path": path},
+	}
+
+	for _, parser := range p.chain {
+		if parser.Parse(line, &entry) {
+			break
+		}
+	}
+
+	// Most application logs start with a timestamp of some kind, which
+	// need not be in a format of the chain; when nothing else took the
+	// line, the timestamp column shows it and the message doesn't repeat it
+	if entry.Timestamp.IsZero() {
+		if ts, rest, ok := leadingTimestamp(line, p.loc, time.Now()); ok {
+			entry.Timestamp = ts
+			if entry.Message == line && rest != "" {
+				entry.Message = rest
+			}
+		}
+	}
+
+	// Formats without a level field still often name one in the text;
+	// only the message is read, so a hostname or path can't supply it
+	if entry.Level == LevelUnknown {
+		entry.Level = detectLevel(entry.Message, p.levels.names)
+	}
+	return entry
+}
+
+// timestamp extracts the timestamp a line carries, if any.
+func (p *lineParser) timestamp(line string) (time.Time, bool) {
+	ts := p.entry("", line).Timestamp
+	return ts, !ts.IsZero()
+}
+
+// syslogLineParser reads syslog files: the traditional format
+// "Jan 18 15:04:05 hostname process[pid]: message", the same with an
+// RFC 3339 timestamp (rsyslog's high-precision file format), and
+// messages written with their "<PRI>" as received.
+type syslogLineParser struct {
+	loc    *time.Location
+	levels LevelNames
+}
+
+// Parse implements Parser.
+func (p syslogLineParser) Parse(line string, entry *LogEntry) bool {
+	if strings.HasPrefix(line, "<") {
+		msg, err := parseSyslogMessage(line, time.Now(), p.loc)
+		if err != nil {
+			return false
+		}
+		msg.fill(entry, p.levels)
+		return true
+	}
+
+	parsed := parseSyslogLine(line, p.loc)
+	if parsed == nil {
+		return false
+	}
+	entry.Timestamp = parsed.timestamp
+	entry.Message = parsed.message
+	entry.Hostname = parsed.hostname
+	entry.PID = parsed.pid
+	entry.Metadata["process"] = parsed.process
+	return true
+}
+
+// rawParser accepts every line as it is, for logs with no format to
+// speak of; put last in a chain, it stops the default syslog attempt.
+type rawParser struct{}
+
+// Parse implements Parser.
+func (rawParser) Parse(string, *LogEntry) bool {
+	return true
+}
+
+// regexParser reads lines with a user-supplied expression. Named groups
+// called timestamp, level, source, pid, host and message fill those
+// fields of the entry; any other named group goes into Metadata.
+type regexParser struct {
+	re     *regexp.Regexp
+	loc    *time.Location
+	levels LevelNames
+}
+
+// newRegexParser compiles a regex parser's pattern.
+func newRegexParser(config ParserConfig) (Parser, error) {
+	if config.Pattern == "" {
+		return nil, errors.New("pattern is required")
+	}
+	re, err := regexp.Compile(config.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	named := false
+	for _, name := range re.SubexpNames() {
+		named = named || name != ""
+	}
+	if !named {
+		return nil, fmt.Errorf("pattern %q has no named groups, e.g. (?P<message>.*)", config.Pattern)
+	}
+	return &regexParser{re: re, loc: config.Location, levels: config.Levels}, nil
+}
+
+// Parse implements Parser.
+func (p *regexParser) Parse(line string, entry *LogEntry) bool {
+	m := p.re.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	for i, name := range p.re.SubexpNames() {
+		// Optional groups that didn't take part are skipped
+		if name != "" && m[i] != "" {
+			setField(entry, name, m[i], p.loc, p.levels)
+		}
+	}
+	return true
+}
+
+// setField stores a named value parsed from a line: the well-known names
+// fill the entry's own fields, and anything else, including a timestamp
+// or level that can't be read, goes into Metadata. A timestamp without a
+// zone is read in loc, and a level looked up in the source's names.
+func setField(entry *LogEntry, name, value string, loc *time.Location, levels LevelNames) {
+	switch name {
+	case "timestamp":
+		if ts, ok := fieldTimestamp(value, loc); ok {
+			entry.Timestamp = ts
+			return
+		}
+	case "level":
+		if level := levels.Level(value); level != LevelUnknown {
+			entry.Level = level
+			return
+		}
+	case "source":
+		entry.Source = value
+		return
+	case "pid":
+		if pid, err := strconv.Atoi(value); err == nil {
+			entry.PID = pid
+			return
+		}
+	case "host":
+		entry.Hostname = value
+		return
+	case "message":
+		entry.Message = value
+		return
+	}
+	entry.Metadata[name] = value
+}
+
+// Ensure the built-in parsers implement Parser
+var (
+	_ Parser = syslogLineParser{}
+	_ Parser = rawParser{}
+	_ Parser = (*regexParser)(nil)
+)
@@ -0,0 +1,243 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Web server logs: the access log shared by nginx and Apache, nginx's
+// error log and HAProxy's HTTP log. Access lines take their level from
+// the status class, so failing requests stand out.
+func init() {
+	RegisterParser("access", func(ParserConfig) (Parser, error) { return accessParser{}, nil })
+	RegisterParser("nginx_error", func(config ParserConfig) (Parser, error) {
+		return nginxErrorParser{loc: config.Location, levels: config.Levels}, nil
+	})
+	RegisterParser("haproxy", func(config ParserConfig) (Parser, error) { return haproxyParser{loc: config.Location}, nil })
+}
+
+// clfLayout is the Common Log Format timestamp.
+const clfLayout = "02/Jan/2006:15:04:05 -0700"
+
+// accessRegex matches the common and combined formats:
+//
+//	10.0.0.1 - alice [16/Oct/2026:12:00:00 +0000] "GET / HTTP/1.1" 200 512 "-" "curl/8.5"
+//
+// The referrer and user agent are the combined format's additions.
+// Whatever follows is extra fields some configs append, such as nginx's
+// $request_time.
+var accessRegex = regexp.MustCompile(
+	`^(\S+) \S+ (\S+) \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}) (\d+|-)(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?(.*)$`,
+)
+
+// accessParser reads common and combined access logs.
+type accessParser struct{}
+
+// Parse implements Parser.
+func (accessParser) Parse(line string, entry *LogEntry) bool {
+	m := accessRegex.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	ts, err := time.Parse(clfLayout, m[3])
+	if err != nil {
+		return false
+	}
+
+	entry.Timestamp = ts
+	entry.Metadata["client_ip"] = m[1]
+	if m[2] != "-" {
+		entry.Metadata["remote_user"] = m[2]
+	}
+	status := setHTTPRequest(entry, m[4], m[5])
+	if m[6] != "-" {
+		entry.Metadata["bytes"] = m[6]
+	}
+	if m[7] != "" && m[7] != "-" {
+		entry.Metadata["referrer"] = m[7]
+	}
+	if m[8] != "" && m[8] != "-" {
+		entry.Metadata["user_agent"] = m[8]
+	}
+
+	// A trailing number is nginx's $request_time; key=value fields are
+	// kept as they are, rt= and request_time= as the request time
+	for _, field := range strings.Fields(m[9]) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			key, value = "request_time", field
+		}
+		value = strings.Trim(value, `"`)
+		if key == "rt" || key == "request_time" {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+			key = "request_time"
+		}
+		entry.Metadata[key] = value
+	}
+
+	entry.Level = httpStatusLevel(status)
+	return true
+}
+
+// setHTTPRequest records a request line ("GET /path HTTP/1.1") and
+// status, and makes "GET /path 200" the message. A request line that
+// isn't one (a TLS handshake sent to a plain port, say) is kept whole.
+// It returns the status.
+func setHTTPRequest(entry *LogEntry, request, statusText string) int {
+	entry.Metadata["status"] = statusText
+	status, _ := strconv.Atoi(statusText)
+
+	parts := strings.Fields(request)
+	if len(parts) < 2 || len(parts) > 3 {
+		entry.Metadata["request"] = request
+		entry.Message = strings.TrimSpace(request + " " + statusText)
+		return status
+	}
+	entry.Metadata["method"] = parts[0]
+	entry.Metadata["uri"] = parts[1] // "path" already names the log file
+	if len(parts) == 3 {
+		entry.Metadata["protocol"] = parts[2]
+	}
+	entry.Message = parts[0] + " " + parts[1] + " " + statusText
+	return status
+}
+
+// httpStatusLevel maps a status class to a level: 5xx errors, 4xx
+// warnings, anything else informational.
+func httpStatusLevel(status int) LogLevel {
+	switch {
+	case status >= 500:
+		return LevelError
+	case status >= 400:
+		return LevelWarning
+	case status > 0:
+		return LevelInfo
+	default:
+		// HAProxy logs -1 when no response was received
+		return LevelError
+	}
+}
+
+// nginxErrorRegex matches nginx's error log:
+//
+//	2026/10/16 12:00:00 [error] 1234#1234: *5 open() "/srv/x" failed (2: No such file or directory), client: 10.0.0.1, server: example.com, request: "GET /x HTTP/1.1", host: "example.com"
+var nginxErrorRegex = regexp.MustCompile(
+	`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}) \[(\w+)\] (\d+)#(\d+): (?:\*(\d+) )?(.*)$`,
+)
+
+// nginxContextRegex matches the ", key: value" context nginx appends to
+// an error message, one field at a time from the end.
+var nginxContextRegex = regexp.MustCompile(`, (client|server|request|upstream|host|referrer|subrequest): ("(?:[^"\\]|\\.)*"|[^,"]*)$`)
+
+// nginxErrorParser reads nginx's error log.
+type nginxErrorParser struct {
+	loc    *time.Location
+	levels LevelNames
+}
+
+// Parse implements Parser.
+func (p nginxErrorParser) Parse(line string, entry *LogEntry) bool {
+	m := nginxErrorRegex.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	// nginx writes local time without a zone
+	ts, err := time.ParseInLocation("2006/01/02 15:04:05", m[1], p.loc)
+	if err != nil {
+		return false
+	}
+
+	entry.Timestamp = ts
+	entry.Level = p.levels.Level(m[2])
+	entry.PID = parseInt(m[3])
+	entry.Metadata["tid"] = m[4]
+	if m[5] != "" {
+		entry.Metadata["connection"] = m[5]
+	}
+
+	message := m[6]
+	for {
+		c := nginxContextRegex.FindStringSubmatchIndex(message)
+		if c == nil {
+			break
+		}
+		key, value := message[c[2]:c[3]], message[c[4]:c[5]]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		if key == "client" {
+			key = "client_ip"
+		}
+		entry.Metadata[key] = value
+		message = message[:c[0]]
+	}
+	entry.Message = message
+	return true
+}
+
+// haproxyLayout is HAProxy's accept date, in local time.
+const haproxyLayout = "02/Jan/2006:15:04:05.000"
+
+// haproxyRegex matches HAProxy's HTTP log format (option httplog), after
+// whatever syslog header it arrived with:
+//
+//	10.0.0.1:51234 [16/Oct/2026:12:00:00.123] web~ api/srv1 0/0/1/12/13 200 512 - - ---- 1/1/0/0/0 0/0 "GET /x HTTP/1.1"
+var haproxyRegex = regexp.MustCompile(
+	`(\S+):(\d+) \[(\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2}\.\d{3})\] (\S+) ([^/\s]+)/(\S+) ` +
+		`(-?\d+/-?\d+/-?\d+/-?\d+/\+?(-?\d+)) (-?\d+) \+?(\d+) \S+ \S+ (\S{4}) \S+ \S+(?: \{[^}]*\}){0,2} "((?:[^"\\]|\\.)*)"`,
+)
+
+// haproxyParser reads HAProxy's HTTP log.
+type haproxyParser struct {
+	loc *time.Location
+}
+
+// Parse implements Parser.
+func (p haproxyParser) Parse(line string, entry *LogEntry) bool {
+	loc := haproxyRegex.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return false
+	}
+	group := func(i int) string { return line[loc[2*i]:loc[2*i+1]] }
+	ts, err := time.ParseInLocation(haproxyLayout, group(3), p.loc)
+	if err != nil {
+		return false
+	}
+
+	// The syslog header, if the line came through a file, names the host
+	if loc[0] > 0 {
+		if parsed := parseSyslogLine(line, p.loc); parsed != nil {
+			entry.Hostname = parsed.hostname
+			entry.Metadata["process"] = parsed.process
+		}
+	}
+
+	entry.Timestamp = ts
+	entry.Metadata["client_ip"] = group(1)
+	entry.Metadata["client_port"] = group(2)
+	entry.Metadata["frontend"] = group(4)
+	entry.Metadata["backend"] = group(5)
+	entry.Metadata["server"] = group(6)
+	entry.Metadata["timers"] = group(7)
+	if ms, err := strconv.Atoi(group(8)); err == nil && ms >= 0 {
+		entry.Metadata["request_time"] = strconv.FormatFloat(float64(ms)/1000, 'f', -1, 64)
+	}
+	entry.Metadata["bytes"] = group(10)
+	entry.Metadata["termination_state"] = group(11)
+
+	status := setHTTPRequest(entry, group(12), group(9))
+	entry.Level = httpStatusLevel(status)
+	return true
+}
+
+// Ensure the web server parsers implement Parser
+var (
+	_ Parser = accessParser{}
+	_ Parser = nginxErrorParser{}
+	_ Parser = haproxyParser{}
+)
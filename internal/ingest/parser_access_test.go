@@ -0,0 +1,121 @@
+package ingest
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWebParsers tests the access, nginx error and HAProxy parsers.
+func TestWebParsers(t *testing.T) {
+	utc := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	local := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		parser   Parser
+		line     string
+		ts       time.Time
+		level    LogLevel
+		message  string
+		metadata map[string]string
+	}{
+		{
+			name:    "combined",
+			parser:  accessParser{},
+			line:    `10.0.0.1 - alice [16/Oct/2026:14:00:00 +0200] "GET /api/users?id=1 HTTP/1.1" 200 512 "https://example.com/" "curl/8.5.0"`,
+			ts:      utc,
+			level:   LevelInfo,
+			message: "GET /api/users?id=1 200",
+			metadata: map[string]string{
+				"client_ip": "10.0.0.1", "remote_user": "alice", "method": "GET", "uri": "/api/users?id=1",
+				"protocol": "HTTP/1.1", "status": "200", "bytes": "512", "referrer": "https://example.com/", "user_agent": "curl/8.5.0",
+			},
+		},
+		{
+			name:     "common, server error",
+			parser:   accessParser{},
+			line:     `10.0.0.2 - - [16/Oct/2026:12:00:00 +0000] "POST /checkout HTTP/1.1" 502 -`,
+			ts:       utc,
+			level:    LevelError,
+			message:  "POST /checkout 502",
+			metadata: map[string]string{"client_ip": "10.0.0.2", "method": "POST", "status": "502"},
+		},
+		{
+			name:     "combined with request time, not found",
+			parser:   accessParser{},
+			line:     `::1 - - [16/Oct/2026:12:00:00 +0000] "GET /missing HTTP/2.0" 404 153 "-" "Mozilla/5.0 (X11)" 0.004 upstream=api`,
+			ts:       utc,
+			level:    LevelWarning,
+			message:  "GET /missing 404",
+			metadata: map[string]string{"client_ip": "::1", "request_time": "0.004", "upstream": "api", "user_agent": "Mozilla/5.0 (X11)"},
+		},
+		{
+			name:     "bad request line",
+			parser:   accessParser{},
+			line:     `10.0.0.3 - - [16/Oct/2026:12:00:00 +0000] "\x16\x03\x01" 400 157 "-" "-"`,
+			ts:       utc,
+			level:    LevelWarning,
+			message:  `\x16\x03\x01 400`,
+			metadata: map[string]string{"request": `\x16\x03\x01`},
+		},
+		{
+			name:    "nginx error",
+			parser:  nginxErrorParser{loc: time.Local},
+			line:    `2026/10/16 12:00:00 [error] 1234#1234: *5 open() "/srv/www/x" failed (2: No such file or directory), client: 10.0.0.1, server: example.com, request: "GET /x HTTP/1.1", host: "example.com"`,
+			ts:      local,
+			level:   LevelError,
+			message: `open() "/srv/www/x" failed (2: No such file or directory)`,
+			metadata: map[string]string{
+				"tid": "1234", "connection": "5", "client_ip": "10.0.0.1", "server": "example.com",
+				"request": "GET /x HTTP/1.1", "host": "example.com",
+			},
+		},
+		{
+			name:     "nginx warning without context",
+			parser:   nginxErrorParser{loc: time.Local},
+			line:     `2026/10/16 12:00:00 [warn] 99#0: conflicting server name "a" on 0.0.0.0:80, ignored`,
+			ts:       local,
+			level:    LevelWarning,
+			message:  `conflicting server name "a" on 0.0.0.0:80, ignored`,
+			metadata: map[string]string{"tid": "0"},
+		},
+		{
+			name:    "haproxy through syslog",
+			parser:  haproxyParser{loc: time.Local},
+			line:    `Oct 16 12:00:00 lb1 haproxy[4242]: 10.0.0.1:51234 [16/Oct/2026:12:00:00.000] web~ api/srv1 0/0/1/12/1500 503 512 - - sC-- 1/1/0/0/0 0/0 {example.com} "GET /slow HTTP/1.1"`,
+			ts:      local,
+			level:   LevelError,
+			message: "GET /slow 503",
+			metadata: map[string]string{
+				"client_ip": "10.0.0.1", "frontend": "web~", "backend": "api", "server": "srv1",
+				"request_time": "1.5", "termination_state": "sC--", "process": "haproxy",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := LogEntry{Message: tt.line, Metadata: map[string]string{"path": "/var/log/web.log"}}
+			if !tt.parser.Parse(tt.line, &entry) {
+				t.Fatal("Parse() = false, want true")
+			}
+			if !entry.Timestamp.Equal(tt.ts) || entry.Level != tt.level || entry.Message != tt.message {
+				t.Errorf("Parse() = %v %v %q, want %v %v %q", entry.Timestamp, entry.Level, entry.Message, tt.ts, tt.level, tt.message)
+			}
+			for key, value := range tt.metadata {
+				if got := entry.Metadata[key]; got != value {
+					t.Errorf("Metadata[%s] = %q, want %q", key, got, value)
+				}
+			}
+			if entry.Metadata["path"] != "/var/log/web.log" {
+				t.Errorf("Metadata[path] = %q, want the log file", entry.Metadata["path"])
+			}
+		})
+	}
+
+	for _, p := range []Parser{accessParser{}, nginxErrorParser{loc: time.Local}, haproxyParser{loc: time.Local}} {
+		if p.Parse("Oct 16 12:00:00 host app: hello", &LogEntry{Metadata: map[string]string{}}) {
+			t.Errorf("%T accepted a plain syslog line", p)
+		}
+	}
+}
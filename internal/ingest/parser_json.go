@@ -0,0 +1,272 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonKeys says where a JSON logger puts the fields a LogEntry has. Each
+// list holds candidate paths, the first one present winning; a path is a
+// key or dotted nested keys ("log.level").
+type jsonKeys struct {
+	time, level, message, source, host, pid []string
+}
+
+// jsonPresets are the field layouts of common JSON loggers.
+var jsonPresets = map[string]jsonKeys{
+	// zap's production encoder: {"level":"info","ts":1697460000.12,"logger":"api","msg":"..."}
+	"zap": {time: []string{"ts"}, level: []string{"level"}, message: []string{"msg"}, source: []string{"logger"}},
+	// logrus: {"level":"info","msg":"...","time":"2026-10-16T12:00:00Z"}
+	"logrus": {time: []string{"time"}, level: []string{"level"}, message: []string{"msg"}},
+	// log/slog's JSONHandler: {"time":"...","level":"INFO","msg":"..."}
+	"slog": {time: []string{"time"}, level: []string{"level"}, message: []string{"msg"}},
+	// bunyan: numeric levels, {"name":"api","hostname":"h","pid":1,"level":30,"msg":"...","time":"..."}
+	"bunyan": {
+		time: []string{"time"}, level: []string{"level"}, message: []string{"msg"},
+		source: []string{"name"}, host: []string{"hostname"}, pid: []string{"pid"},
+	},
+	// pino: bunyan's levels with epoch-millisecond times
+	"pino": {
+		time: []string{"time"}, level: []string{"level"}, message: []string{"msg"},
+		source: []string{"name"}, host: []string{"hostname"}, pid: []string{"pid"},
+	},
+}
+
+// jsonDefaultKeys covers the usual names when no preset is given,
+// including Docker's json-file driver ("log") and ECS ("@timestamp",
+// "log.level").
+var jsonDefaultKeys = jsonKeys{
+	time:    []string{"time", "ts", "timestamp", "@timestamp"},
+	level:   []string{"level", "lvl", "severity", "log.level"},
+	message: []string{"msg", "message", "log"},
+	host:    []string{"hostname", "host"},
+	pid:     []string{"pid"},
+}
+
+// jsonParser reads JSON-lines logs. The mapped fields fill the entry and
+// every other field, nested objects flattened to dotted keys, goes into
+// Metadata for the detail view.
+type jsonParser struct {
+	keys   jsonKeys
+	loc    *time.Location
+	levels LevelNames
+}
+
+func init() {
+	RegisterParser("json", newJSONParser)
+}
+
+// newJSONParser builds a JSON parser from a preset and key overrides.
+func newJSONParser(config ParserConfig) (Parser, error) {
+	keys := jsonDefaultKeys
+	if config.Preset != "" {
+		preset, ok := jsonPresets[config.Preset]
+		if !ok {
+			return nil, fmt.Errorf("unknown preset %q (must be %s)", config.Preset, joinOr(jsonPresetNames()))
+		}
+		keys = preset
+	}
+
+	for _, override := range []struct {
+		key  string
+		dest *[]string
+	}{
+		{config.TimeKey, &keys.time},
+		{config.LevelKey, &keys.level},
+		{config.MessageKey, &keys.message},
+	} {
+		if override.key != "" {
+			*override.dest = []string{override.key}
+		}
+	}
+	return &jsonParser{keys: keys, loc: config.Location, levels: config.Levels}, nil
+}
+
+// jsonPresetNames returns the preset names in sorted order.
+func jsonPresetNames() []string {
+	names := make([]string, 0, len(jsonPresets))
+	for name := range jsonPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse implements Parser.
+func (p *jsonParser) Parse(line string, entry *LogEntry) bool {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") {
+		return false
+	}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return false
+	}
+
+	// used holds the paths mapped onto the entry, kept out of Metadata
+	used := make(map[string]bool)
+	if path, value, ok := lookupJSON(fields, p.keys.time); ok {
+		if ts, ok := jsonTimestamp(value, p.loc); ok {
+			entry.Timestamp = ts
+			used[path] = true
+		}
+	}
+	if path, value, ok := lookupJSON(fields, p.keys.level); ok {
+		if level := jsonLevel(value, p.levels); level != LevelUnknown {
+			entry.Level = level
+			used[path] = true
+		}
+	}
+	if path, value, ok := lookupJSON(fields, p.keys.message); ok {
+		if msg, ok := value.(string); ok {
+			entry.Message = strings.TrimRight(msg, "\r\n")
+			used[path] = true
+		}
+	}
+	if path, value, ok := lookupJSON(fields, p.keys.source); ok {
+		if source := jsonString(value); source != "" {
+			entry.Source = source
+			used[path] = true
+		}
+	}
+	if path, value, ok := lookupJSON(fields, p.keys.host); ok {
+		if host, ok := value.(string); ok {
+			entry.Hostname = host
+			used[path] = true
+		}
+	}
+	if path, value, ok := lookupJSON(fields, p.keys.pid); ok {
+		if pid, err := strconv.Atoi(jsonString(value)); err == nil {
+			entry.PID = pid
+			used[path] = true
+		}
+	}
+
+	flattenJSON(fields, "", used, entry.Metadata)
+	return true
+}
+
+// lookupJSON returns the first of paths present in fields. A path is
+// tried as a key first, since some loggers write "log.level" flat, then
+// as nested keys.
+func lookupJSON(fields map[string]any, paths []string) (string, any, bool) {
+	for _, path := range paths {
+		if value, ok := fields[path]; ok {
+			return path, value, true
+		}
+		var value any = fields
+		for _, key := range strings.Split(path, ".") {
+			object, ok := value.(map[string]any)
+			if !ok {
+				value = nil
+				break
+			}
+			if value, ok = object[key]; !ok {
+				value = nil
+				break
+			}
+		}
+		if value != nil {
+			return path, value, true
+		}
+	}
+	return "", nil, false
+}
+
+// flattenJSON stores every field not in used into metadata, nested
+// objects under dotted keys and arrays as compact JSON.
+func flattenJSON(fields map[string]any, prefix string, used map[string]bool, metadata map[string]string) {
+	for key, value := range fields {
+		path := prefix + key
+		if used[path] {
+			continue
+		}
+		if object, ok := value.(map[string]any); ok && len(object) > 0 {
+			flattenJSON(object, path+".", used, metadata)
+			continue
+		}
+		metadata[path] = jsonString(value)
+	}
+}
+
+// jsonString formats a decoded JSON value for display.
+func jsonString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "null"
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// jsonTimestamp reads a time field: a timestamp string, or a Unix epoch
+// number in seconds, milliseconds, microseconds or nanoseconds, told
+// apart by magnitude.
+func jsonTimestamp(value any, loc *time.Location) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		return fieldTimestamp(v, loc)
+	case json.Number:
+		if n, err := v.Float64(); err == nil {
+			return epochTime(n), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// jsonLevel reads a level field: a name, or a number on bunyan and
+// pino's scale (10 trace to 60 fatal) or, below 10, a syslog priority.
+// The source's own names come first for either.
+func jsonLevel(value any, levels LevelNames) LogLevel {
+	switch v := value.(type) {
+	case string:
+		return levels.Level(v)
+	case json.Number:
+		if level, ok := levels.mapped(v.String()); ok {
+			return level
+		}
+		n, err := v.Int64()
+		if err != nil {
+			return LevelUnknown
+		}
+		return numericLevel(int(n))
+	}
+	return LevelUnknown
+}
+
+// numericLevel maps bunyan/pino levels, or syslog priorities below 10.
+func numericLevel(n int) LogLevel {
+	switch {
+	case n < 0:
+		return LevelUnknown
+	case n < 10:
+		return priorityToLevel(n)
+	case n < 30:
+		return LevelDebug
+	case n < 40:
+		return LevelInfo
+	case n < 50:
+		return LevelWarning
+	case n < 60:
+		return LevelError
+	default:
+		return LevelCritical
+	}
+}
+
+// Ensure jsonParser implements Parser
+var _ Parser = (*jsonParser)(nil)
@@ -0,0 +1,125 @@
+package ingest
+
+import (
+	"testing"
+	"time"
+)
+
+// TestJSONParser tests the presets, key overrides and flattening.
+func TestJSONParser(t *testing.T) {
+	ts := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		config   ParserConfig
+		line     string
+		want     LogEntry
+		metadata map[string]string
+	}{
+		{
+			name:     "zap",
+			config:   ParserConfig{Type: "json", Preset: "zap"},
+			line:     `{"level":"warn","ts":1792152000.5,"logger":"api","caller":"main.go:42","msg":"slow request","latency_ms":812}`,
+			want:     LogEntry{Timestamp: ts.Add(500 * time.Millisecond), Level: LevelWarning, Source: "api", Message: "slow request"},
+			metadata: map[string]string{"caller": "main.go:42", "latency_ms": "812"},
+		},
+		{
+			name:     "logrus fatal",
+			config:   ParserConfig{Type: "json", Preset: "logrus"},
+			line:     `{"level":"fatal","msg":"cannot bind","time":"2026-10-16T12:00:00Z","port":80}`,
+			want:     LogEntry{Timestamp: ts, Level: LevelCritical, Source: "App", Message: "cannot bind"},
+			metadata: map[string]string{"port": "80"},
+		},
+		{
+			name:     "slog",
+			config:   ParserConfig{Type: "json", Preset: "slog"},
+			line:     `{"time":"2026-10-16T12:00:00.000Z","level":"ERROR","msg":"query failed","db":{"table":"users","retries":3}}`,
+			want:     LogEntry{Timestamp: ts, Level: LevelError, Source: "App", Message: "query failed"},
+			metadata: map[string]string{"db.table": "users", "db.retries": "3"},
+		},
+		{
+			name:     "bunyan",
+			config:   ParserConfig{Type: "json", Preset: "bunyan"},
+			line:     `{"name":"billing","hostname":"web01","pid":4242,"level":50,"msg":"charge failed","time":"2026-10-16T12:00:00.000Z","v":0}`,
+			want:     LogEntry{Timestamp: ts, Level: LevelError, Source: "billing", Hostname: "web01", PID: 4242, Message: "charge failed"},
+			metadata: map[string]string{"v": "0"},
+		},
+		{
+			name:     "pino epoch millis",
+			config:   ParserConfig{Type: "json", Preset: "pino"},
+			line:     `{"level":30,"time":1792152000000,"pid":7,"hostname":"web02","msg":"listening","tags":["a","b"]}`,
+			want:     LogEntry{Timestamp: ts, Level: LevelInfo, Source: "App", Hostname: "web02", PID: 7, Message: "listening"},
+			metadata: map[string]string{"tags": `["a","b"]`},
+		},
+		{
+			name:     "default keys, ECS nested level",
+			config:   ParserConfig{Type: "json"},
+			line:     `{"@timestamp":"2026-10-16T12:00:00Z","log":{"level":"warning","logger":"web"},"message":"disk 91% full"}`,
+			want:     LogEntry{Timestamp: ts, Level: LevelWarning, Source: "App", Message: "disk 91% full"},
+			metadata: map[string]string{"log.logger": "web"},
+		},
+		{
+			name:     "key overrides",
+			config:   ParserConfig{Type: "json", TimeKey: "meta.at", LevelKey: "sev", MessageKey: "event.text"},
+			line:     `{"meta":{"at":"2026-10-16T12:00:00Z"},"sev":"notice","event":{"text":"rotated","id":9}}`,
+			want:     LogEntry{Timestamp: ts, Level: LevelNotice, Source: "App", Message: "rotated"},
+			metadata: map[string]string{"event.id": "9"},
+		},
+		{
+			name:     "docker json-file",
+			config:   ParserConfig{Type: "json"},
+			line:     `{"log":"GET / 200\n","stream":"stdout","time":"2026-10-16T12:00:00.000000000Z"}`,
+			want:     LogEntry{Timestamp: ts, Level: LevelUnknown, Source: "App", Message: "GET / 200"},
+			metadata: map[string]string{"stream": "stdout"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newLineParser(SourceConfig{Name: "App", Parsers: []ParserConfig{tt.config}})
+			if err != nil {
+				t.Fatalf("newLineParser() error: %v", err)
+			}
+			got := p.entry("app.log", tt.line)
+			if !got.Timestamp.Equal(tt.want.Timestamp) || got.Level != tt.want.Level || got.Source != tt.want.Source ||
+				got.Hostname != tt.want.Hostname || got.PID != tt.want.PID || got.Message != tt.want.Message {
+				t.Errorf("entry() = %v %v %s %s %d %q, want %v %v %s %s %d %q",
+					got.Timestamp, got.Level, got.Source, got.Hostname, got.PID, got.Message,
+					tt.want.Timestamp, tt.want.Level, tt.want.Source, tt.want.Hostname, tt.want.PID, tt.want.Message)
+			}
+			// path, plus the unmapped fields and nothing else
+			if len(got.Metadata) != len(tt.metadata)+1 {
+				t.Errorf("Metadata = %v, want %v", got.Metadata, tt.metadata)
+			}
+			for key, value := range tt.metadata {
+				if got.Metadata[key] != value {
+					t.Errorf("Metadata[%s] = %q, want %q", key, got.Metadata[key], value)
+				}
+			}
+		})
+	}
+}
+
+// TestJSONParserFallback tests that non-JSON lines go to the next parser.
+func TestJSONParserFallback(t *testing.T) {
+	p, err := newLineParser(SourceConfig{Name: "App", Parsers: []ParserConfig{{Type: "json"}, {Type: "syslog"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"Oct 11 22:14:15 host app: plain", `{"truncated":`} {
+		got := p.entry("", line)
+		if _, ok := got.Metadata["truncated"]; ok || (got.Message != "plain" && got.Message != line) {
+			t.Errorf("entry(%q) = %+v", line, got)
+		}
+	}
+}
+
+// TestEpochTime tests telling epoch units apart.
+func TestEpochTime(t *testing.T) {
+	want := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for _, n := range []float64{1792152000, 1792152000000, 1792152000000000, 1792152000000000000} {
+		if got := epochTime(n); !got.Equal(want) {
+			t.Errorf("epochTime(%g) = %v, want %v", n, got, want)
+		}
+	}
+}
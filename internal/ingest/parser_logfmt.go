@@ -0,0 +1,165 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// logfmtPair is one key=value pair of a logfmt line. A bare key ("ok"
+// rather than "ok=true") has no value.
+type logfmtPair struct {
+	key   string
+	value string
+	bare  bool
+}
+
+// logfmt keys mapped onto the entry; the first of each that reads wins.
+var (
+	logfmtTimeKeys    = []string{"ts", "time", "timestamp", "t"}
+	logfmtLevelKeys   = []string{"level", "lvl", "severity", "at"} // at: Heroku's router
+	logfmtMessageKeys = []string{"msg", "message"}
+)
+
+// logfmtParser reads key=value lines as written by go-kit, Prometheus,
+// Grafana, Loki and Heroku-style apps:
+//
+//	ts=2026-10-16T12:00:00Z level=warn caller=main.go:42 msg="slow query" took=2.5s
+//
+// The time, level and message keys fill the entry; every other pair goes
+// into Metadata, with the values of a repeated key joined by commas.
+type logfmtParser struct {
+	loc    *time.Location
+	levels LevelNames
+}
+
+func init() {
+	RegisterParser("logfmt", func(config ParserConfig) (Parser, error) {
+		return logfmtParser{loc: config.Location, levels: config.Levels}, nil
+	})
+}
+
+// Parse implements Parser. A line is taken as logfmt when it scans
+// cleanly and most of its keys carry values, so prose with the odd "="
+// in it falls through to the next parser.
+func (p logfmtParser) Parse(line string, entry *LogEntry) bool {
+	pairs, err := parseLogfmt(line)
+	if err != nil {
+		return false
+	}
+	valued := 0
+	for _, pair := range pairs {
+		if !pair.bare {
+			valued++
+		}
+	}
+	if valued == 0 || valued < len(pairs)-valued {
+		return false
+	}
+
+	timeSet, levelSet, messageSet := false, false, false
+	seen := make(map[string]bool, len(pairs))
+	for _, pair := range pairs {
+		switch {
+		case !timeSet && contains(logfmtTimeKeys, pair.key):
+			if ts, ok := fieldTimestamp(pair.value, p.loc); ok {
+				entry.Timestamp, timeSet = ts, true
+				continue
+			}
+		case !levelSet && contains(logfmtLevelKeys, pair.key):
+			if level := p.levels.Level(pair.value); level != LevelUnknown {
+				entry.Level, levelSet = level, true
+				continue
+			}
+		case !messageSet && contains(logfmtMessageKeys, pair.key):
+			entry.Message, messageSet = pair.value, true
+			continue
+		}
+
+		if seen[pair.key] {
+			entry.Metadata[pair.key] += ", " + pair.value
+		} else {
+			entry.Metadata[pair.key] = pair.value
+			seen[pair.key] = true
+		}
+	}
+	return true
+}
+
+// parseLogfmt splits a line into its pairs. Values may be quoted, with
+// Go-style escapes (\" \\ \n \t \u00e9); a key is anything up to '=' or
+// a space that isn't a quote.
+func parseLogfmt(line string) ([]logfmtPair, error) {
+	var pairs []logfmtPair
+	i := 0
+	for {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		if i == len(line) {
+			return pairs, nil
+		}
+
+		start := i
+		for i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		if i == start {
+			return nil, errors.New("logfmt: expected a key")
+		}
+		pair := logfmtPair{key: line[start:i]}
+
+		if i == len(line) || line[i] == ' ' || line[i] == '\t' {
+			pair.bare = true
+			pairs = append(pairs, pair)
+			continue
+		}
+		if line[i] != '=' {
+			return nil, errors.New("logfmt: quote in a key")
+		}
+		i++
+
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, errors.New("logfmt: unterminated quoted value")
+			}
+			value, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, err
+			}
+			pair.value = value
+			i = end + 1
+			if i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				return nil, errors.New("logfmt: text after a quoted value")
+			}
+		} else {
+			start = i
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				i++
+			}
+			pair.value = line[start:i]
+		}
+		pairs = append(pairs, pair)
+	}
+}
+
+// contains reports whether list holds s.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Ensure logfmtParser implements Parser
+var _ Parser = logfmtParser{}
@@ -0,0 +1,111 @@
+package ingest
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseLogfmt tests quoting, escapes and bare keys.
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []logfmtPair
+		wantErr bool
+	}{
+		{
+			line: `a=1 b="two words" c= d`,
+			want: []logfmtPair{{key: "a", value: "1"}, {key: "b", value: "two words"}, {key: "c"}, {key: "d", bare: true}},
+		},
+		{
+			line: `msg="say \"hi\"\n\tand\\leave" path=/a=b`,
+			want: []logfmtPair{{key: "msg", value: "say \"hi\"\n\tand\\leave"}, {key: "path", value: "/a=b"}},
+		},
+		{line: `  spaced=yes   `, want: []logfmtPair{{key: "spaced", value: "yes"}}},
+		{line: `msg="unterminated`, wantErr: true},
+		{line: `msg="closed"trailing`, wantErr: true},
+		{line: `=value`, wantErr: true},
+		{line: `ke"y=v`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseLogfmt(tt.line)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseLogfmt(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseLogfmt(%q) = %+v, want %+v", tt.line, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parseLogfmt(%q)[%d] = %+v, want %+v", tt.line, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+// TestLogfmtParser tests mapping pairs onto the entry.
+func TestLogfmtParser(t *testing.T) {
+	ts := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		line     string
+		ok       bool
+		want     LogEntry
+		metadata map[string]string
+	}{
+		{
+			name:     "prometheus",
+			line:     `ts=2026-10-16T12:00:00.000Z caller=main.go:42 level=warn component=tsdb msg="compaction failed" err="disk full"`,
+			ok:       true,
+			want:     LogEntry{Timestamp: ts, Level: LevelWarning, Message: "compaction failed"},
+			metadata: map[string]string{"caller": "main.go:42", "component": "tsdb", "err": "disk full"},
+		},
+		{
+			name:     "grafana short keys",
+			line:     `t=2026-10-16T12:00:00Z lvl=eror msg="Request error" logger=context`,
+			ok:       true,
+			want:     LogEntry{Timestamp: ts, Level: LevelError, Message: "Request error"},
+			metadata: map[string]string{"logger": "context"},
+		},
+		{
+			name:     "heroku router",
+			line:     `at=error code=H12 desc="Request timeout" method=GET path="/" dyno=web.1`,
+			ok:       true,
+			want:     LogEntry{Level: LevelError, Message: `at=error code=H12 desc="Request timeout" method=GET path="/" dyno=web.1`},
+			metadata: map[string]string{"code": "H12", "desc": "Request timeout", "method": "GET", "path": "/", "dyno": "web.1"},
+		},
+		{
+			name:     "duplicate keys and bare key",
+			line:     `time=1792152000 level=info msg=retry tag=a tag=b cached`,
+			ok:       true,
+			want:     LogEntry{Timestamp: ts, Level: LevelInfo, Message: "retry"},
+			metadata: map[string]string{"tag": "a, b", "cached": ""},
+		},
+		{name: "prose", line: "starting worker pool with size 4", ok: false},
+		{name: "prose with one pair", line: "retrying request to host=db1 after timeout", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := LogEntry{Message: tt.line, Metadata: map[string]string{"path": "app.log"}}
+			if ok := (logfmtParser{loc: time.Local}).Parse(tt.line, &entry); ok != tt.ok {
+				t.Fatalf("Parse() = %v, want %v", ok, tt.ok)
+			}
+			if !tt.ok {
+				return
+			}
+			if !entry.Timestamp.Equal(tt.want.Timestamp) || entry.Level != tt.want.Level || entry.Message != tt.want.Message {
+				t.Errorf("Parse() = %v %v %q, want %v %v %q",
+					entry.Timestamp, entry.Level, entry.Message, tt.want.Timestamp, tt.want.Level, tt.want.Message)
+			}
+			for key, value := range tt.metadata {
+				if got, ok := entry.Metadata[key]; !ok || got != value {
+					t.Errorf("Metadata[%s] = %q, want %q", key, got, value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package ingest
+
+import (
+	"testing"
+	"time"
+)
+
+// appPattern reads lines like "2026-10-16 12:00:00 WARN [api:4242] slow request id=7".
+const appPattern = `^(?P<timestamp>\S+ \S+) (?P<level>\w+) \[(?P<source>\w+):(?P<pid>\d+)\] (?P<message>.*?)(?: id=(?P<request_id>\d+))?$`
+
+// TestLineParserChain tests that the first parser to recognise a line
+// wins, and that unrecognised lines are kept whole.
+func TestLineParserChain(t *testing.T) {
+	p, err := newLineParser(SourceConfig{
+		Name:    "App",
+		Parsers: []ParserConfig{{Type: "regex", Pattern: appPattern}, {Type: "syslog"}},
+	})
+	if err != nil {
+		t.Fatalf("newLineParser() error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		line     string
+		source   string
+		level    LogLevel
+		message  string
+		metadata map[string]string
+		stamped  bool
+	}{
+		{
+			name: "regex", line: "2026-10-16 12:00:00 WARN [api:4242] slow request id=7",
+			source: "api", level: LevelWarning, message: "slow request",
+			metadata: map[string]string{"request_id": "7"}, stamped: true,
+		},
+		{
+			name: "regex without optional group", line: "2026-10-16 12:00:00 info [api:1] up",
+			source: "api", level: LevelInfo, message: "up", stamped: true,
+		},
+		{
+			name: "syslog fallback", line: "Oct 11 22:14:15 host sshd[230]: error: auth failed",
+			source: "App", level: LevelError, message: "error: auth failed",
+			metadata: map[string]string{"process": "sshd"}, stamped: true,
+		},
+		{
+			name: "neither", line: "panic: runtime error",
+			source: "App", level: LevelCritical, message: "panic: runtime error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.entry("/var/log/app.log", tt.line)
+			if got.Source != tt.source || got.Level != tt.level || got.Message != tt.message || got.Raw != tt.line {
+				t.Errorf("entry() = %s/%v/%q, want %s/%v/%q", got.Source, got.Level, got.Message, tt.source, tt.level, tt.message)
+			}
+			if got.Timestamp.IsZero() == tt.stamped {
+				t.Errorf("Timestamp = %v, want one parsed: %v", got.Timestamp, tt.stamped)
+			}
+			if got.Metadata["path"] != "/var/log/app.log" {
+				t.Errorf("Metadata[path] = %q", got.Metadata["path"])
+			}
+			for key, value := range tt.metadata {
+				if got.Metadata[key] != value {
+					t.Errorf("Metadata[%s] = %q, want %q", key, got.Metadata[key], value)
+				}
+			}
+		})
+	}
+
+	e := p.entry("", tests[0].line)
+	if e.PID != 4242 || !e.Timestamp.Equal(time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)) {
+		t.Errorf("regex entry PID = %d, Timestamp = %v", e.PID, e.Timestamp)
+	}
+}
+
+// TestValidateParsers tests the parser settings of a line source.
+func TestValidateParsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		parsers []ParserConfig
+		wantErr bool
+	}{
+		{"default", nil, false},
+		{"raw", []ParserConfig{{Type: "raw"}}, false},
+		{"logfmt then syslog", []ParserConfig{{Type: "logfmt"}, {Type: "syslog"}}, false},
+		{"web server logs", []ParserConfig{{Type: "access"}, {Type: "nginx_error"}, {Type: "haproxy"}}, false},
+		{"regex", []ParserConfig{{Type: "regex", Pattern: `(?P<message>.*)`}}, false},
+		{"json preset", []ParserConfig{{Type: "json", Preset: "bunyan"}}, false},
+		{"unknown parser", []ParserConfig{{Type: "xml"}}, true},
+		{"unknown json preset", []ParserConfig{{Type: "json", Preset: "log4j"}}, true},
+		{"regex without pattern", []ParserConfig{{Type: "regex"}}, true},
+		{"regex without named groups", []ParserConfig{{Type: "regex", Pattern: `(\w+) (.*)`}}, true},
+		{"bad regex", []ParserConfig{{Type: "regex", Pattern: `(?P<message>`}}, true},
+	}
+
+	for _, tt := range tests {
+		cfg := SourceConfig{Type: SourceFile, Path: "/var/log/app.log", Parsers: tt.parsers}
+		if err := Validate(cfg); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import "time"
+
+// Position records how far a source has read, so a later run can resume
+// without a gap. Journal sources use Cursor; file sources identify the
+// file by device and inode, since its name may have been rotated away.
+type Position struct {
+	Cursor string    `json:"cursor,omitempty"`
+	Dev    uint64    `json:"dev,omitempty"`
+	Inode  uint64    `json:"inode,omitempty"`
+	Offset int64     `json:"offset,omitempty"`
+	Time   time.Time `json:"time"` // When the position was recorded
+}
+
+// PositionStore keeps the last position of each source, keyed by name.
+// Save is called for every entry, so implementations should keep it
+// cheap and write to disk separately.
+type PositionStore interface {
+	Load(source string) (Position, bool)
+	Save(source string, pos Position)
+}
+
+// PositionTracker is implemented by sources that can record their
+// position. The store must be set before Start. A source configured with
+// Resume continues from its saved position instead of the current end.
+type PositionTracker interface {
+	TrackPosition(store PositionStore)
+}
+
+// position returns where the tailer has read up to, for the store.
+func (t *tailer) position() Position {
+	return Position{
+		Dev:    t.id.dev,
+		Inode:  t.id.ino,
+		Offset: t.offset,
+		Time:   time.Now(),
+	}
+}
@@ -0,0 +1,167 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memStore is an in-memory PositionStore for tests.
+type memStore struct {
+	mu        sync.Mutex
+	positions map[string]Position
+}
+
+func newMemStore() *memStore {
+	return &memStore{positions: make(map[string]Position)}
+}
+
+func (m *memStore) Load(source string) (Position, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pos, ok := m.positions[source]
+	return pos, ok
+}
+
+func (m *memStore) Save(source string, pos Position) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.positions[source] = pos
+}
+
+// runFile starts a file ingestor with store and returns its entries.
+func runFile(t *testing.T, cfg SourceConfig, store PositionStore) (*FileIngestor, chan LogEntry) {
+	t.Helper()
+	ing := NewFileIngestor(cfg)
+	ing.TrackPosition(store)
+
+	entries := make(chan LogEntry, 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := ing.Start(ctx, entries); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(func() { ing.Stop() })
+	return ing, entries
+}
+
+// TestFileIngestorResume tests catching up on lines written while no
+// ingestor was running, with and without a rotation in between.
+func TestFileIngestorResume(t *testing.T) {
+	tests := []struct {
+		name string
+		away func(t *testing.T, path string) // What happens while stopped
+		want []string
+	}{
+		{
+			name: "appended",
+			away: func(t *testing.T, path string) {
+				appendLine(t, path, "missed one")
+				appendLine(t, path, "missed two")
+			},
+			want: []string{"missed one", "missed two"},
+		},
+		{
+			name: "rotated",
+			away: func(t *testing.T, path string) {
+				appendLine(t, path, "missed before rotation")
+				rename(t, path, path+".1")
+				appendLine(t, path, "missed after rotation")
+			},
+			want: []string{"missed before rotation", "missed after rotation"},
+		},
+		{
+			name: "truncated",
+			away: func(t *testing.T, path string) {
+				if err := os.Truncate(path, 0); err != nil {
+					t.Fatal(err)
+				}
+				appendLine(t, path, "short")
+			},
+			want: []string{"short"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			appendLine(t, path, "already shown before the first run")
+			cfg := SourceConfig{Name: "App", Type: SourceFile, Path: path, Resume: true}
+			store := newMemStore()
+
+			// First run: reads one line, recording its position
+			first, entries := runFile(t, cfg, store)
+			appendLine(t, path, "seen by first run")
+			waitEntry(t, entries)
+			first.Stop()
+			waitFor(t, "first run to stop", func() bool { return !first.Healthy() })
+
+			tt.away(t, path)
+
+			// Second run picks up exactly where the first left off
+			_, entries = runFile(t, cfg, store)
+			var got []string
+			for range tt.want {
+				got = append(got, waitEntry(t, entries).Message)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("caught up %q, want %q", got, tt.want)
+			}
+
+			appendLine(t, path, "live")
+			if e := waitEntry(t, entries); e.Message != "live" {
+				t.Errorf("Message = %q, want live", e.Message)
+			}
+		})
+	}
+}
+
+// TestFileIngestorNoResume tests that without resume the saved position
+// is recorded but not used.
+func TestFileIngestorNoResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLine(t, path, "old")
+	store := newMemStore()
+	store.Save("App", Position{Inode: 1, Offset: 0})
+
+	_, entries := runFile(t, SourceConfig{Name: "App", Type: SourceFile, Path: path}, store)
+	appendLine(t, path, "new")
+	if e := waitEntry(t, entries); e.Message != "new" {
+		t.Errorf("Message = %q, want new", e.Message)
+	}
+	pos, _ := store.Load("App")
+	if info, _ := os.Stat(path); pos.Offset != info.Size() || pos.Inode == 1 {
+		t.Errorf("saved position = %+v, want offset %d of the real file", pos, info.Size())
+	}
+}
+
+// TestJournalResumeArgs tests that --after-cursor is only used when resuming.
+func TestJournalResumeArgs(t *testing.T) {
+	store := newMemStore()
+	store.Save("Journal", Position{Cursor: "s=1;i=2"})
+
+	tests := []struct {
+		name   string
+		resume bool
+		store  PositionStore
+		want   bool
+	}{
+		{"resume", true, store, true},
+		{"no resume", false, store, false},
+		{"no saved cursor", true, newMemStore(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewJournalIngestor(SourceConfig{Name: "Journal", Type: SourceJournald, Resume: tt.resume})
+			j.TrackPosition(tt.store)
+			args := strings.Join(j.args(true), " ")
+			if got := strings.Contains(args, "--after-cursor s=1;i=2"); got != tt.want {
+				t.Errorf("args() = %q, want --after-cursor: %v", args, tt.want)
+			}
+		})
+	}
+}
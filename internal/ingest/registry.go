@@ -0,0 +1,117 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory builds an ingestor from a validated source configuration.
+type Factory func(config SourceConfig) (Ingestor, error)
+
+// Validator checks a source configuration for type-specific errors.
+// It must not touch the filesystem or start anything.
+type Validator func(config SourceConfig) error
+
+// registration ties a source type to its name, constructor and validator.
+type registration struct {
+	name     string
+	factory  Factory
+	validate Validator
+}
+
+// The registry is filled from init() functions in each ingestor's file,
+// so config validation and ingestor creation always share one table.
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[SourceType]registration)
+)
+
+// Register makes a source type available to ParseSourceType, Validate and New.
+// It panics on duplicate registration, which is always a programming error.
+func Register(sourceType SourceType, name string, factory Factory, validate Validator) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[sourceType]; exists {
+		panic(fmt.Sprintf("ingest: source type %q registered twice", name))
+	}
+	registry[sourceType] = registration{
+		name:     name,
+		factory:  factory,
+		validate: validate,
+	}
+}
+
+// ParseSourceType converts a config type name (e.g. "journald") into a SourceType.
+func ParseSourceType(name string) (SourceType, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for st, reg := range registry {
+		if reg.name == name {
+			return st, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid type %q (must be %s)", name, joinOr(sourceTypeNamesLocked()))
+}
+
+// SourceTypeNames returns the registered type names in sorted order.
+func SourceTypeNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return sourceTypeNamesLocked()
+}
+
+func sourceTypeNamesLocked() []string {
+	names := make([]string, 0, len(registry))
+	for _, reg := range registry {
+		names = append(names, reg.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks a source configuration using its type's validator.
+func Validate(config SourceConfig) error {
+	registryMu.RLock()
+	reg, ok := registry[config.Type]
+	registryMu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("unsupported source type %s", config.Type)
+	}
+	if reg.validate == nil {
+		return nil
+	}
+	return reg.validate(config)
+}
+
+// New validates a source configuration and builds its ingestor.
+func New(config SourceConfig) (Ingestor, error) {
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
+
+	registryMu.RLock()
+	reg := registry[config.Type]
+	registryMu.RUnlock()
+
+	return reg.factory(config)
+}
+
+// joinOr formats ["a", "b", "c"] as "a, b, or c".
+func joinOr(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	case 2:
+		return items[0] + " or " + items[1]
+	default:
+		return strings.Join(items[:len(items)-1], ", ") + ", or " + items[len(items)-1]
+	}
+}
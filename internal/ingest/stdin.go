@@ -0,0 +1,91 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// StdinPath is what stdin entries record as their path.
+const StdinPath = "-"
+
+// stdin is read by stdin sources; tests replace it.
+var stdin io.Reader = os.Stdin
+
+// StdinIngestor reads lines piped into Argus (`make test 2>&1 | argus -`)
+// through the same parsing as a file source. The stream ending is not an
+// error: the lines read so far stay in view.
+type StdinIngestor struct {
+	config  SourceConfig
+	parser  *lineParser
+	mu      sync.Mutex
+	healthy bool
+	cancel  context.CancelFunc
+}
+
+func init() {
+	Register(SourceStdin, "stdin", func(config SourceConfig) (Ingestor, error) {
+		return NewStdinIngestor(config), nil
+	}, validateParsers)
+}
+
+// NewStdinIngestor creates an ingestor for standard input.
+func NewStdinIngestor(config SourceConfig) *StdinIngestor {
+	// Validated already
+	parser, _ := newLineParser(config)
+	return &StdinIngestor{config: config, parser: parser}
+}
+
+// Name returns the human-readable name of this source.
+func (s *StdinIngestor) Name() string {
+	return s.config.Name
+}
+
+// Healthy returns true if the ingestor is functioning normally.
+func (s *StdinIngestor) Healthy() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.healthy
+}
+
+func (s *StdinIngestor) setHealthy(healthy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthy = healthy
+}
+
+// Start reads standard input until it ends or the context is cancelled.
+func (s *StdinIngestor) Start(ctx context.Context, entries chan<- LogEntry) error {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.setHealthy(true)
+
+	lines := s.parser.newAssembler(func(entry LogEntry) bool {
+		return sendEntry(ctx, entries, entry)
+	})
+	go func() {
+		err := scanLines(stdin, func(line string) bool {
+			return lines.add(StdinPath, line, false)
+		})
+		// The last entry can't be continued once input has ended
+		lines.flush()
+		lines.stop()
+		if err != nil {
+			s.setHealthy(false)
+		}
+	}()
+	return nil
+}
+
+// Stop gracefully shuts down the ingestor. A read already waiting on
+// input finishes when the next line arrives, and is dropped.
+func (s *StdinIngestor) Stop() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
+}
+
+// Ensure StdinIngestor implements Ingestor
+var _ Ingestor = (*StdinIngestor)(nil)
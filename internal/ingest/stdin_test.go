@@ -0,0 +1,34 @@
+package ingest
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestStdinRead tests that piped lines are parsed like a file's.
+func TestStdinRead(t *testing.T) {
+	old := stdin
+	stdin = strings.NewReader("Oct 11 22:14:15 host app[42]: ERROR disk full\n\nbuild ok\n")
+	t.Cleanup(func() { stdin = old })
+
+	s := NewStdinIngestor(SourceConfig{Name: "stdin", Type: SourceStdin})
+	entries := make(chan LogEntry, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.Start(ctx, entries); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer s.Stop()
+
+	first, second := waitEntry(t, entries), waitEntry(t, entries)
+	if first.Hostname != "host" || first.Level != LevelError || first.Metadata["path"] != StdinPath {
+		t.Errorf("first = %+v", first)
+	}
+	if second.Message != "build ok" || second.SourceType != SourceStdin {
+		t.Errorf("second = %+v", second)
+	}
+	if !s.Healthy() {
+		t.Error("Healthy() = false after the input ended, want true")
+	}
+}
@@ -0,0 +1,375 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxSyslogMessage bounds one received message. UDP can't carry more, and
+// TCP senders that claim more are cut off.
+const maxSyslogMessage = 64 * 1024
+
+// SyslogIngestor receives syslog messages over UDP, TCP or a unix
+// datagram socket, so network devices and containers can log straight to
+// Argus.
+//
+// The listen address is a URL: "udp://0.0.0.0:514", "tcp://:6514" or
+// "unix:///run/argus/log.sock". On TCP each connection may frame
+// messages by octet counting ("LEN MSG", RFC 6587) or end them with a
+// newline; the framing is recognised per message.
+type SyslogIngestor struct {
+	config  SourceConfig
+	network string // "udp", "tcp" or "unixgram"
+	address string
+	loc     *time.Location // Zone of timestamps sent without one
+	levels  *levelRules
+
+	// conn is the UDP or unix datagram socket; listener the TCP one
+	conn     net.PacketConn
+	listener net.Listener
+	socket   os.FileInfo // The unix socket file this process created
+
+	mu      sync.Mutex
+	healthy bool
+	cancel  context.CancelFunc
+}
+
+func init() {
+	Register(SourceSyslog, "syslog", func(config SourceConfig) (Ingestor, error) {
+		return NewSyslogIngestor(config), nil
+	}, validateSyslog)
+}
+
+// validateSyslog checks the listen address and time zone.
+func validateSyslog(config SourceConfig) error {
+	if config.Listen == "" {
+		return fmt.Errorf("listen is required for type %s (e.g. udp://0.0.0.0:514)", config.Type)
+	}
+	if _, _, err := parseListen(config.Listen); err != nil {
+		return err
+	}
+	if _, err := sourceLocation(config); err != nil {
+		return err
+	}
+	return validateLevels(config)
+}
+
+// parseListen splits a listen URL into a network and address for net.
+func parseListen(listen string) (network, address string, err error) {
+	u, err := url.Parse(listen)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid listen address %q: %w", listen, err)
+	}
+
+	switch u.Scheme {
+	case "udp", "tcp":
+		if _, port, err := net.SplitHostPort(u.Host); err != nil || port == "" {
+			return "", "", fmt.Errorf("listen address %q needs a host:port", listen)
+		}
+		return u.Scheme, u.Host, nil
+	case "unix":
+		if !filepath.IsAbs(u.Path) || u.Host != "" {
+			return "", "", fmt.Errorf("listen address %q needs an absolute socket path (unix:///run/argus.sock)", listen)
+		}
+		return "unixgram", u.Path, nil
+	default:
+		return "", "", fmt.Errorf("listen address %q must start with udp://, tcp:// or unix://", listen)
+	}
+}
+
+// NewSyslogIngestor creates a syslog receiver.
+func NewSyslogIngestor(config SourceConfig) *SyslogIngestor {
+	// Validated already
+	network, address, _ := parseListen(config.Listen)
+	loc, _ := sourceLocation(config)
+	return &SyslogIngestor{config: config, network: network, address: address, loc: loc, levels: sourceLevelRules(config)}
+}
+
+// Name returns the human-readable name of this source.
+func (s *SyslogIngestor) Name() string {
+	return s.config.Name
+}
+
+// Healthy returns true if the ingestor is functioning normally.
+func (s *SyslogIngestor) Healthy() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.healthy
+}
+
+func (s *SyslogIngestor) setHealthy(healthy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthy = healthy
+}
+
+// Start listens on the configured address and sends every message
+// received until the context is cancelled.
+func (s *SyslogIngestor) Start(ctx context.Context, entries chan<- LogEntry) error {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	var err error
+	switch s.network {
+	case "tcp":
+		s.listener, err = net.Listen("tcp", s.address)
+	case "unixgram":
+		if err = clearStaleSocket(s.address); err != nil {
+			break
+		}
+		if s.conn, err = net.ListenPacket("unixgram", s.address); err == nil {
+			s.socket, _ = os.Lstat(s.address)
+		}
+	default:
+		s.conn, err = net.ListenPacket(s.network, s.address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.config.Listen, err)
+	}
+
+	s.setHealthy(true)
+
+	// Closing the socket ends the loops reading from it
+	go func() {
+		<-ctx.Done()
+		if s.listener != nil {
+			s.listener.Close()
+		}
+		if s.conn != nil {
+			s.conn.Close()
+		}
+		// Only the socket bound here; another process may have taken
+		// the path over since
+		if s.socket != nil {
+			if info, err := os.Lstat(s.address); err == nil && os.SameFile(info, s.socket) {
+				os.Remove(s.address)
+			}
+		}
+	}()
+
+	if s.listener != nil {
+		go s.acceptLoop(ctx, entries)
+	} else {
+		go s.packetLoop(ctx, entries)
+	}
+	return nil
+}
+
+// clearStaleSocket removes a unix socket left at path by a run that
+// didn't clean up, which would block the bind. A socket something still
+// receives on, such as the system logger's, is never touched: only one
+// that refuses a connection is stale.
+func clearStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return nil // Nothing there, or not a socket; the bind reports it
+	}
+
+	conn, err := net.Dial("unixgram", path)
+	switch {
+	case err == nil:
+		conn.Close()
+		return fmt.Errorf("%s is in use by another process", path)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return os.Remove(path)
+	default:
+		return nil // A stream socket, or no access; the bind reports it
+	}
+}
+
+// addr returns the address being listened on, once started.
+func (s *SyslogIngestor) addr() net.Addr {
+	if s.listener != nil {
+		return s.listener.Addr()
+	}
+	if s.conn != nil {
+		return s.conn.LocalAddr()
+	}
+	return nil
+}
+
+// packetLoop receives datagrams, one message each.
+func (s *SyslogIngestor) packetLoop(ctx context.Context, entries chan<- LogEntry) {
+	defer s.setHealthy(false)
+
+	buf := make([]byte, maxSyslogMessage)
+	for {
+		n, from, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				// A transient error (ICMP unreachable and the like)
+				continue
+			}
+			return
+		}
+		if !s.send(ctx, entries, string(buf[:n]), from) {
+			return
+		}
+	}
+}
+
+// acceptLoop accepts TCP connections and reads each in its own goroutine.
+func (s *SyslogIngestor) acceptLoop(ctx context.Context, entries chan<- LogEntry) {
+	defer s.setHealthy(false)
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		go func() {
+			defer conn.Close()
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			defer stop()
+			s.readStream(ctx, conn, entries)
+		}()
+	}
+}
+
+// readStream reads framed messages from one TCP connection until it is
+// closed.
+func (s *SyslogIngestor) readStream(ctx context.Context, conn net.Conn, entries chan<- LogEntry) {
+	r := bufio.NewReaderSize(conn, 64*1024)
+	for {
+		frame, err := readFrame(r)
+		if frame != "" && !s.send(ctx, entries, frame, conn.RemoteAddr()) {
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readFrame reads the next message from a stream. A frame starting with
+// a digit is octet counted ("57 <34>1 ..."); anything else runs to the
+// next newline. io.EOF is returned with the last unterminated message.
+func readFrame(r *bufio.Reader) (string, error) {
+	// Newlines between frames carry nothing
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if b != '\n' && b != '\r' {
+			r.UnreadByte()
+			break
+		}
+	}
+
+	if first, err := r.Peek(1); err == nil && first[0] >= '1' && first[0] <= '9' {
+		prefix, err := r.ReadString(' ')
+		if err != nil {
+			return "", err
+		}
+		length, err := strconv.Atoi(strings.TrimSuffix(prefix, " "))
+		if err != nil || length > maxSyslogMessage {
+			return "", fmt.Errorf("invalid syslog frame length %q", prefix)
+		}
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return "", err
+		}
+		return string(frame), nil
+	}
+
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		switch {
+		case len(line) > maxSyslogMessage:
+			return "", fmt.Errorf("syslog message over %d bytes", maxSyslogMessage)
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case err != nil:
+			return string(line), err
+		}
+		return string(line), nil
+	}
+}
+
+// send parses one message and sends it. A message that doesn't parse is
+// still shown, whole, rather than lost.
+func (s *SyslogIngestor) send(ctx context.Context, entries chan<- LogEntry, data string, from net.Addr) bool {
+	entry := s.toLogEntry(data, time.Now())
+	if from != nil && from.String() != "" {
+		entry.Metadata["remote"] = from.String()
+	}
+	return sendEntry(ctx, entries, entry)
+}
+
+// toLogEntry converts a received message into a LogEntry.
+func (s *SyslogIngestor) toLogEntry(data string, now time.Time) LogEntry {
+	entry := LogEntry{
+		Timestamp:    now,
+		Source:       s.config.Name,
+		IngestorName: s.config.Name,
+		SourceType:   SourceSyslog,
+		Level:        LevelUnknown,
+		Message:      strings.TrimRight(data, "\r\n\x00"),
+		Raw:          strings.TrimRight(data, "\r\n\x00"),
+		Metadata:     map[string]string{},
+	}
+
+	msg, err := parseSyslogMessage(data, now, s.loc)
+	if err != nil {
+		entry.Level = detectLevel(entry.Message, s.levels.names)
+	} else {
+		if msg.appName != "" {
+			entry.Source = msg.appName
+		}
+		msg.fill(&entry, s.levels.names)
+	}
+	s.levels.apply(&entry)
+	return entry
+}
+
+// fill copies a parsed message into an entry, taking the level of its
+// severity from the source's level names if they map it.
+func (msg syslogMessage) fill(entry *LogEntry, names LevelNames) {
+	if !msg.timestamp.IsZero() {
+		entry.Timestamp = msg.timestamp
+	}
+	entry.Level = names.priority(msg.severity)
+	entry.Message = msg.message
+	entry.Hostname = msg.hostname
+	entry.PID = parseInt(msg.procID)
+
+	entry.Metadata["facility"] = facilityName(msg.facility)
+	for key, value := range map[string]string{"appname": msg.appName, "procid": msg.procID, "msgid": msg.msgID} {
+		if value != "" {
+			entry.Metadata[key] = value
+		}
+	}
+	for key, value := range msg.structured {
+		entry.Metadata[key] = value
+	}
+}
+
+// Stop gracefully shuts down the ingestor.
+func (s *SyslogIngestor) Stop() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
+}
+
+// Ensure SyslogIngestor implements Ingestor
+var _ Ingestor = (*SyslogIngestor)(nil)
@@ -0,0 +1,190 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslogMessage is one message received by a syslog source, from either
+// RFC 5424 or the older BSD format of RFC 3164.
+type syslogMessage struct {
+	facility  int
+	severity  int
+	timestamp time.Time // Zero if the message carried none
+	hostname  string
+	appName   string
+	procID    string
+	msgID     string
+	message   string
+
+	// structured holds RFC 5424 structured data as "SD-ID.PARAM" keys
+	// ("timeQuality.isSynced"); an element without parameters is stored
+	// under its SD-ID alone
+	structured map[string]string
+}
+
+// rfc3164Layout is the BSD syslog timestamp; the day is space-padded.
+const rfc3164Layout = "Jan _2 15:04:05"
+
+// parseSyslogMessage parses a received message. The version digit after
+// the priority tells RFC 5424 apart; anything else is read as RFC 3164,
+// as leniently as senders in the wild require, with times that carry no
+// zone read in loc.
+func parseSyslogMessage(data string, now time.Time, loc *time.Location) (syslogMessage, error) {
+	data = strings.TrimRight(data, "\r\n\x00")
+
+	pri, rest, err := parsePRI(data)
+	if err != nil {
+		return syslogMessage{}, err
+	}
+	msg := syslogMessage{facility: pri >> 3, severity: pri & 7}
+
+	if strings.HasPrefix(rest, "1 ") {
+		return parseRFC5424(msg, rest[2:])
+	}
+	return parseRFC3164(msg, rest, now, loc), nil
+}
+
+// parsePRI splits the "<PRI>" prefix off a message.
+func parsePRI(data string) (int, string, error) {
+	end := strings.IndexByte(data, '>')
+	if !strings.HasPrefix(data, "<") || end < 2 || end > 4 {
+		return 0, "", fmt.Errorf("syslog message without a priority: %.40q", data)
+	}
+	pri, err := strconv.Atoi(data[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, "", fmt.Errorf("invalid syslog priority %q", data[1:end])
+	}
+	return pri, data[end+1:], nil
+}
+
+// parseRFC5424 parses what follows "<PRI>1 ":
+//
+//	TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+//
+// with "-" for each header field that is absent.
+func parseRFC5424(msg syslogMessage, rest string) (syslogMessage, error) {
+	var header [5]string
+	for i := range header {
+		field, tail, ok := strings.Cut(rest, " ")
+		if !ok {
+			return msg, errors.New("truncated RFC 5424 header")
+		}
+		if field != "-" {
+			header[i] = field
+		}
+		rest = tail
+	}
+
+	if header[0] != "" {
+		ts, err := time.Parse(time.RFC3339Nano, header[0])
+		if err != nil {
+			return msg, fmt.Errorf("invalid RFC 5424 timestamp %q", header[0])
+		}
+		msg.timestamp = ts
+	}
+	msg.hostname, msg.appName, msg.procID, msg.msgID = header[1], header[2], header[3], header[4]
+
+	switch {
+	case strings.HasPrefix(rest, "-"):
+		rest = rest[1:]
+	case strings.HasPrefix(rest, "["):
+		structured, tail, err := parseStructuredData(rest)
+		if err != nil {
+			return msg, err
+		}
+		msg.structured, rest = structured, tail
+	default:
+		return msg, errors.New("RFC 5424 message without structured data")
+	}
+
+	msg.message = strings.TrimPrefix(strings.TrimPrefix(rest, " "), "\ufeff")
+	return msg, nil
+}
+
+// parseStructuredData parses one or more "[SD-ID PARAM="value" ...]"
+// elements and returns what follows them. Values escape '"', '\' and
+// ']' with a backslash.
+func parseStructuredData(s string) (map[string]string, string, error) {
+	structured := make(map[string]string)
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+		end := strings.IndexAny(s, " ]")
+		if end <= 0 {
+			return nil, "", errors.New("invalid structured data element")
+		}
+		id := s[:end]
+		s = s[end:]
+		if strings.HasPrefix(s, "]") {
+			structured[id] = ""
+		}
+
+		for strings.HasPrefix(s, " ") {
+			s = s[1:]
+			name, tail, ok := strings.Cut(s, `="`)
+			if !ok || name == "" || strings.ContainsAny(name, ` ]"`) {
+				return nil, "", fmt.Errorf("invalid structured data parameter in [%s]", id)
+			}
+			value, tail, err := readSDValue(tail)
+			if err != nil {
+				return nil, "", fmt.Errorf("structured data [%s]: %w", id, err)
+			}
+			structured[id+"."+name] = value
+			s = tail
+		}
+
+		if !strings.HasPrefix(s, "]") {
+			return nil, "", fmt.Errorf("unterminated structured data element [%s]", id)
+		}
+		s = s[1:]
+	}
+	return structured, s, nil
+}
+
+// readSDValue reads a parameter value up to its closing quote.
+func readSDValue(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+				i++
+			}
+			b.WriteByte(s[i])
+		case '"':
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", errors.New("unterminated parameter value")
+}
+
+// parseRFC3164 parses what follows "<PRI>" in the BSD format:
+//
+//	Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG
+//
+// Local senders (the /dev/log convention) leave out the hostname, some
+// devices send no timestamp and rsyslog can send an RFC 3339 one;
+// whatever can't be recognised is left in the message.
+func parseRFC3164(msg syslogMessage, rest string, now time.Time, loc *time.Location) syslogMessage {
+	if ts, tail, ok := leadingTimestamp(rest, loc, now); ok {
+		msg.timestamp, rest = ts, tail
+
+		// A hostname is a single word not ending like a tag
+		if word, tail, ok := strings.Cut(rest, " "); ok && !strings.HasSuffix(word, ":") && !strings.Contains(word, "[") {
+			msg.hostname, rest = word, tail
+		}
+	}
+
+	// TAG[PID]: or TAG: ahead of the message
+	if m := syslogTagRegex.FindStringSubmatch(rest); m != nil {
+		msg.appName, msg.procID, rest = m[1], m[2], m[3]
+	}
+	msg.message = rest
+	return msg
+}
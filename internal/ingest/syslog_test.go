@@ -0,0 +1,321 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseSyslogMessage tests both message formats.
+func TestParseSyslogMessage(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		data    string
+		want    syslogMessage
+		wantErr bool
+	}{
+		{
+			name: "rfc5424",
+			data: `<165>1 2026-10-11T22:14:15.003Z mymachine.example.com evntslog 8710 ID47 [exampleSDID@32473 iut="3" eventSource="Application"] An application event`,
+			want: syslogMessage{
+				facility: 20, severity: 5, timestamp: time.Date(2026, 10, 11, 22, 14, 15, 3000000, time.UTC),
+				hostname: "mymachine.example.com", appName: "evntslog", procID: "8710", msgID: "ID47",
+				message: "An application event",
+				structured: map[string]string{
+					"exampleSDID@32473.iut": "3", "exampleSDID@32473.eventSource": "Application",
+				},
+			},
+		},
+		{
+			name: "rfc5424 nil fields and BOM",
+			data: "<34>1 - - su - - - \ufeff'su root' failed\n",
+			want: syslogMessage{facility: 4, severity: 2, appName: "su", message: "'su root' failed"},
+		},
+		{
+			name: "rfc5424 escaped and empty elements",
+			data: `<13>1 2026-10-11T22:14:15+02:00 host app - - [a x="q\"b\]c\\"][timeQuality]`,
+			want: syslogMessage{
+				facility: 1, severity: 5, timestamp: time.Date(2026, 10, 11, 20, 14, 15, 0, time.UTC),
+				hostname: "host", appName: "app",
+				structured: map[string]string{"a.x": `q"b]c\`, "timeQuality": ""},
+			},
+		},
+		{
+			name: "rfc3164",
+			data: "<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8",
+			want: syslogMessage{
+				facility: 4, severity: 2, timestamp: time.Date(2026, 10, 11, 22, 14, 15, 0, time.Local),
+				hostname: "mymachine", appName: "su", procID: "230",
+				message: "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			name: "rfc3164 local sender without hostname",
+			data: "<30>Oct  1 08:00:00 systemd[1]: Started cron.",
+			want: syslogMessage{
+				facility: 3, severity: 6, timestamp: time.Date(2026, 10, 1, 8, 0, 0, 0, time.Local),
+				appName: "systemd", procID: "1", message: "Started cron.",
+			},
+		},
+		{
+			name: "rfc3164 last year",
+			data: "<13>Dec 31 23:59:59 host app: bye",
+			want: syslogMessage{
+				facility: 1, severity: 5, timestamp: time.Date(2025, 12, 31, 23, 59, 59, 0, time.Local),
+				hostname: "host", appName: "app", message: "bye",
+			},
+		},
+		{
+			name: "rfc3164 just past new year",
+			data: "<13>Jan  1 00:00:00 host app: early",
+			want: syslogMessage{
+				facility: 1, severity: 5, timestamp: time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local),
+				hostname: "host", appName: "app", message: "early",
+			},
+		},
+		{
+			name: "rfc3164 with rfc3339 timestamp",
+			data: "<13>2026-10-16T11:59:59.250+02:00 host app[7]: hi",
+			want: syslogMessage{
+				facility: 1, severity: 5, timestamp: time.Date(2026, 10, 16, 9, 59, 59, 250000000, time.UTC),
+				hostname: "host", appName: "app", procID: "7", message: "hi",
+			},
+		},
+		{
+			name: "no timestamp",
+			data: "<187>link down on port 3",
+			want: syslogMessage{facility: 23, severity: 3, message: "link down on port 3"},
+		},
+		{name: "no priority", data: "hello", wantErr: true},
+		{name: "bad priority", data: "<999>hello", wantErr: true},
+		{name: "bad rfc5424 timestamp", data: "<13>1 yesterday host app - - - hi", wantErr: true},
+		{name: "unterminated structured data", data: `<13>1 - host app - - [a x="1" hi`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSyslogMessage(tt.data, now, time.Local)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSyslogMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.facility != tt.want.facility || got.severity != tt.want.severity ||
+				!got.timestamp.Equal(tt.want.timestamp) || got.hostname != tt.want.hostname ||
+				got.appName != tt.want.appName || got.procID != tt.want.procID ||
+				got.msgID != tt.want.msgID || got.message != tt.want.message {
+				t.Errorf("parseSyslogMessage() = %+v, want %+v", got, tt.want)
+			}
+			if len(got.structured) != len(tt.want.structured) {
+				t.Errorf("structured = %v, want %v", got.structured, tt.want.structured)
+			}
+			for key, value := range tt.want.structured {
+				if got.structured[key] != value {
+					t.Errorf("structured[%s] = %q, want %q", key, got.structured[key], value)
+				}
+			}
+		})
+	}
+}
+
+// TestReadFrame tests octet counting and newline framing on one stream.
+func TestReadFrame(t *testing.T) {
+	stream := "11 <13>1 - a b\n<13>second\r\n\n16 <13>with\nnewline<13>last"
+	r := bufio.NewReader(strings.NewReader(stream))
+
+	want := []string{"<13>1 - a b", "<13>second\r\n", "<13>with\nnewline", "<13>last"}
+	for i, w := range want {
+		got, err := readFrame(r)
+		if err != nil && i < len(want)-1 {
+			t.Fatalf("readFrame() %d error: %v", i, err)
+		}
+		if got != w {
+			t.Errorf("readFrame() %d = %q, want %q", i, got, w)
+		}
+	}
+	if _, err := readFrame(r); err == nil {
+		t.Error("readFrame() at end of stream should fail")
+	}
+
+	if _, err := readFrame(bufio.NewReader(strings.NewReader("99999999 <13>x"))); err == nil {
+		t.Error("readFrame() with an oversized length should fail")
+	}
+}
+
+// TestSyslogReceive tests receiving over each transport on loopback.
+func TestSyslogReceive(t *testing.T) {
+	rfc5424 := `<165>1 2026-10-11T22:14:15Z host app 42 ID47 [meta seq="2"] second`
+	octetCounted := fmt.Sprintf("%d %s", len(rfc5424), rfc5424)
+
+	tests := []struct {
+		name   string
+		listen string
+		send   []string // Written as is: datagrams, or one stream for TCP
+	}{
+		{"udp", "udp://127.0.0.1:0", []string{
+			"<34>Oct 11 22:14:15 router su[230]: first",
+			rfc5424,
+		}},
+		{"tcp", "tcp://127.0.0.1:0", []string{
+			"<34>Oct 11 22:14:15 router su[230]: first\n" + octetCounted,
+		}},
+		{"unix", "unix://" + filepath.Join(t.TempDir(), "log.sock"), []string{
+			"<34>Oct 11 22:14:15 router su[230]: first",
+			rfc5424,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := SourceConfig{Name: "Syslog", Type: SourceSyslog, Listen: tt.listen}
+			if err := validateSyslog(cfg); err != nil {
+				t.Fatalf("validateSyslog() error: %v", err)
+			}
+			s := NewSyslogIngestor(cfg)
+			entries := make(chan LogEntry, 10)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := s.Start(ctx, entries); err != nil {
+				t.Fatalf("Start() error: %v", err)
+			}
+			defer s.Stop()
+
+			addr := s.addr()
+			conn, err := net.Dial(addr.Network(), addr.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			for _, data := range tt.send {
+				if _, err := conn.Write([]byte(data)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.name == "tcp" {
+				// The newline-framed message ends with the connection
+				conn.Close()
+			}
+
+			first, second := waitEntry(t, entries), waitEntry(t, entries)
+			if first.Source != "su" || first.PID != 230 || first.Hostname != "router" ||
+				first.Level != LevelCritical || first.Message != "first" || first.Metadata["facility"] != "auth" {
+				t.Errorf("first = %+v", first)
+			}
+			if second.Source != "app" || second.Level != LevelNotice || second.Message != "second" ||
+				second.Metadata["msgid"] != "ID47" || second.Metadata["procid"] != "42" ||
+				second.Metadata["meta.seq"] != "2" || second.Metadata["facility"] != "local4" {
+				t.Errorf("second = %+v", second)
+			}
+		})
+	}
+}
+
+// TestSyslogValidate tests the listen address.
+func TestSyslogValidate(t *testing.T) {
+	tests := []struct {
+		listen  string
+		wantErr bool
+	}{
+		{"udp://0.0.0.0:514", false},
+		{"tcp://:6514", false},
+		{"unix:///run/argus/log.sock", false},
+		{"", true},
+		{"udp://0.0.0.0", true},
+		{"unix://relative.sock", true},
+		{"http://:80", true},
+	}
+
+	for _, tt := range tests {
+		err := validateSyslog(SourceConfig{Type: SourceSyslog, Listen: tt.listen})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateSyslog(%q) error = %v, wantErr %v", tt.listen, err, tt.wantErr)
+		}
+	}
+}
+
+// TestSyslogUnixSocket tests that a unix listener takes over a stale
+// socket but never one still in use, and on shutdown removes only the
+// socket it created.
+func TestSyslogUnixSocket(t *testing.T) {
+	dir := t.TempDir()
+	start := func(path string) (*SyslogIngestor, context.CancelFunc, error) {
+		s := NewSyslogIngestor(SourceConfig{Name: "Syslog", Type: SourceSyslog, Listen: "unix://" + path})
+		ctx, cancel := context.WithCancel(context.Background())
+		return s, cancel, s.Start(ctx, make(chan LogEntry, 10))
+	}
+	// waitGone waits for the shutdown goroutine to remove path, or not
+	waitGone := func(path string, want bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			_, err := os.Lstat(path)
+			if gone := os.IsNotExist(err); gone == want {
+				return
+			} else if time.Now().After(deadline) {
+				t.Fatalf("%s removed = %v, want %v", path, gone, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Another process's socket stays, and keeps receiving
+	live := filepath.Join(dir, "live.sock")
+	other, err := net.ListenPacket("unixgram", live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if _, cancel, err := start(live); err == nil || !strings.Contains(err.Error(), "in use") {
+		cancel()
+		t.Fatalf("Start() on a live socket error = %v, want in use", err)
+	}
+	conn, err := net.Dial("unixgram", live)
+	if err != nil {
+		t.Fatalf("the live socket was disturbed: %v", err)
+	}
+	conn.Close()
+
+	// A socket nothing receives on is taken over, and removed on stop
+	stale := filepath.Join(dir, "stale.sock")
+	old, err := net.ListenPacket("unixgram", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old.Close()
+	if _, err := os.Lstat(stale); err != nil {
+		t.Fatalf("closing left no socket behind to test with: %v", err)
+	}
+	s, cancel, err := start(stale)
+	if err != nil {
+		t.Fatalf("Start() on a stale socket error: %v", err)
+	}
+	s.Stop()
+	cancel()
+	waitGone(stale, true)
+
+	// A socket that replaced ours at the path is left alone
+	replaced := filepath.Join(dir, "replaced.sock")
+	s, cancel, err = start(replaced)
+	if err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	os.Remove(replaced)
+	newer, err := net.ListenPacket("unixgram", replaced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newer.Close()
+	s.Stop()
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	waitGone(replaced, false)
+}
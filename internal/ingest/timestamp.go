@@ -0,0 +1,216 @@
+// Package ingest provides log source ingestion capabilities.
+package ingest
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timestamps are read the same way whatever format a line is in: a
+// parser that finds a time field hands it to parseTimestamp, and a line
+// that gave no time to any parser is searched for one at its start by
+// leadingTimestamp. Times written without a zone are in the source's
+// location, its timezone setting or else local time, and syslog's
+// yearless dates are placed in the latest year that isn't ahead of now.
+
+// futureSkew is how far ahead of now a yearless date may fall and still
+// be taken as this year's: room for clocks that disagree a little, far
+// too little for last December to pass as this one.
+const futureSkew = 5 * time.Minute
+
+// timestampForm is one way of writing a timestamp: an expression finding
+// it at the start of a line and the layouts that read it.
+type timestampForm struct {
+	re       *regexp.Regexp
+	layouts  []string
+	yearless bool
+}
+
+// timestampForms are the timestamp forms recognised, tried in order.
+// Fractional seconds are accepted after any of them, with a period or a
+// comma, since time.Parse reads them even where a layout doesn't say so.
+var timestampForms = []timestampForm{
+	// ISO 8601 and RFC 3339, with a T or a space, with or without a zone:
+	// 2026-10-16T12:00:00.123Z, 2026-10-16 12:00:00,123 (Python, log4j)
+	{
+		re: regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}(?::?\d{2})?)?`),
+		layouts: []string{
+			"2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05Z0700", "2006-01-02T15:04:05Z07", "2006-01-02T15:04:05",
+			"2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05Z0700", "2006-01-02 15:04:05Z07", "2006-01-02 15:04:05",
+		},
+	},
+	// Go's log package and nginx: 2026/10/16 12:00:00.123456
+	{
+		re:      regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(?:[.,]\d+)?`),
+		layouts: []string{"2006/01/02 15:04:05"},
+	},
+	// Common Log Format: 16/Oct/2026:12:00:00 +0200
+	{
+		re:      regexp.MustCompile(`^\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?: [+-]\d{4})?`),
+		layouts: []string{clfLayout, "02/Jan/2006:15:04:05"},
+	},
+	// Tomcat and Java's default formats: 16-Oct-2026 12:00:00.123
+	{
+		re:      regexp.MustCompile(`^\d{2}-[A-Z][a-z]{2}-\d{4} \d{2}:\d{2}:\d{2}(?:[.,]\d+)?`),
+		layouts: []string{"02-Jan-2006 15:04:05"},
+	},
+	// ctime(3), date(1) and Apache's error log: Fri Oct 16 12:00:00 [UTC] 2026
+	{
+		re:      regexp.MustCompile(`^[A-Z][a-z]{2} [A-Z][a-z]{2} [ \d]?\d \d{2}:\d{2}:\d{2}(?:\.\d+)?(?: [A-Z]{3,5})? \d{4}`),
+		layouts: []string{time.ANSIC, time.UnixDate},
+	},
+	// BSD syslog, which has no year: Oct 16 12:00:00
+	{
+		re:       regexp.MustCompile(`^[A-Z][a-z]{2} [ \d]?\d \d{2}:\d{2}:\d{2}(?:\.\d+)?`),
+		layouts:  []string{rfc3164Layout},
+		yearless: true,
+	},
+}
+
+// epochRegex matches a Unix time at the start of a line: ten digits of
+// seconds (2001 to 2286) with any fraction, or whole milliseconds,
+// microseconds or nanoseconds. Shorter numbers are too likely to be
+// something else.
+var epochRegex = regexp.MustCompile(`^(?:\d{10}(?:\.\d{1,9})?|\d{13}|\d{16}|\d{19})\b`)
+
+// leadingTimestamp reads a timestamp at the start of line, optionally in
+// square brackets, and returns it with the rest of the line. Times
+// without a zone are read in loc, and a yearless date gets the year that
+// puts it closest before now.
+func leadingTimestamp(line string, loc *time.Location, now time.Time) (time.Time, string, bool) {
+	text := line
+	bracketed := strings.HasPrefix(text, "[")
+	if bracketed {
+		text = text[1:]
+	}
+
+	ts, n, ok := matchTimestamp(text, loc, now)
+	if !ok {
+		return time.Time{}, line, false
+	}
+	rest := text[n:]
+	if bracketed {
+		var closed bool
+		if rest, closed = strings.CutPrefix(rest, "]"); !closed {
+			return time.Time{}, line, false
+		}
+	}
+	return ts, strings.TrimLeft(rest, " \t"), true
+}
+
+// matchTimestamp reads a timestamp at the start of text, returning its
+// length.
+func matchTimestamp(text string, loc *time.Location, now time.Time) (time.Time, int, bool) {
+	for _, form := range timestampForms {
+		match := form.re.FindString(text)
+		if match == "" {
+			continue
+		}
+		for _, layout := range form.layouts {
+			ts, err := time.ParseInLocation(layout, match, loc)
+			if err != nil {
+				continue
+			}
+			if form.yearless {
+				ts = inferYear(ts, now)
+			}
+			return ts, len(match), true
+		}
+	}
+
+	match := epochRegex.FindString(text)
+	if match == "" {
+		return time.Time{}, 0, false
+	}
+	// Whole numbers are converted exactly; a float can't hold nanoseconds
+	if n, err := strconv.ParseInt(match, 10, 64); err == nil {
+		switch len(match) {
+		case 19:
+			return time.Unix(0, n), len(match), true
+		case 16:
+			return time.UnixMicro(n), len(match), true
+		case 13:
+			return time.UnixMilli(n), len(match), true
+		default:
+			return time.Unix(n, 0), len(match), true
+		}
+	}
+	n, _ := strconv.ParseFloat(match, 64) // Digits and a fraction
+	return epochTime(n), len(match), true
+}
+
+// inferYear dates a timestamp read without a year. It takes the latest
+// year that doesn't put it more than futureSkew ahead of now, so December
+// lines read in January land in the year they were written, and a line
+// just past midnight on New Year's Eve may still be next year's. Feb 29
+// goes back to the last leap year.
+func inferYear(ts, now time.Time) time.Time {
+	limit := now.Add(futureSkew)
+	for year := now.Year() + 1; ; year-- {
+		t := time.Date(year, ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(), ts.Location())
+		if t.Day() == ts.Day() && !t.After(limit) {
+			return t
+		}
+	}
+}
+
+// parseTimestamp reads a time field holding a timestamp and nothing
+// else, in any of the forms leadingTimestamp knows or RFC 1123's.
+func parseTimestamp(value string, loc *time.Location) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if ts, rest, ok := leadingTimestamp(value, loc, time.Now()); ok && rest == "" {
+		return ts, true
+	}
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC850} {
+		if ts, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// fieldTimestamp reads a time field given as text: a timestamp, or a
+// Unix epoch number.
+func fieldTimestamp(value string, loc *time.Location) (time.Time, bool) {
+	if ts, ok := parseTimestamp(value, loc); ok {
+		return ts, true
+	}
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		return epochTime(n), true
+	}
+	return time.Time{}, false
+}
+
+// epochTime converts a Unix time in whichever unit it seems to be in.
+// Seconds reach 1e11 only in the year 5138, so anything larger is a
+// finer unit.
+func epochTime(n float64) time.Time {
+	switch abs := math.Abs(n); {
+	case abs >= 1e17:
+		return time.Unix(0, int64(n))
+	case abs >= 1e14:
+		return time.UnixMicro(int64(n))
+	case abs >= 1e11:
+		return time.UnixMilli(int64(n))
+	default:
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(math.Round(frac*1e9)))
+	}
+}
+
+// sourceLocation returns the zone a source's timestamps are in when they
+// don't say: its timezone setting ("UTC", "Europe/Berlin"), or local time.
+func sourceLocation(config SourceConfig) (*time.Location, error) {
+	if config.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(config.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("timezone: %w", err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,140 @@
+package ingest
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseTimestamp tests the timestamp forms a parsed field may take.
+func TestParseTimestamp(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no zoneinfo: %v", err)
+	}
+
+	tests := []struct {
+		value string
+		loc   *time.Location
+		want  time.Time
+		ok    bool
+	}{
+		{"2026-10-16T12:00:00.5Z", time.Local, time.Date(2026, 10, 16, 12, 0, 0, 500000000, time.UTC), true},
+		{"2026-10-16T12:00:00.123456789+02:00", time.Local, time.Date(2026, 10, 16, 10, 0, 0, 123456789, time.UTC), true},
+		{"2026-10-16T12:00:00+0200", time.Local, time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), true},
+		{"2026-10-16 12:00:00", time.Local, time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local), true},
+		{"2026-10-16 12:00:00", berlin, time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), true},
+		{"2026-10-16 12:00:00,250", time.UTC, time.Date(2026, 10, 16, 12, 0, 0, 250000000, time.UTC), true},
+		{"2026/10/16 12:00:00", time.UTC, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), true},
+		{"16/Oct/2026:12:00:00 +0200", time.UTC, time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), true},
+		{"16-Oct-2026 12:00:00.125", time.UTC, time.Date(2026, 10, 16, 12, 0, 0, 125000000, time.UTC), true},
+		{"Fri Oct 16 12:00:00 2026", time.UTC, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), true},
+		{"Fri, 16 Oct 2026 12:00:00 +0200", time.UTC, time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), true},
+		{"1792152000", time.Local, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), true},
+		{"1792152000.25", time.Local, time.Date(2026, 10, 16, 12, 0, 0, 250000000, time.UTC), true},
+		{"1792152000123", time.Local, time.Date(2026, 10, 16, 12, 0, 0, 123000000, time.UTC), true},
+		{"1792152000123456", time.Local, time.Date(2026, 10, 16, 12, 0, 0, 123456000, time.UTC), true},
+		{"1792152000123456789", time.Local, time.Date(2026, 10, 16, 12, 0, 0, 123456789, time.UTC), true},
+		{"2026-10-16 12:00:00 and more", time.Local, time.Time{}, false},
+		{"12345", time.Local, time.Time{}, false},
+		{"yesterday", time.Local, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseTimestamp(tt.value, tt.loc)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("parseTimestamp(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestLeadingTimestamp tests finding a timestamp at the start of a line.
+func TestLeadingTimestamp(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		line string
+		want time.Time
+		rest string
+		ok   bool
+	}{
+		{"2026-10-16 11:00:00,123 ERROR db: gone", time.Date(2026, 10, 16, 11, 0, 0, 123000000, time.UTC), "ERROR db: gone", true},
+		{"[2026-10-16T11:00:00Z] started", time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC), "started", true},
+		{"[Fri Oct 16 11:00:00.5 2026] [core:error] oops", time.Date(2026, 10, 16, 11, 0, 0, 500000000, time.UTC), "[core:error] oops", true},
+		{"Oct 16 11:00:00 host app: hi", time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC), "host app: hi", true},
+		{"Dec 31 23:00:00 late", time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC), "late", true},
+		{"1792148400.5 tick", time.Date(2026, 10, 16, 11, 0, 0, 500000000, time.UTC), "tick", true},
+		{"[2026-10-16T11:00:00Z started", time.Time{}, "", false},
+		{"200 OK", time.Time{}, "", false},
+		{"12345678901234 id", time.Time{}, "", false},
+		{"plain text", time.Time{}, "", false},
+	}
+
+	for _, tt := range tests {
+		got, rest, ok := leadingTimestamp(tt.line, time.UTC, now)
+		if ok != tt.ok || !got.Equal(tt.want) || (ok && rest != tt.rest) {
+			t.Errorf("leadingTimestamp(%q) = %v, %q, %v; want %v, %q, %v", tt.line, got, rest, ok, tt.want, tt.rest, tt.ok)
+		}
+	}
+}
+
+// TestInferYear tests that yearless dates never land in the future.
+func TestInferYear(t *testing.T) {
+	date := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		ts   time.Time // As parsed, in year 0
+		now  time.Time
+		want time.Time
+	}{
+		{"earlier today", date(0, 10, 16, 11, 0), date(2026, 10, 16, 12, 0), date(2026, 10, 16, 11, 0)},
+		{"december read in january", date(0, 12, 31, 23, 59), date(2027, 1, 1, 0, 10), date(2026, 12, 31, 23, 59)},
+		{"an hour ahead is last year", date(0, 10, 16, 13, 0), date(2026, 10, 16, 12, 0), date(2025, 10, 16, 13, 0)},
+		{"skewed clock", date(0, 10, 16, 12, 2), date(2026, 10, 16, 12, 0), date(2026, 10, 16, 12, 2)},
+		{"new year on a fast clock", date(0, 1, 1, 0, 1), date(2026, 12, 31, 23, 59), date(2027, 1, 1, 0, 1)},
+		{"leap day", date(0, 2, 29, 12, 0), date(2027, 3, 1, 0, 0), date(2024, 2, 29, 12, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferYear(tt.ts, tt.now); !got.Equal(tt.want) {
+				t.Errorf("inferYear() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSourceTimezone tests that a source's timezone applies to zoneless
+// timestamps and is checked at validation.
+func TestSourceTimezone(t *testing.T) {
+	if _, err := time.LoadLocation("America/New_York"); err != nil {
+		t.Skipf("no zoneinfo: %v", err)
+	}
+
+	p, err := newLineParser(SourceConfig{Name: "app", Timezone: "America/New_York"})
+	if err != nil {
+		t.Fatalf("newLineParser() error: %v", err)
+	}
+	want := time.Date(2026, 10, 16, 16, 0, 0, 0, time.UTC) // EDT is UTC-4
+	for _, line := range []string{
+		"2026-10-16T12:00:00 host app: hi",
+		"<13>2026-10-16T12:00:00 host app: hi",
+		"2026-10-16 12:00:00 WARN plain app line",
+	} {
+		if got := p.entry("", line).Timestamp; !got.Equal(want) {
+			t.Errorf("entry(%q).Timestamp = %v, want %v", line, got, want)
+		}
+	}
+	if got := p.entry("", "2026-10-16 12:00:00 WARN plain app line").Message; got != "WARN plain app line" {
+		t.Errorf("Message = %q, want the timestamp left out", got)
+	}
+
+	if err := validateParsers(SourceConfig{Timezone: "Mars/Olympus_Mons"}); err == nil {
+		t.Error("validateParsers() accepted an unknown timezone")
+	}
+	if err := validateSyslog(SourceConfig{Listen: "udp://:514", Timezone: "Mars/Olympus_Mons"}); err == nil {
+		t.Error("validateSyslog() accepted an unknown timezone")
+	}
+}
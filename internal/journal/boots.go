@@ -0,0 +1,82 @@
+package journal
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Boot is one boot's span in the journal, as journalctl --list-boots
+// shows it.
+type Boot struct {
+	ID    [16]byte
+	First time.Time // First entry logged during the boot
+	Last  time.Time // Last entry logged during the boot
+}
+
+// IDString returns the boot ID in journalctl's 32-digit hex form.
+func (b Boot) IDString() string {
+	return hex.EncodeToString(b.ID[:])
+}
+
+// Boots lists the boots with entries in the open files, oldest first.
+// Only entry objects are read, not their data, and the position Next
+// reads from is unchanged.
+func (d *Dir) Boots() ([]Boot, error) {
+	spans := make(map[[16]byte]*Boot)
+	for _, f := range d.files {
+		// A second reader over the same file keeps f's position intact
+		scan := &File{path: f.path, file: f.file, header: f.header}
+		for scan.read < scan.header.NEntries {
+			offset, err := scan.nextEntryOffset()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Path(), err)
+			}
+			scan.read++
+
+			boot, realtime, err := scan.entryBoot(offset)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Path(), err)
+			}
+			span, ok := spans[boot]
+			if !ok {
+				spans[boot] = &Boot{ID: boot, First: realtime, Last: realtime}
+				continue
+			}
+			if realtime.Before(span.First) {
+				span.First = realtime
+			}
+			if realtime.After(span.Last) {
+				span.Last = realtime
+			}
+		}
+	}
+
+	boots := make([]Boot, 0, len(spans))
+	for _, span := range spans {
+		boots = append(boots, *span)
+	}
+	sort.Slice(boots, func(i, j int) bool {
+		return boots[i].First.Before(boots[j].First)
+	})
+	return boots, nil
+}
+
+// ResolveBoot finds a boot by journalctl's offset rules: 0 is the last
+// boot, -1 the one before it, and 1 the first boot in the journal.
+func ResolveBoot(boots []Boot, offset int) (Boot, error) {
+	i := len(boots) - 1 + offset
+	if offset > 0 {
+		i = offset - 1
+	}
+	if i < 0 || i >= len(boots) {
+		return Boot{}, fmt.Errorf("no boot at offset %d (the journal has %d)", offset, len(boots))
+	}
+	return boots[i], nil
+}
@@ -0,0 +1,135 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// earlierBoot is patched into fixture entries to fake a previous boot.
+var earlierBoot = [16]byte{0xaa, 0xbb, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}
+
+// patchBoot rewrites the boot ID of the first n entries of a journal file.
+func patchBoot(t *testing.T, path string, n int, boot [16]byte) {
+	t.Helper()
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var offsets []uint64
+	for i := 0; i < n; i++ {
+		offset, err := f.nextEntryOffset()
+		if err != nil {
+			t.Fatal(err)
+		}
+		offsets = append(offsets, offset)
+	}
+	f.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, offset := range offsets {
+		copy(data[offset+40:], boot[:])
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBoots tests listing boots and resolving journalctl's offsets.
+func TestBoots(t *testing.T) {
+	dir := t.TempDir()
+	copyFixture(t, "regular", dir, "system.journal")
+	patchBoot(t, filepath.Join(dir, "system.journal"), 3, earlierBoot)
+
+	d, err := OpenDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	boots, err := d.Boots()
+	if err != nil {
+		t.Fatalf("Boots() error: %v", err)
+	}
+	if len(boots) != 2 || boots[0].ID != earlierBoot {
+		t.Fatalf("Boots() = %v, want the patched boot first", boots)
+	}
+	if !boots[0].Last.Before(boots[1].First) {
+		t.Errorf("boot spans overlap: %v", boots)
+	}
+
+	// Listing boots doesn't move the reader
+	entries, _ := readAll(t, d)
+	if len(entries) != 8 {
+		t.Errorf("read %d entries after Boots(), want 8", len(entries))
+	}
+
+	tests := []struct {
+		offset int
+		want   int // Index into boots, or -1 for an error
+	}{
+		{0, 1},
+		{-1, 0},
+		{-2, -1},
+		{1, 0},
+		{2, 1},
+		{3, -1},
+	}
+	for _, tt := range tests {
+		got, err := ResolveBoot(boots, tt.offset)
+		switch {
+		case tt.want < 0 && err == nil:
+			t.Errorf("ResolveBoot(%d) = %s, want error", tt.offset, got.IDString())
+		case tt.want >= 0 && (err != nil || got.ID != boots[tt.want].ID):
+			t.Errorf("ResolveBoot(%d) = %s, %v; want boot %d", tt.offset, got.IDString(), err, tt.want)
+		}
+	}
+}
+
+// TestOpenNamespace tests that namespaces keep to their own directories.
+func TestOpenNamespace(t *testing.T) {
+	root := t.TempDir()
+	copyFixture(t, "regular", filepath.Join(root, "fed6b2924c424cf1b9a322f606b4de6d"), "system.journal")
+	copyFixture(t, "compact", filepath.Join(root, "fed6b2924c424cf1b9a322f606b4de6d.audit"), "system.journal")
+
+	tests := []struct {
+		namespace string
+		want      string // Fixture whose entries are read
+	}{
+		{"", "regular"},
+		{"audit", "compact"},
+	}
+	for _, tt := range tests {
+		d, err := OpenNamespace(tt.namespace, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, _ := readAll(t, d)
+		d.Close()
+
+		golden := readGolden(t, tt.want)
+		if len(entries) != len(golden) || entries[0].Cursor() != golden[0]["__CURSOR"] {
+			t.Errorf("namespace %q read %d entries, want the %d of %s", tt.namespace, len(entries), len(golden), tt.want)
+		}
+	}
+}
+
+// TestOpenFiles tests reading named files as one stream.
+func TestOpenFiles(t *testing.T) {
+	d, err := OpenFiles(filepath.Join("testdata", "regular.journal"), filepath.Join("testdata", "compact.journal"))
+	if err != nil {
+		t.Fatalf("OpenFiles() error: %v", err)
+	}
+	entries, _ := readAll(t, d)
+	d.Close()
+	if len(entries) != 16 {
+		t.Errorf("read %d entries, want 16", len(entries))
+	}
+
+	if _, err := OpenFiles(filepath.Join("testdata", "regular.json")); err == nil {
+		t.Error("OpenFiles() of a non-journal file should fail")
+	}
+}
@@ -0,0 +1,306 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultDirs are where journald keeps persistent and volatile journals.
+var DefaultDirs = []string{"/var/log/journal", "/run/log/journal"}
+
+// Dir reads the journal files in one or more directories as a single
+// stream, interleaving them by time like journalctl does. A directory
+// may hold journal files directly (copied from another machine) or in
+// per-machine subdirectories, as /var/log/journal does.
+//
+// journald archives a full file by renaming it and starting a new one.
+// Refresh picks up new files; files are told apart by the file ID in
+// their header, so a renamed file that is already open isn't read twice.
+type Dir struct {
+	dirs      []string
+	paths     []string // Single files opened with OpenFiles
+	namespace string
+	files     []*dirFile
+	known     map[[16]byte]bool // File IDs already opened
+	skipped   []string          // Unreadable files, for Skipped
+}
+
+// dirFile is an open file plus the next entry it will return.
+type dirFile struct {
+	*File
+	next *Entry
+	err  error // Error to return alongside next
+}
+
+// OpenDir opens every journal file of the default namespace in dirs.
+// Directories that don't exist are ignored, but at least one must.
+func OpenDir(dirs ...string) (*Dir, error) {
+	return OpenNamespace("", dirs...)
+}
+
+// OpenNamespace opens the journal files of a journald namespace, kept in
+// "<machine-id>.<namespace>" subdirectories of dirs.
+func OpenNamespace(namespace string, dirs ...string) (*Dir, error) {
+	d := &Dir{dirs: dirs, namespace: namespace, known: make(map[[16]byte]bool)}
+
+	found := false
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no journal directory in %s", strings.Join(dirs, ", "))
+	}
+
+	if err := d.Refresh(); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// OpenFiles reads the given journal files as a single stream.
+func OpenFiles(paths ...string) (*Dir, error) {
+	d := &Dir{paths: paths, known: make(map[[16]byte]bool)}
+	if err := d.Refresh(); err != nil {
+		d.Close()
+		return nil, err
+	}
+	if len(d.files) == 0 {
+		return nil, fmt.Errorf("no readable journal file in %s", strings.Join(paths, ", "))
+	}
+	return d, nil
+}
+
+// journalFiles lists the *.journal files in dir and in its per-machine
+// subdirectories for namespace. Files journald set aside as corrupt end
+// in .journal~ and are left out.
+func journalFiles(dir, namespace string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.journal"))
+	if err != nil {
+		return nil, err
+	}
+	subdirs, err := filepath.Glob(filepath.Join(dir, "*", "*.journal"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range subdirs {
+		// "<machine-id>" holds the default namespace, "<machine-id>.<ns>" the others
+		_, ns, _ := strings.Cut(filepath.Base(filepath.Dir(path)), ".")
+		if ns == namespace {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Refresh opens journal files that appeared since the last call.
+func (d *Dir) Refresh() error {
+	candidates := append([]string(nil), d.paths...)
+	for _, dir := range d.dirs {
+		paths, err := journalFiles(dir, d.namespace)
+		if err != nil {
+			return err
+		}
+		candidates = append(candidates, paths...)
+	}
+
+	for _, path := range candidates {
+		if d.HasFile(path) {
+			continue
+		}
+		f, err := Open(path)
+		if err != nil {
+			// A file being created may not have its header yet
+			if !errors.Is(err, ErrNotJournal) {
+				d.skip(path)
+			}
+			continue
+		}
+		id := f.Header().FileID
+		if d.known[id] {
+			f.Close()
+			continue
+		}
+		d.known[id] = true
+		d.files = append(d.files, &dirFile{File: f})
+	}
+	return nil
+}
+
+// HasFile reports whether path is open under that name.
+func (d *Dir) HasFile(path string) bool {
+	for _, f := range d.files {
+		if f.Path() == path {
+			return true
+		}
+	}
+	return false
+}
+
+// skip records an unreadable file once.
+func (d *Dir) skip(path string) {
+	for _, p := range d.skipped {
+		if p == path {
+			return
+		}
+	}
+	d.skipped = append(d.skipped, path)
+}
+
+// Files returns the paths of the open journal files, in the order they
+// were opened. A file renamed by journald keeps its original path here.
+func (d *Dir) Files() []string {
+	paths := make([]string, len(d.files))
+	for i, f := range d.files {
+		paths[i] = f.Path()
+	}
+	return paths
+}
+
+// Skipped returns the journal files that couldn't be opened, typically
+// for lack of permission.
+func (d *Dir) Skipped() []string {
+	return d.skipped
+}
+
+// Next returns the oldest unread entry across all files, or io.EOF when
+// every file is read to its end. Like File.Next, a later call returns
+// entries written in the meantime.
+//
+// Entries with compressed fields come with a *CompressionError; other
+// errors mean a file is damaged, and it is dropped from the merge.
+func (d *Dir) Next() (*Entry, error) {
+	var oldest *dirFile
+	for i := 0; i < len(d.files); i++ {
+		f := d.files[i]
+		if f.next == nil {
+			f.next, f.err = f.File.Next()
+			if f.next == nil && f.err != nil && !errors.Is(f.err, io.EOF) {
+				err := fmt.Errorf("%s: %w", f.Path(), f.err)
+				d.drop(i)
+				return nil, err
+			}
+		}
+		if f.next != nil && (oldest == nil || before(f.next, oldest.next)) {
+			oldest = f
+		}
+	}
+	if oldest == nil {
+		return nil, io.EOF
+	}
+
+	entry, err := oldest.next, oldest.err
+	oldest.next, oldest.err = nil, nil
+	return entry, err
+}
+
+// before orders entries by wall-clock time, then by sequence number for
+// entries logged within the same microsecond.
+func before(a, b *Entry) bool {
+	if !a.Realtime.Equal(b.Realtime) {
+		return a.Realtime.Before(b.Realtime)
+	}
+	return a.Seqnum < b.Seqnum
+}
+
+// drop closes and forgets the file at index i. Its file ID stays known
+// so it isn't reopened.
+func (d *Dir) drop(i int) {
+	d.skip(d.files[i].Path())
+	d.files[i].Close()
+	d.files = append(d.files[:i], d.files[i+1:]...)
+}
+
+// SeekRealtime makes Next skip entries logged before t. Files whose last
+// entry is older than t are skipped without being decoded.
+func (d *Dir) SeekRealtime(t time.Time) {
+	usec := uint64(t.UnixMicro())
+	for _, f := range d.files {
+		if f.next == nil && f.header.TailEntryRealtime < usec {
+			_ = f.skipAll()
+		}
+	}
+	d.skipUntil(func(e *Entry) bool { return e.Realtime.Before(t) })
+}
+
+// SeekCursor makes Next continue after the entry cursor points to, as
+// returned by Entry.Cursor or journalctl. Entries from the cursor's
+// sequence are compared by sequence number, others by time.
+func (d *Dir) SeekCursor(cursor string) error {
+	c, err := parseCursor(cursor)
+	if err != nil {
+		return err
+	}
+	usec := uint64(c.realtime.UnixMicro())
+	for _, f := range d.files {
+		h := f.header
+		stale := h.TailEntryRealtime < usec
+		if h.SeqnumID == c.seqnumID {
+			stale = h.TailEntrySeqnum <= c.seqnum
+		}
+		if f.next == nil && stale {
+			_ = f.skipAll()
+		}
+	}
+	d.skipUntil(func(e *Entry) bool {
+		if e.SeqnumID == c.seqnumID {
+			return e.Seqnum <= c.seqnum
+		}
+		return !e.Realtime.After(c.realtime)
+	})
+	return nil
+}
+
+// skipUntil reads past entries of the open files for which skip is
+// true. Files are sorted by time, so each stops at its first keeper.
+func (d *Dir) skipUntil(skip func(*Entry) bool) {
+	for i := 0; i < len(d.files); i++ {
+		f := d.files[i]
+		for f.next == nil {
+			e, err := f.File.Next()
+			if e == nil {
+				if err != nil && !errors.Is(err, io.EOF) {
+					d.drop(i)
+					i--
+				}
+				break
+			}
+			if !skip(e) {
+				f.next, f.err = e, err
+			}
+		}
+	}
+}
+
+// Tail positions every file at most n entries before its end, so that
+// reading on yields at least the last n entries of the merged stream.
+func (d *Dir) Tail(n int) error {
+	for _, f := range d.files {
+		if f.next != nil {
+			continue
+		}
+		left := f.header.NEntries - min(f.header.NEntries, f.read)
+		if err := f.Skip(left - min(left, uint64(n))); err != nil {
+			return fmt.Errorf("%s: %w", f.Path(), err)
+		}
+	}
+	return nil
+}
+
+// Close closes every open file.
+func (d *Dir) Close() error {
+	for _, f := range d.files {
+		f.Close()
+	}
+	d.files = nil
+	return nil
+}
@@ -0,0 +1,587 @@
+// Package journal reads systemd journal files (.journal) directly,
+// without journalctl.
+//
+// A journal file is a header followed by an arena of 8-byte aligned
+// objects. Entries are found through a chain of entry array objects
+// starting at the header's entry_array_offset; each entry object lists
+// the data objects ("FIELD=value" payloads) it is made of. Files written
+// in compact mode (systemd 252+) use 32-bit offsets in entry items and
+// entry arrays. The layout follows systemd's journal-def.h and
+// https://systemd.io/JOURNAL_FILE_FORMAT/.
+//
+// Data objects over a size threshold may be compressed with xz, lz4 or
+// zstd. Those are detected but not decompressed: the field is left out
+// of the entry and a *CompressionError reports it.
+package journal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature is the magic at the start of every journal file.
+const Signature = "LPKSHHRH"
+
+// Header incompatible flags.
+const (
+	IncompatibleCompressedXZ   = 1 << 0
+	IncompatibleCompressedLZ4  = 1 << 1
+	IncompatibleKeyedHash      = 1 << 2
+	IncompatibleCompressedZSTD = 1 << 3
+	IncompatibleCompact        = 1 << 4
+
+	// incompatibleSupported are the flags this reader understands. The
+	// compression flags only say the writer may compress; individual
+	// objects are checked as they are read.
+	incompatibleSupported = IncompatibleCompressedXZ | IncompatibleCompressedLZ4 |
+		IncompatibleKeyedHash | IncompatibleCompressedZSTD | IncompatibleCompact
+)
+
+// Object types.
+const (
+	objectData       = 1
+	objectEntry      = 3
+	objectEntryArray = 6
+)
+
+// Object compression flags.
+const (
+	objectCompressedXZ   = 1 << 0
+	objectCompressedLZ4  = 1 << 1
+	objectCompressedZSTD = 1 << 2
+	objectCompressedMask = objectCompressedXZ | objectCompressedLZ4 | objectCompressedZSTD
+)
+
+// File states.
+const (
+	StateOffline  = 0
+	StateOnline   = 1
+	StateArchived = 2
+)
+
+// Layout sizes and offsets from journal-def.h.
+const (
+	minHeaderSize        = 208 // Up to tail_entry_monotonic, systemd 187
+	objectHeaderSize     = 16
+	entryItemsOffset     = 64
+	entryArrayItemOffset = 24
+	dataPayloadOffset    = 64
+	dataPayloadCompact   = 72
+
+	// maxObjectSize guards against corrupt sizes causing huge reads
+	maxObjectSize = 64 << 20
+)
+
+// ErrNotJournal is returned for files without the journal signature.
+var ErrNotJournal = errors.New("not a journal file")
+
+// CompressionError reports data objects this reader can't decompress.
+// The entry is still returned, without those fields.
+type CompressionError struct {
+	Algorithm string   // "xz", "lz4" or "zstd"
+	Fields    []string // Names of the fields left out, where known
+}
+
+func (e *CompressionError) Error() string {
+	return fmt.Sprintf("journal data compressed with %s is not supported (fields: %s)",
+		e.Algorithm, strings.Join(e.Fields, ", "))
+}
+
+// Header is the fixed header of a journal file.
+type Header struct {
+	CompatibleFlags   uint32
+	IncompatibleFlags uint32
+	State             uint8
+	FileID            [16]byte
+	MachineID         [16]byte
+	BootID            [16]byte // Boot of the last entry written
+	SeqnumID          [16]byte
+	HeaderSize        uint64
+	ArenaSize         uint64
+	TailObjectOffset  uint64
+	NObjects          uint64
+	NEntries          uint64
+	TailEntrySeqnum   uint64
+	HeadEntrySeqnum   uint64
+	EntryArrayOffset  uint64
+	HeadEntryRealtime uint64
+	TailEntryRealtime uint64
+}
+
+// Compact reports whether the file uses 32-bit entry offsets.
+func (h Header) Compact() bool {
+	return h.IncompatibleFlags&IncompatibleCompact != 0
+}
+
+// Entry is one journal entry.
+type Entry struct {
+	Seqnum    uint64
+	SeqnumID  [16]byte
+	Realtime  time.Time
+	Monotonic time.Duration
+	BootID    [16]byte
+	XorHash   uint64
+
+	// Fields maps field names to values. Values are raw bytes in a
+	// string and may be binary; a field logged more than once has its
+	// values joined by newlines, as the journalctl backend reads them.
+	Fields map[string]string
+}
+
+// Cursor returns the entry's position in journalctl's cursor format, so
+// cursors can be shared with journalctl --after-cursor.
+func (e *Entry) Cursor() string {
+	return fmt.Sprintf("s=%s;i=%x;b=%s;m=%x;t=%x;x=%x",
+		hex.EncodeToString(e.SeqnumID[:]), e.Seqnum,
+		hex.EncodeToString(e.BootID[:]), uint64(e.Monotonic/time.Microsecond),
+		e.Realtime.UnixMicro(), e.XorHash)
+}
+
+// cursor is the part of a cursor string needed to find an entry again.
+type cursor struct {
+	seqnumID [16]byte
+	seqnum   uint64
+	realtime time.Time
+}
+
+// parseCursor parses the s=, i= and t= parts of a cursor string; the
+// rest are ignored.
+func parseCursor(s string) (cursor, error) {
+	var c cursor
+	var seen int
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return c, fmt.Errorf("invalid cursor %q", s)
+		}
+		var err error
+		switch key {
+		case "s":
+			var id []byte
+			if id, err = hex.DecodeString(value); err == nil && len(id) != len(c.seqnumID) {
+				err = fmt.Errorf("bad length")
+			}
+			copy(c.seqnumID[:], id)
+		case "i":
+			c.seqnum, err = strconv.ParseUint(value, 16, 64)
+		case "t":
+			var usec uint64
+			usec, err = strconv.ParseUint(value, 16, 64)
+			c.realtime = time.UnixMicro(int64(usec))
+		default:
+			continue
+		}
+		if err != nil {
+			return c, fmt.Errorf("invalid cursor %q: %s: %w", s, key, err)
+		}
+		seen++
+	}
+	if seen != 3 {
+		return c, fmt.Errorf("invalid cursor %q", s)
+	}
+	return c, nil
+}
+
+// File reads entries from a single journal file in the order they were
+// written. It is not safe for concurrent use.
+type File struct {
+	path   string
+	file   *os.File
+	header Header
+
+	// Iteration state: the entry array being read, its capacity (0 until
+	// read) and the next item in it
+	array    uint64
+	arrayCap uint64
+	arrayIdx uint64
+	read     uint64 // Entries returned so far
+}
+
+// Open opens a journal file and reads its header.
+func Open(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{path: path, file: file}
+	if err := f.readHeader(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if unknown := f.header.IncompatibleFlags &^ incompatibleSupported; unknown != 0 {
+		file.Close()
+		return nil, fmt.Errorf("%s: unsupported journal features (incompatible flags %#x)", path, unknown)
+	}
+	return f, nil
+}
+
+// Path returns the file's path.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Header returns the header as last read.
+func (f *File) Header() Header {
+	return f.header
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	return f.file.Close()
+}
+
+// readHeader (re)reads the header; a journal being written keeps
+// updating n_entries and the arena size.
+func (f *File) readHeader() error {
+	buf := make([]byte, 240)
+	n, err := f.file.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if n < len(Signature) || string(buf[:len(Signature)]) != Signature {
+		return ErrNotJournal
+	}
+	if n < minHeaderSize {
+		return fmt.Errorf("truncated header")
+	}
+
+	le := binary.LittleEndian
+	h := &f.header
+	h.CompatibleFlags = le.Uint32(buf[8:])
+	h.IncompatibleFlags = le.Uint32(buf[12:])
+	h.State = buf[16]
+	copy(h.FileID[:], buf[24:40])
+	copy(h.MachineID[:], buf[40:56])
+	copy(h.BootID[:], buf[56:72])
+	copy(h.SeqnumID[:], buf[72:88])
+	h.HeaderSize = le.Uint64(buf[88:])
+	h.ArenaSize = le.Uint64(buf[96:])
+	h.TailObjectOffset = le.Uint64(buf[136:])
+	h.NObjects = le.Uint64(buf[144:])
+	h.NEntries = le.Uint64(buf[152:])
+	h.TailEntrySeqnum = le.Uint64(buf[160:])
+	h.HeadEntrySeqnum = le.Uint64(buf[168:])
+	h.EntryArrayOffset = le.Uint64(buf[176:])
+	h.HeadEntryRealtime = le.Uint64(buf[184:])
+	h.TailEntryRealtime = le.Uint64(buf[192:])
+
+	if h.HeaderSize < minHeaderSize {
+		return fmt.Errorf("header size %d too small", h.HeaderSize)
+	}
+	return nil
+}
+
+// Next returns the next entry, or io.EOF when every entry written so far
+// has been read. Calling Next again later picks up entries appended
+// since, so a file being written can be followed.
+//
+// If some of the entry's data is compressed, the entry is returned
+// without those fields together with a *CompressionError.
+func (f *File) Next() (*Entry, error) {
+	if f.read >= f.header.NEntries {
+		if err := f.readHeader(); err != nil {
+			return nil, err
+		}
+		if f.read >= f.header.NEntries {
+			return nil, io.EOF
+		}
+	}
+
+	offset, err := f.nextEntryOffset()
+	if err != nil {
+		return nil, err
+	}
+	f.read++
+	return f.readEntry(offset)
+}
+
+// nextEntryOffset walks the entry array chain to the next entry. Only
+// the item read is loaded: an array holds up to hundreds of thousands.
+func (f *File) nextEntryOffset() (uint64, error) {
+	itemSize := f.arrayItemSize()
+
+	if f.array == 0 {
+		if f.header.EntryArrayOffset == 0 {
+			return 0, io.EOF
+		}
+		f.array, f.arrayCap, f.arrayIdx = f.header.EntryArrayOffset, 0, 0
+	}
+
+	for hops := 0; ; hops++ {
+		capacity, err := f.arrayCapacity()
+		if err != nil {
+			return 0, err
+		}
+
+		if f.arrayIdx >= capacity {
+			next, err := f.nextArray()
+			if err != nil {
+				return 0, err
+			}
+			if next == 0 {
+				// Stay on this array; the writer links the next one later
+				return 0, io.EOF
+			}
+			if err := f.followArray(next, hops); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		var item [8]byte
+		pos := f.array + entryArrayItemOffset + f.arrayIdx*itemSize
+		if _, err := f.file.ReadAt(item[:itemSize], int64(pos)); err != nil {
+			return 0, fmt.Errorf("object at %d: %w", f.array, err)
+		}
+		offset := binary.LittleEndian.Uint64(item[:])
+		if offset == 0 {
+			// Slot not filled in yet
+			return 0, io.EOF
+		}
+		f.arrayIdx++
+		return offset, nil
+	}
+}
+
+// Skip moves past the next n entries without decoding them. Skipping
+// beyond the last entry written stops there.
+func (f *File) Skip(n uint64) error {
+	for hops := 0; n > 0 && f.read < f.header.NEntries; hops++ {
+		if f.array == 0 {
+			f.array, f.arrayCap, f.arrayIdx = f.header.EntryArrayOffset, 0, 0
+		}
+		capacity, err := f.arrayCapacity()
+		if err != nil {
+			return err
+		}
+
+		if f.arrayIdx >= capacity {
+			next, err := f.nextArray()
+			if err != nil || next == 0 {
+				return err
+			}
+			if err := f.followArray(next, hops); err != nil {
+				return err
+			}
+			continue
+		}
+
+		step := min(n, capacity-f.arrayIdx, f.header.NEntries-f.read)
+		f.arrayIdx += step
+		f.read += step
+		n -= step
+		hops = -1 // Counted afresh from the next array
+	}
+	return nil
+}
+
+// arrayItemSize is the size of an entry array item: compact files use
+// 32-bit offsets.
+func (f *File) arrayItemSize() uint64 {
+	if f.header.Compact() {
+		return 4
+	}
+	return 8
+}
+
+// arrayCapacity returns how many items the current entry array holds,
+// reading its size once.
+func (f *File) arrayCapacity() (uint64, error) {
+	if f.arrayCap == 0 {
+		size, err := f.objectSize(f.array, objectEntryArray)
+		if err != nil {
+			return 0, err
+		}
+		f.arrayCap = (size - entryArrayItemOffset) / f.arrayItemSize()
+	}
+	return f.arrayCap, nil
+}
+
+// nextArray reads the current entry array's link to the next one, 0 if
+// none has been written yet.
+func (f *File) nextArray() (uint64, error) {
+	var next [8]byte
+	if _, err := f.file.ReadAt(next[:], int64(f.array)+objectHeaderSize); err != nil {
+		return 0, fmt.Errorf("object at %d: %w", f.array, err)
+	}
+	return binary.LittleEndian.Uint64(next[:]), nil
+}
+
+// maxArrayHops caps how many entry arrays are followed in a row without
+// reaching an entry. journald never writes an empty array, so only a
+// damaged file comes near it.
+const maxArrayHops = 64
+
+// followArray moves on to the next entry array in the chain, hops arrays
+// after the last one that held an entry. journald only links forward, so
+// a link back to this array or an earlier one, which would loop forever,
+// means the file is damaged.
+func (f *File) followArray(next uint64, hops int) error {
+	if next <= f.array {
+		return fmt.Errorf("entry array at %d: links back to %d", f.array, next)
+	}
+	if hops >= maxArrayHops {
+		return fmt.Errorf("entry array at %d: %d arrays without entries", next, hops)
+	}
+	f.array, f.arrayCap, f.arrayIdx = next, 0, 0
+	return nil
+}
+
+// skipAll moves past every entry written so far.
+func (f *File) skipAll() error {
+	return f.Skip(f.header.NEntries - f.read)
+}
+
+// objectSize reads the header of the object at offset, checking its type
+// and returning its size.
+func (f *File) objectSize(offset uint64, want uint8) (uint64, error) {
+	var head [objectHeaderSize]byte
+	if _, err := f.file.ReadAt(head[:], int64(offset)); err != nil {
+		return 0, fmt.Errorf("object at %d: %w", offset, err)
+	}
+	kind := head[0]
+	size := binary.LittleEndian.Uint64(head[8:])
+	if kind != want {
+		return 0, fmt.Errorf("object at %d: type %d, want %d", offset, kind, want)
+	}
+	if size < objectHeaderSize || size > maxObjectSize {
+		return 0, fmt.Errorf("object at %d: bad size %d", offset, size)
+	}
+	if want == objectEntryArray && size < entryArrayItemOffset {
+		return 0, fmt.Errorf("object at %d: bad size %d", offset, size)
+	}
+	return size, nil
+}
+
+// readObject reads the whole object at offset, checking its type.
+func (f *File) readObject(offset uint64, want uint8) ([]byte, error) {
+	size, err := f.objectSize(offset, want)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := make([]byte, size)
+	if _, err := f.file.ReadAt(obj, int64(offset)); err != nil {
+		return nil, fmt.Errorf("object at %d: %w", offset, err)
+	}
+	return obj, nil
+}
+
+// readEntry decodes the entry object at offset and its data objects.
+func (f *File) readEntry(offset uint64) (*Entry, error) {
+	obj, err := f.readObject(offset, objectEntry)
+	if err != nil {
+		return nil, err
+	}
+	if len(obj) < entryItemsOffset {
+		return nil, fmt.Errorf("entry at %d: too small", offset)
+	}
+
+	le := binary.LittleEndian
+	e := &Entry{
+		Seqnum:    le.Uint64(obj[16:]),
+		SeqnumID:  f.header.SeqnumID,
+		Realtime:  time.UnixMicro(int64(le.Uint64(obj[24:]))),
+		Monotonic: time.Duration(le.Uint64(obj[32:])) * time.Microsecond,
+		XorHash:   le.Uint64(obj[56:]),
+		Fields:    make(map[string]string),
+	}
+	copy(e.BootID[:], obj[40:56])
+
+	itemSize := 16 // le64 object_offset + le64 hash
+	if f.header.Compact() {
+		itemSize = 4 // le32 object_offset
+	}
+
+	var compressed *CompressionError
+	for pos := entryItemsOffset; pos+itemSize <= len(obj); pos += itemSize {
+		var dataOffset uint64
+		if itemSize == 4 {
+			dataOffset = uint64(le.Uint32(obj[pos:]))
+		} else {
+			dataOffset = le.Uint64(obj[pos:])
+		}
+		if dataOffset == 0 {
+			continue
+		}
+
+		name, value, algorithm, err := f.readData(dataOffset)
+		if err != nil {
+			return nil, fmt.Errorf("entry at %d: %w", offset, err)
+		}
+		if algorithm != "" {
+			if compressed == nil {
+				compressed = &CompressionError{Algorithm: algorithm}
+			}
+			compressed.Fields = append(compressed.Fields, name)
+			continue
+		}
+		if prev, ok := e.Fields[name]; ok {
+			value = prev + "\n" + value
+		}
+		e.Fields[name] = value
+	}
+
+	if compressed != nil {
+		return e, compressed
+	}
+	return e, nil
+}
+
+// entryBoot reads just the boot ID and time of the entry at offset.
+func (f *File) entryBoot(offset uint64) (boot [16]byte, realtime time.Time, err error) {
+	if _, err := f.objectSize(offset, objectEntry); err != nil {
+		return boot, realtime, err
+	}
+	var head [entryItemsOffset]byte
+	if _, err := f.file.ReadAt(head[:], int64(offset)); err != nil {
+		return boot, realtime, fmt.Errorf("entry at %d: %w", offset, err)
+	}
+	copy(boot[:], head[40:56])
+	return boot, time.UnixMicro(int64(binary.LittleEndian.Uint64(head[24:]))), nil
+}
+
+// readData decodes a data object into a field name and value. For a
+// compressed object only the algorithm is returned; the name is
+// unknown too, since it is compressed along with the value.
+func (f *File) readData(offset uint64) (name, value, algorithm string, err error) {
+	obj, err := f.readObject(offset, objectData)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	start := dataPayloadOffset
+	if f.header.Compact() {
+		start = dataPayloadCompact
+	}
+	if len(obj) < start {
+		return "", "", "", fmt.Errorf("data at %d: too small", offset)
+	}
+
+	switch obj[1] & objectCompressedMask {
+	case 0:
+	case objectCompressedXZ:
+		return "?", "", "xz", nil
+	case objectCompressedLZ4:
+		return "?", "", "lz4", nil
+	case objectCompressedZSTD:
+		return "?", "", "zstd", nil
+	default:
+		return "", "", "", fmt.Errorf("data at %d: conflicting compression flags %#x", offset, obj[1])
+	}
+
+	payload := obj[start:]
+	eq := bytes.IndexByte(payload, '=')
+	if eq <= 0 {
+		return "", "", "", fmt.Errorf("data at %d: payload is not FIELD=value", offset)
+	}
+	return string(payload[:eq]), string(payload[eq+1:]), "", nil
+}
@@ -0,0 +1,179 @@
+// Package state persists how far each log source has read, so a restarted
+// Argus can resume where the previous run stopped.
+//
+// State lives under $XDG_STATE_HOME/argus (default ~/.local/state/argus),
+// separate from the config: it changes constantly and is safe to delete.
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Expert21/argus/internal/ingest"
+)
+
+const (
+	// DefaultStateDir is the directory under XDG_STATE_HOME
+	DefaultStateDir = "argus"
+
+	// PositionsFile holds the saved source positions
+	PositionsFile = "positions.json"
+
+	// FlushInterval is how often positions are written while running
+	FlushInterval = 5 * time.Second
+)
+
+// Dir returns the state directory, honouring XDG_STATE_HOME.
+func Dir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, DefaultStateDir), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", DefaultStateDir), nil
+}
+
+// file is the on-disk layout of the positions file.
+type file struct {
+	Saved     time.Time                  `json:"saved"`
+	Positions map[string]ingest.Position `json:"positions"`
+}
+
+// Store is an ingest.PositionStore backed by a JSON file.
+//
+// Sources call Save for every entry, so positions are kept in memory and
+// only written by Flush; FlushEvery does that periodically.
+type Store struct {
+	path      string
+	mu        sync.Mutex
+	positions map[string]ingest.Position
+	lastRun   time.Time // When the previous run last wrote the file
+	dirty     bool
+}
+
+// Open loads the positions file at path. A missing file is not an error;
+// the store simply starts empty.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:      path,
+		positions: make(map[string]ingest.Position),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse state %s: %w", path, err)
+	}
+	if f.Positions != nil {
+		s.positions = f.Positions
+	}
+	s.lastRun = f.Saved
+	return s, nil
+}
+
+// OpenDefault opens the positions file in the default state directory.
+func OpenDefault() (*Store, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	return Open(filepath.Join(dir, PositionsFile))
+}
+
+// Path returns the file the store is saved to.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// LastRun returns when the previous run last saved its positions, or the
+// zero time if there was none.
+func (s *Store) LastRun() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastRun
+}
+
+// Load returns the saved position of a source.
+func (s *Store) Load(source string) (ingest.Position, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pos, ok := s.positions[source]
+	return pos, ok
+}
+
+// Save records the position of a source in memory.
+func (s *Store) Save(source string, pos ingest.Position) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.positions[source] = pos
+	s.dirty = true
+}
+
+// Flush writes the positions to disk if anything changed. The file is
+// replaced atomically so a crash never leaves it half-written.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	data, err := json.MarshalIndent(file{Saved: time.Now(), Positions: s.positions}, "", "  ")
+	s.dirty = false
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), PositionsFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// FlushEvery flushes on every tick until ctx is cancelled, then once more.
+func (s *Store) FlushEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			_ = s.Flush()
+			return
+		case <-ticker.C:
+			_ = s.Flush()
+		}
+	}
+}
+
+// Ensure Store implements ingest.PositionStore
+var _ ingest.PositionStore = (*Store)(nil)
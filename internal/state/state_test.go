@@ -0,0 +1,73 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Expert21/argus/internal/ingest"
+)
+
+// TestStoreRoundTrip tests that flushed positions survive a reopen.
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", PositionsFile)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() on missing file error: %v", err)
+	}
+	if !store.LastRun().IsZero() {
+		t.Errorf("LastRun() = %v, want zero for a first run", store.LastRun())
+	}
+
+	store.Save("System Journal", ingest.Position{Cursor: "s=abc;i=42"})
+	store.Save("Auth Log", ingest.Position{Dev: 2049, Inode: 1234, Offset: 5678})
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	if pos, ok := reopened.Load("System Journal"); !ok || pos.Cursor != "s=abc;i=42" {
+		t.Errorf("Load(journal) = %+v, %v", pos, ok)
+	}
+	if pos, ok := reopened.Load("Auth Log"); !ok || pos.Inode != 1234 || pos.Offset != 5678 {
+		t.Errorf("Load(file) = %+v, %v", pos, ok)
+	}
+	if _, ok := reopened.Load("Unknown"); ok {
+		t.Error("Load(Unknown) should report no position")
+	}
+	if since := time.Since(reopened.LastRun()); since < 0 || since > time.Minute {
+		t.Errorf("LastRun() = %v, want about now", reopened.LastRun())
+	}
+}
+
+// TestDir tests XDG_STATE_HOME handling.
+func TestDir(t *testing.T) {
+	tests := []struct {
+		name string
+		xdg  string
+		home string
+		want string
+	}{
+		{"xdg set", "/xdg/state", "/home/u", "/xdg/state/argus"},
+		{"xdg unset", "", "/home/u", "/home/u/.local/state/argus"},
+		{"xdg relative is ignored", "state", "/home/u", "/home/u/.local/state/argus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", tt.xdg)
+			t.Setenv("HOME", tt.home)
+			got, err := Dir()
+			if err != nil {
+				t.Fatalf("Dir() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Dir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
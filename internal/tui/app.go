@@ -0,0 +1,546 @@
+// Package tui provides the terminal user interface components.
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Expert21/argus/internal/aggregate"
+	"github.com/Expert21/argus/internal/config"
+	"github.com/Expert21/argus/internal/ingest"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// GO SYNTAX LESSON #38: The Elm Architecture
+// ==========================================
+// Bubble Tea programs are built from three methods on a single model:
+// - Init() returns the first command to run (or nil)
+// - Update(msg) handles an event and returns the new model + next command
+// - View() renders the model as a string
+//
+// The model is never mutated from other goroutines. Background work
+// (like waiting for log entries) runs inside a tea.Cmd, which is just a
+// func() tea.Msg. Whatever it returns is delivered back to Update.
+
+// ReloadFunc re-reads the configuration and re-registers log sources.
+// It runs off the UI goroutine, so it may block.
+type ReloadFunc func() (*config.Config, error)
+
+// ListBootsFunc lists the boots in the journal, oldest first.
+type ListBootsFunc func() ([]ingest.Boot, error)
+
+// SwitchBootFunc restarts the journald sources on one boot and returns
+// the subscription the new boot's entries arrive on; entries still on the
+// old one belong to the old boot. Like ReloadFunc, it runs off the UI
+// goroutine.
+type SwitchBootFunc func(boot ingest.Boot) (*aggregate.Subscriber, error)
+
+// focusArea identifies which panel receives navigation keys.
+type focusArea int
+
+const (
+	focusSidebar focusArea = iota
+	focusLogs
+)
+
+// Layout constants
+const (
+	sidebarWidth     = 28
+	detailWidth      = 48
+	detailMinWidth   = 140 // Terminal width needed before the detail panel is shown
+	entryBatchSize   = 256 // Max entries drained from the subscriber per message
+	healthRefreshInt = time.Second
+)
+
+// entriesMsg carries a batch of new entries from the aggregator, and the
+// subscription they came from.
+type entriesMsg struct {
+	sub     *aggregate.Subscriber
+	entries []ingest.LogEntry
+}
+
+// subscriptionClosedMsg is sent when the aggregator closes a subscription.
+type subscriptionClosedMsg struct {
+	sub *aggregate.Subscriber
+}
+
+// tickMsg triggers a periodic redraw (source health, counters).
+type tickMsg time.Time
+
+// bootsMsg carries the boot list for the boot picker.
+type bootsMsg struct {
+	boots []ingest.Boot
+	err   error
+}
+
+// bootSwitchedMsg reports the result of switching boots.
+type bootSwitchedMsg struct {
+	boot ingest.Boot
+	sub  *aggregate.Subscriber
+	err  error
+}
+
+// reloadedMsg reports the result of a config reload.
+type reloadedMsg struct {
+	cfg *config.Config
+	err error
+}
+
+// App is the root Bubble Tea model. It owns every panel and routes
+// messages between them.
+type App struct {
+	cfg        *config.Config
+	aggregator *aggregate.Aggregator
+	sub        *aggregate.Subscriber
+	reload     ReloadFunc
+	listBoots  ListBootsFunc
+	switchBoot SwitchBootFunc
+
+	// UI components
+	sidebar    *Sidebar
+	logView    *LogView
+	detailView *LogDetailView
+	statusBar  *StatusBar
+	bootPicker *BootPicker
+
+	focus     focusArea
+	paused    bool
+	pending   []ingest.LogEntry // Entries received while paused
+	showHelp  bool
+	showBoots bool
+	status    string
+
+	width, height int
+}
+
+// NewApp creates the root model. The subscriber must already be registered
+// with the aggregator so no entries are missed before Init runs.
+func NewApp(cfg *config.Config, agg *aggregate.Aggregator, sub *aggregate.Subscriber, reload ReloadFunc) *App {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	SetDefaultFormatter(cfg)
+
+	app := &App{
+		cfg:        cfg,
+		aggregator: agg,
+		sub:        sub,
+		reload:     reload,
+		sidebar:    NewSidebar(agg),
+		logView:    NewLogView(),
+		detailView: NewLogDetailView(),
+		statusBar:  NewStatusBar(),
+		bootPicker: NewBootPicker(),
+		focus:      focusLogs,
+		status:     "Starting...",
+	}
+
+	app.logView.SetMaxEntries(cfg.General.MaxBuffer)
+	if !cfg.General.ScrollOnNew {
+		app.logView.ToggleAutoScroll()
+	}
+	app.sidebar.RefreshSources()
+	app.applyFocus()
+
+	return app
+}
+
+// SetStatus sets the initial status bar message (e.g. source start errors).
+func (a *App) SetStatus(status string) {
+	a.status = status
+}
+
+// SetBootPicker enables the boot picker, which lists boots with list and
+// switches the journald sources with switchTo.
+func (a *App) SetBootPicker(list ListBootsFunc, switchTo SwitchBootFunc) {
+	a.listBoots = list
+	a.switchBoot = switchTo
+}
+
+// Init starts listening for entries and schedules the first redraw tick.
+func (a *App) Init() tea.Cmd {
+	return tea.Batch(waitForEntries(a.sub), tick())
+}
+
+// waitForEntries blocks until at least one entry arrives, then drains
+// whatever else is already queued so bursts cost a single redraw.
+//
+// GO SYNTAX LESSON #39: Commands as Closures
+// ==========================================
+// A tea.Cmd is a function value. Returning a closure lets us capture
+// the subscriber without storing goroutine state on the model.
+// Bubble Tea runs it in its own goroutine and hands the result to Update.
+func waitForEntries(sub *aggregate.Subscriber) tea.Cmd {
+	return func() tea.Msg {
+		entry, ok := <-sub.Ch
+		if !ok {
+			return subscriptionClosedMsg{sub: sub}
+		}
+
+		batch := entriesMsg{sub: sub, entries: []ingest.LogEntry{entry}}
+		for len(batch.entries) < entryBatchSize {
+			select {
+			case entry, ok := <-sub.Ch:
+				if !ok {
+					return batch
+				}
+				batch.entries = append(batch.entries, entry)
+			default:
+				return batch
+			}
+		}
+		return batch
+	}
+}
+
+// tick schedules the next periodic redraw.
+func tick() tea.Cmd {
+	return tea.Tick(healthRefreshInt, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// Update handles incoming messages.
+func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		a.width = msg.Width
+		a.height = msg.Height
+		a.layout()
+		return a, nil
+
+	case tea.KeyMsg:
+		return a.handleKey(msg)
+
+	case entriesMsg:
+		if msg.sub != a.sub {
+			// Left over from before a boot switch
+			return a, nil
+		}
+		if a.paused {
+			a.pending = append(a.pending, msg.entries...)
+			if overflow := len(a.pending) - a.cfg.General.MaxBuffer; overflow > 0 {
+				a.pending = a.pending[overflow:]
+			}
+		} else {
+			for _, entry := range msg.entries {
+				a.logView.AddEntry(entry)
+			}
+			a.detailView.SetEntry(a.logView.GetSelectedEntry())
+		}
+		return a, waitForEntries(a.sub)
+
+	case subscriptionClosedMsg:
+		if msg.sub != a.sub {
+			return a, nil
+		}
+		a.status = "Aggregator stopped"
+		return a, nil
+
+	case tickMsg:
+		a.sidebar.RefreshSources()
+		return a, tick()
+
+	case bootsMsg:
+		if msg.err != nil {
+			a.status = fmt.Sprintf("Listing boots failed: %v", msg.err)
+			return a, nil
+		}
+		a.bootPicker.SetBoots(msg.boots)
+		a.showBoots = true
+		a.status = fmt.Sprintf("%d boots", len(msg.boots))
+		return a, nil
+
+	case bootSwitchedMsg:
+		var listen tea.Cmd
+		if msg.sub != nil {
+			// The old boot's entries would mix with the new one's, which
+			// wait on the new subscription until it is read
+			a.sub = msg.sub
+			a.logView.Clear()
+			a.pending = nil
+			a.detailView.SetEntry(nil)
+			listen = waitForEntries(a.sub)
+		}
+		a.sidebar.RefreshSources()
+		if msg.err != nil {
+			a.status = fmt.Sprintf("Switching boot failed: %v", msg.err)
+			return a, listen
+		}
+		a.status = fmt.Sprintf("Showing boot %d (%s, %s)", msg.boot.Index,
+			truncateStr(msg.boot.ID, 12), msg.boot.First.Format("Jan 2 15:04"))
+		return a, listen
+
+	case reloadedMsg:
+		if msg.err != nil {
+			a.status = fmt.Sprintf("Reload failed: %v", msg.err)
+			return a, nil
+		}
+		a.cfg = msg.cfg
+		SetDefaultFormatter(a.cfg)
+		a.logView.SetMaxEntries(a.cfg.General.MaxBuffer)
+		a.sidebar.RefreshSources()
+		a.status = fmt.Sprintf("Config reloaded (%d sources)", len(a.aggregator.GetSources()))
+		return a, nil
+	}
+
+	return a, nil
+}
+
+// handleKey dispatches a key press according to the README keybindings.
+func (a *App) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+
+	// Quit works everywhere, including the help overlay
+	if key == "q" || key == "ctrl+c" {
+		return a, tea.Quit
+	}
+
+	if a.showHelp {
+		if key == "?" || key == "esc" {
+			a.showHelp = false
+		}
+		return a, nil
+	}
+
+	if a.showBoots {
+		return a.handleBootKey(key)
+	}
+
+	switch key {
+	case "?":
+		a.showHelp = true
+
+	case "tab", "shift+tab":
+		if a.focus == focusSidebar {
+			a.focus = focusLogs
+		} else {
+			a.focus = focusSidebar
+		}
+		a.applyFocus()
+
+	case "j", "down":
+		if a.focus == focusSidebar {
+			a.sidebar.MoveDown()
+		} else {
+			a.logView.SelectDown()
+		}
+
+	case "k", "up":
+		if a.focus == focusSidebar {
+			a.sidebar.MoveUp()
+		} else {
+			a.logView.SelectUp()
+		}
+
+	case "pgup", "ctrl+u":
+		a.logView.PageUp()
+
+	case "pgdown", "ctrl+d":
+		a.logView.PageDown()
+
+	case "enter":
+		if a.focus == focusSidebar {
+			filter := a.sidebar.Select()
+			a.logView.SetSourceFilter(filter)
+			if filter == "" {
+				a.status = "Showing all sources"
+			} else {
+				a.status = fmt.Sprintf("Filtering: %s", filter)
+			}
+		}
+
+	case "p", " ":
+		a.togglePause()
+
+	case "c":
+		a.logView.Clear()
+		a.pending = nil
+		a.status = "Log view cleared"
+
+	case "g", "home":
+		a.logView.GotoTop()
+
+	case "G", "end":
+		a.logView.GotoBottom()
+
+	case "b":
+		if a.listBoots == nil {
+			a.status = "Boot picker not available"
+			return a, nil
+		}
+		a.status = "Listing boots..."
+		list := a.listBoots
+		return a, func() tea.Msg {
+			boots, err := list()
+			return bootsMsg{boots: boots, err: err}
+		}
+
+	case "r":
+		if a.reload == nil {
+			a.status = "Reload not available"
+			return a, nil
+		}
+		a.status = "Reloading config..."
+		reload := a.reload
+		return a, func() tea.Msg {
+			cfg, err := reload()
+			return reloadedMsg{cfg: cfg, err: err}
+		}
+	}
+
+	a.detailView.SetEntry(a.logView.GetSelectedEntry())
+	return a, nil
+}
+
+// handleBootKey handles keys while the boot picker is open.
+func (a *App) handleBootKey(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "esc", "b":
+		a.showBoots = false
+
+	case "j", "down":
+		a.bootPicker.MoveDown()
+
+	case "k", "up":
+		a.bootPicker.MoveUp()
+
+	case "enter":
+		boot, ok := a.bootPicker.Selected()
+		if !ok {
+			return a, nil
+		}
+		a.showBoots = false
+		a.status = fmt.Sprintf("Switching to boot %d...", boot.Index)
+		switchTo := a.switchBoot
+		return a, func() tea.Msg {
+			sub, err := switchTo(boot)
+			return bootSwitchedMsg{boot: boot, sub: sub, err: err}
+		}
+	}
+	return a, nil
+}
+
+// togglePause pauses or resumes the live feed. Entries that arrived while
+// paused are flushed into the log view on resume.
+func (a *App) togglePause() {
+	a.paused = !a.paused
+	if a.paused {
+		a.status = "Paused"
+		return
+	}
+
+	for _, entry := range a.pending {
+		a.logView.AddEntry(entry)
+	}
+	a.status = fmt.Sprintf("Resumed (%d new entries)", len(a.pending))
+	a.pending = nil
+	a.detailView.SetEntry(a.logView.GetSelectedEntry())
+}
+
+// applyFocus propagates the focus state to the panels.
+func (a *App) applyFocus() {
+	a.sidebar.SetFocused(a.focus == focusSidebar)
+	a.logView.SetFocused(a.focus == focusLogs)
+}
+
+// showDetail reports whether the terminal is wide enough for the detail panel.
+func (a *App) showDetail() bool {
+	return a.width >= detailMinWidth
+}
+
+// layout distributes the terminal size across the panels.
+func (a *App) layout() {
+	// Status bar takes one line plus its top margin; panels have a
+	// one-line border top and bottom.
+	panelHeight := a.height - 4
+	if panelHeight < 3 {
+		panelHeight = 3
+	}
+
+	// Sidebar: width + border (2) + margin (1)
+	remaining := a.width - sidebarWidth - 3
+	a.sidebar.SetSize(sidebarWidth, panelHeight)
+
+	if a.showDetail() {
+		a.detailView.SetSize(detailWidth, panelHeight)
+		remaining -= detailWidth + 2
+	} else {
+		a.detailView.SetSize(0, 0)
+	}
+
+	// Log view border (2)
+	a.logView.SetSize(remaining-2, panelHeight)
+	a.statusBar.SetWidth(a.width)
+	a.bootPicker.SetHeight(a.height)
+}
+
+// View renders the full screen.
+func (a *App) View() string {
+	if a.width == 0 {
+		return "Initializing..."
+	}
+
+	if a.showHelp {
+		return lipgloss.Place(a.width, a.height, lipgloss.Center, lipgloss.Center, helpView())
+	}
+	if a.showBoots {
+		return lipgloss.Place(a.width, a.height, lipgloss.Center, lipgloss.Center, a.bootPicker.View())
+	}
+
+	panels := []string{a.sidebar.View(), a.logView.View()}
+	if a.showDetail() {
+		panels = append(panels, a.detailView.View())
+	}
+	main := lipgloss.JoinHorizontal(lipgloss.Top, panels...)
+
+	a.statusBar.Update(a.status, a.paused, a.aggregator.EntryCount(), len(a.aggregator.GetSources()))
+
+	return lipgloss.JoinVertical(lipgloss.Left, main, a.statusBar.View())
+}
+
+// keyHelp lists the keybindings shown in the help overlay.
+var keyHelp = []struct {
+	key    string
+	action string
+}{
+	{"q / Ctrl+C", "Quit"},
+	{"Tab", "Switch focus (sidebar ↔ logs)"},
+	{"j/k or ↑/↓", "Navigate / Scroll"},
+	{"PgUp/PgDn", "Scroll one page"},
+	{"Enter", "Select source filter"},
+	{"p / Space", "Pause / Resume"},
+	{"c", "Clear log view"},
+	{"g / G", "Go to top / bottom"},
+	{"b", "Pick a boot (journal sources)"},
+	{"r", "Reload config"},
+	{"?", "Show / hide help"},
+}
+
+// helpView renders the keybinding overlay.
+func helpView() string {
+	var content strings.Builder
+
+	content.WriteString(lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ColorPrimary).
+		Render("⌨  Keybindings"))
+	content.WriteString("\n\n")
+
+	keyStyle := lipgloss.NewStyle().Foreground(ColorAccent).Bold(true).Width(14)
+	for _, h := range keyHelp {
+		content.WriteString(keyStyle.Render(h.key))
+		content.WriteString(StatusTextStyle.Render(h.action))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(HelpStyle.Render("Press ? or Esc to close"))
+
+	return LogDetailFocusedStyle.Render(content.String())
+}
+
+// Ensure App implements tea.Model
+var _ tea.Model = (*App)(nil)
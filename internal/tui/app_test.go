@@ -0,0 +1,268 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Expert21/argus/internal/aggregate"
+	"github.com/Expert21/argus/internal/config"
+	"github.com/Expert21/argus/internal/ingest"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stubSource is a source that sends nothing; it only gives the sidebar
+// a name to list.
+type stubSource struct{ name string }
+
+func (s stubSource) Start(ctx context.Context, entries chan<- ingest.LogEntry) error { return nil }
+func (s stubSource) Stop() error                                                     { return nil }
+func (s stubSource) Name() string                                                    { return s.name }
+func (s stubSource) Healthy() bool                                                   { return true }
+
+// newTestApp returns an App sized like a wide terminal, with sources
+// "api" and "db" registered.
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+	agg := aggregate.NewAggregator(100)
+	for _, name := range []string{"api", "db"} {
+		if err := agg.AddSource(stubSource{name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(agg.Stop)
+
+	a := NewApp(config.DefaultConfig(), agg, agg.Subscribe("tui"), nil)
+	a.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	return a
+}
+
+// press sends key presses to the app, returning the last command.
+func press(a *App, keys ...string) tea.Cmd {
+	var cmd tea.Cmd
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "ctrl+c":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlC}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		_, cmd = a.Update(msg)
+	}
+	return cmd
+}
+
+// entries returns a batch as the app's subscription would deliver it,
+// n entries alternating between the api and db sources.
+func entries(a *App, n int) entriesMsg {
+	msg := entriesMsg{sub: a.sub}
+	for i := 0; i < n; i++ {
+		source := []string{"api", "db"}[i%2]
+		msg.entries = append(msg.entries, ingest.LogEntry{
+			Timestamp:    time.Date(2026, 10, 16, 12, 0, i, 0, time.UTC),
+			Source:       source,
+			IngestorName: source,
+			Level:        ingest.LevelInfo,
+			Message:      fmt.Sprintf("entry %d", i),
+		})
+	}
+	return msg
+}
+
+// TestAppPause tests that entries arriving while paused wait, and are
+// shown on resume.
+func TestAppPause(t *testing.T) {
+	a := newTestApp(t)
+	a.Update(entries(a, 2))
+
+	press(a, "p")
+	if !a.paused || a.status != "Paused" {
+		t.Fatalf("after p: paused = %v, status = %q", a.paused, a.status)
+	}
+	a.Update(entries(a, 3))
+	if got := a.logView.TotalEntryCount(); got != 2 {
+		t.Errorf("entries shown while paused = %d, want 2", got)
+	}
+
+	press(a, " ")
+	if a.paused || a.pending != nil {
+		t.Errorf("after space: paused = %v, %d pending", a.paused, len(a.pending))
+	}
+	if got := a.logView.TotalEntryCount(); got != 5 {
+		t.Errorf("entries shown on resume = %d, want 5", got)
+	}
+	if !strings.Contains(a.status, "3 new entries") {
+		t.Errorf("status = %q, want the resumed count", a.status)
+	}
+}
+
+// TestAppSourceFilter tests focusing the sidebar and picking a source.
+func TestAppSourceFilter(t *testing.T) {
+	a := newTestApp(t)
+	a.Update(entries(a, 4))
+
+	// j and k move the sidebar selection once it has focus, not the logs
+	press(a, "tab", "j", "j", "k", "enter")
+	if a.focus != focusSidebar {
+		t.Fatal("tab didn't focus the sidebar")
+	}
+	if a.status != "Filtering: api" || a.logView.EntryCount() != 2 {
+		t.Errorf("status = %q, %d entries shown, want api's 2", a.status, a.logView.EntryCount())
+	}
+
+	press(a, "k", "enter")
+	if a.status != "Showing all sources" || a.logView.EntryCount() != 4 {
+		t.Errorf("status = %q, %d entries shown, want all 4", a.status, a.logView.EntryCount())
+	}
+
+	press(a, "tab")
+	if a.focus != focusLogs {
+		t.Error("second tab didn't focus the logs")
+	}
+}
+
+// TestAppNavigation tests moving the selection, which the detail panel
+// follows, and clearing the view.
+func TestAppNavigation(t *testing.T) {
+	a := newTestApp(t)
+	a.Update(entries(a, 5))
+
+	selected := func() string {
+		if e := a.logView.GetSelectedEntry(); e != nil {
+			return e.Message
+		}
+		return ""
+	}
+	steps := []struct {
+		key  string
+		want string
+	}{
+		{"g", "entry 0"},
+		{"j", "entry 1"},
+		{"G", "entry 4"},
+		{"k", "entry 3"},
+	}
+	for _, step := range steps {
+		press(a, step.key)
+		if got := selected(); got != step.want {
+			t.Errorf("after %s: selected %q, want %q", step.key, got, step.want)
+		}
+		if a.detailView.entry == nil || a.detailView.entry.Message != step.want {
+			t.Errorf("after %s: detail view doesn't show the selection", step.key)
+		}
+	}
+
+	press(a, "c")
+	if a.logView.TotalEntryCount() != 0 || a.status != "Log view cleared" {
+		t.Errorf("after c: %d entries, status %q", a.logView.TotalEntryCount(), a.status)
+	}
+}
+
+// TestAppHelpAndQuit tests the help overlay swallowing keys, and quitting.
+func TestAppHelpAndQuit(t *testing.T) {
+	a := newTestApp(t)
+
+	press(a, "?")
+	if !a.showHelp || !strings.Contains(a.View(), "Pause / Resume") {
+		t.Fatal("? didn't show the help")
+	}
+	press(a, "p")
+	if a.paused {
+		t.Error("p paused the feed behind the help overlay")
+	}
+	press(a, "esc")
+	if a.showHelp {
+		t.Error("esc didn't close the help")
+	}
+
+	for _, key := range []string{"q", "ctrl+c"} {
+		cmd := press(a, key)
+		if cmd == nil {
+			t.Fatalf("%s returned no command", key)
+		}
+		if _, ok := cmd().(tea.QuitMsg); !ok {
+			t.Errorf("%s didn't quit", key)
+		}
+	}
+}
+
+// TestAppReload tests the r key with and without a reload function.
+func TestAppReload(t *testing.T) {
+	a := newTestApp(t)
+	if cmd := press(a, "r"); cmd != nil || a.status != "Reload not available" {
+		t.Errorf("r without reload: status = %q", a.status)
+	}
+
+	a.reload = func() (*config.Config, error) { return nil, errors.New("bad yaml") }
+	cmd := press(a, "r")
+	if cmd == nil {
+		t.Fatal("r returned no command")
+	}
+	a.Update(cmd())
+	if a.status != "Reload failed: bad yaml" {
+		t.Errorf("status = %q", a.status)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.General.MaxBuffer = 3
+	a.reload = func() (*config.Config, error) { return cfg, nil }
+	a.Update(press(a, "r")())
+	if a.cfg != cfg || !strings.HasPrefix(a.status, "Config reloaded") {
+		t.Errorf("after reload: status = %q", a.status)
+	}
+	a.Update(entries(a, 5))
+	if got := a.logView.TotalEntryCount(); got != 3 {
+		t.Errorf("entries kept = %d, want the reloaded max_buffer of 3", got)
+	}
+}
+
+// TestAppBootSwitch tests that entries left on the old subscription
+// after a boot switch are dropped, and the new boot starts on a clear view.
+func TestAppBootSwitch(t *testing.T) {
+	a := newTestApp(t)
+	a.Update(entries(a, 3))
+	old := entries(a, 2)
+
+	next := a.aggregator.Subscribe("next")
+	boot := ingest.Boot{Index: -1, ID: "0123456789abcdef0123456789abcdef", First: time.Now()}
+	a.SetBootPicker(func() ([]ingest.Boot, error) {
+		return []ingest.Boot{boot}, nil
+	}, func(ingest.Boot) (*aggregate.Subscriber, error) {
+		return next, nil
+	})
+
+	a.Update(press(a, "b")())
+	if !a.showBoots {
+		t.Fatal("b didn't open the boot picker")
+	}
+	cmd := press(a, "enter")
+	if a.showBoots || cmd == nil {
+		t.Fatal("enter didn't pick the boot")
+	}
+	a.Update(cmd())
+	if a.sub != next || a.logView.TotalEntryCount() != 0 {
+		t.Errorf("after the switch: %d entries shown", a.logView.TotalEntryCount())
+	}
+	if !strings.HasPrefix(a.status, "Showing boot -1") {
+		t.Errorf("status = %q", a.status)
+	}
+
+	a.Update(old)
+	if got := a.logView.TotalEntryCount(); got != 0 {
+		t.Errorf("entries from before the switch shown: %d", got)
+	}
+	a.Update(entries(a, 2))
+	if got := a.logView.TotalEntryCount(); got != 2 {
+		t.Errorf("entries of the new boot shown = %d, want 2", got)
+	}
+}
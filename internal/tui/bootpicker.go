@@ -0,0 +1,111 @@
+// Package tui provides the terminal user interface components.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Expert21/argus/internal/ingest"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// BootPicker is the overlay listing the journal's boots, newest first,
+// so journald sources can be switched to an earlier boot.
+type BootPicker struct {
+	// boots are listed newest first, the way people look for "the last crash"
+	boots []ingest.Boot
+
+	// selectedIndex is the highlighted boot
+	selectedIndex int
+
+	// maxRows limits how many boots are shown at once
+	maxRows int
+}
+
+// NewBootPicker creates an empty boot picker.
+func NewBootPicker() *BootPicker {
+	return &BootPicker{maxRows: 15}
+}
+
+// SetBoots replaces the listed boots (given oldest first, as journalctl
+// lists them) and highlights the newest.
+func (b *BootPicker) SetBoots(boots []ingest.Boot) {
+	b.boots = make([]ingest.Boot, len(boots))
+	for i, boot := range boots {
+		b.boots[len(boots)-1-i] = boot
+	}
+	b.selectedIndex = 0
+}
+
+// SetHeight fits the list to the terminal height.
+func (b *BootPicker) SetHeight(height int) {
+	b.maxRows = max(height-8, 3)
+}
+
+// MoveUp moves selection up.
+func (b *BootPicker) MoveUp() {
+	if b.selectedIndex > 0 {
+		b.selectedIndex--
+	}
+}
+
+// MoveDown moves selection down.
+func (b *BootPicker) MoveDown() {
+	if b.selectedIndex < len(b.boots)-1 {
+		b.selectedIndex++
+	}
+}
+
+// Selected returns the highlighted boot, if any.
+func (b *BootPicker) Selected() (ingest.Boot, bool) {
+	if b.selectedIndex >= len(b.boots) {
+		return ingest.Boot{}, false
+	}
+	return b.boots[b.selectedIndex], true
+}
+
+// View renders the boot list.
+func (b *BootPicker) View() string {
+	var content strings.Builder
+
+	content.WriteString(lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ColorPrimary).
+		Render("⏻  Boots"))
+	content.WriteString("\n\n")
+
+	if len(b.boots) == 0 {
+		content.WriteString(lipgloss.NewStyle().
+			Foreground(ColorSecondary).
+			Italic(true).
+			Render("No boots in the journal"))
+		content.WriteString("\n")
+	}
+
+	// Keep the selection in view
+	start := 0
+	if b.selectedIndex >= b.maxRows {
+		start = b.selectedIndex - b.maxRows + 1
+	}
+	end := min(start+b.maxRows, len(b.boots))
+
+	for i := start; i < end; i++ {
+		boot := b.boots[i]
+		line := fmt.Sprintf("%4d  %s  %s – %s",
+			boot.Index,
+			truncateStr(boot.ID, 12),
+			boot.First.Format("Jan 02 15:04"),
+			boot.Last.Format("Jan 02 15:04"))
+		if i == b.selectedIndex {
+			content.WriteString(SourceItemSelectedStyle.Render("▸ " + line))
+		} else {
+			content.WriteString(SourceItemStyle.Render("  " + line))
+		}
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(HelpStyle.Render("Enter to view a boot · Esc to close"))
+
+	return LogDetailFocusedStyle.Render(content.String())
+}
@@ -3,10 +3,13 @@ package tui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/Expert21/argus/internal/ingest"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // LogDetailView displays the full details of a selected log entry.
@@ -122,11 +125,18 @@ func (dv *LogDetailView) View() string {
 				Foreground(ColorSecondary).
 				Render("Metadata:"))
 			content.WriteString("\n")
-			for key, value := range dv.entry.Metadata {
+			// Journal entries carry dozens of fields; list them in a stable order
+			keys := make([]string, 0, len(dv.entry.Metadata))
+			for key := range dv.entry.Metadata {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
 				keyStyle := lipgloss.NewStyle().Foreground(ColorAccent)
-				valStr := fmt.Sprintf("%v", value)
-				if len(valStr) > contentWidth-len(key)-4 {
-					valStr = valStr[:contentWidth-len(key)-7] + "..."
+				valStr := strings.ReplaceAll(dv.entry.Metadata[key], "\n", " ")
+				// Cut by display width: values are often UTF-8 or wide text
+				if room := contentWidth - lipgloss.Width(key) - 4; lipgloss.Width(valStr) > room {
+					valStr = ansi.Truncate(valStr, max(room, 3), "...")
 				}
 				content.WriteString(fmt.Sprintf("  %s: %s\n",
 					keyStyle.Render(key),
@@ -135,10 +145,21 @@ func (dv *LogDetailView) View() string {
 		}
 	}
 
+	// A long stack trace would push the panel past the screen; the
+	// padding and header take three of its lines
+	lines := strings.Split(content.String(), "\n")
+	if room := dv.height - 3; room > 1 && len(lines) > room {
+		more := len(lines) - room + 1
+		lines = append(lines[:room-1], lipgloss.NewStyle().
+			Foreground(ColorSecondary).
+			Italic(true).
+			Render(fmt.Sprintf("… %d more lines", more)))
+	}
+
 	// Combine header and content
 	inner := lipgloss.JoinVertical(lipgloss.Left,
 		header,
-		content.String(),
+		strings.Join(lines, "\n"),
 	)
 
 	// Apply border style
@@ -168,34 +189,61 @@ func (dv *LogDetailView) renderFieldStyled(label, styledValue string) string {
 	return fmt.Sprintf("%s %s", labelStyle.Render(label+":"), styledValue)
 }
 
-// wrapText wraps text to the given width.
+// wrapText wraps text to the given width. Line breaks are kept, so a
+// multi-line entry's stack trace reads as it was written, and a wrapped
+// line keeps its indent.
 func (dv *LogDetailView) wrapText(text string, width int) string {
 	if width <= 0 {
 		return text
 	}
 
 	var result strings.Builder
-	words := strings.Fields(text)
-	lineLen := 0
-
-	for i, word := range words {
-		wordLen := len(word)
-		if lineLen+wordLen+1 > width && lineLen > 0 {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
 			result.WriteString("\n")
+		}
+		wrapLine(&result, line, width)
+	}
+	return result.String()
+}
+
+// wrapLine writes one line wrapped to width, measured in terminal cells
+// so wide CJK and emoji text fits too. Words longer than a line, such as
+// a deep stack frame, are broken.
+func wrapLine(b *strings.Builder, line string, width int) {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+	if len(indent) > width/2 {
+		indent = indent[:width/2]
+	}
+	room := width - len(indent)
+
+	b.WriteString(indent)
+	lineLen := 0
+	for _, word := range strings.Fields(line) {
+		wordLen := ansi.StringWidth(word)
+		if lineLen > 0 && lineLen+1+wordLen > room {
+			b.WriteString("\n" + indent)
 			lineLen = 0
 		}
 		if lineLen > 0 {
-			result.WriteString(" ")
+			b.WriteString(" ")
 			lineLen++
 		}
-		result.WriteString(word)
+		for wordLen > room {
+			head := ansi.Truncate(word, room, "")
+			if head == "" {
+				// A character wider than the room goes on a line of its own
+				_, size := utf8.DecodeRuneInString(word)
+				head = word[:size]
+			}
+			b.WriteString(head + "\n" + indent)
+			word = ansi.TruncateLeft(word, ansi.StringWidth(head), "")
+			wordLen = ansi.StringWidth(word)
+		}
+		b.WriteString(word)
 		lineLen += wordLen
-
-		// Avoid trailing space issues
-		_ = i
 	}
-
-	return result.String()
 }
 
 // min returns the smaller of two integers.
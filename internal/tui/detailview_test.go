@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// TestWrapText tests that wrapped lines fit the panel in terminal cells,
+// whatever the script, and that nothing is lost.
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+	}{
+		{"ascii words", "connection reset by peer while reading response header", 12},
+		{"long word", "com.example.service.handlers.RequestHandler.handle", 10},
+		{"indented frame", "\tat com.example.App.main(App.java:42)", 16},
+		{"cjk", "接続がリセットされました 再試行しています", 10},
+		{"emoji", "deploy 🚀🚀🚀🚀🚀🚀 finished ✅", 7},
+		{"wide character in a narrow pane", "日本", 1},
+	}
+
+	dv := NewLogDetailView()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dv.wrapText(tt.text, tt.width)
+			for _, line := range strings.Split(got, "\n") {
+				// A character wider than the pane can't fit anywhere
+				if w := ansi.StringWidth(line); w > tt.width && len([]rune(strings.TrimSpace(line))) > 1 {
+					t.Errorf("line %q is %d cells wide, want at most %d", line, w, tt.width)
+				}
+			}
+			squash := func(s string) string { return strings.Join(strings.Fields(s), "") }
+			if squash(got) != squash(strings.ReplaceAll(tt.text, "\t", "    ")) {
+				t.Errorf("wrapText() = %q, lost text of %q", got, tt.text)
+			}
+		})
+	}
+}
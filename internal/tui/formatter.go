@@ -281,12 +281,18 @@ func (sb *StatusBar) View() string {
 	left := fmt.Sprintf("%s  %s", statusIndicator, message)
 	right := fmt.Sprintf("%s  │  %s", stats, help)
 
-	spacing := sb.width - lipgloss.Width(left) - lipgloss.Width(right)
+	// Drop the key hints before letting the bar wrap (padding is 2 columns)
+	spacing := sb.width - 2 - lipgloss.Width(left) - lipgloss.Width(right)
+	if spacing < 0 {
+		right = stats
+		spacing = sb.width - 2 - lipgloss.Width(left) - lipgloss.Width(right)
+	}
 	if spacing < 0 {
 		spacing = 0
 	}
 
 	bar := left + strings.Repeat(" ", spacing) + right
 
-	return StatusBarStyle.Width(sb.width).Render(bar)
+	// Margin + one line; never wrap into the panels above
+	return StatusBarStyle.Width(sb.width).MaxHeight(2).Render(bar)
 }
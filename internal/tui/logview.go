@@ -73,6 +73,18 @@ func (lv *LogView) SetFocused(focused bool) {
 	lv.focused = focused
 }
 
+// SetMaxEntries sets how many entries the view keeps in memory.
+func (lv *LogView) SetMaxEntries(n int) {
+	if n <= 0 {
+		return
+	}
+	lv.maxEntries = n
+	if len(lv.entries) > n {
+		lv.entries = lv.entries[len(lv.entries)-n:]
+		lv.updateContent()
+	}
+}
+
 // SetSourceFilter sets the source filter.
 func (lv *LogView) SetSourceFilter(source string) {
 	lv.sourceFilter = source
@@ -82,13 +94,32 @@ func (lv *LogView) SetSourceFilter(source string) {
 	}
 }
 
-// AddEntry adds a new log entry.
+// AddEntry adds a new log entry. Historical entries are placed by
+// timestamp rather than appended, like the aggregator's ring buffer.
 func (lv *LogView) AddEntry(entry ingest.LogEntry) {
-	lv.entries = append(lv.entries, entry)
+	pos := len(lv.entries)
+	if entry.Historical {
+		for pos > 0 && lv.entries[pos-1].Timestamp.After(entry.Timestamp) {
+			pos--
+		}
+	}
+	lv.entries = append(lv.entries, ingest.LogEntry{})
+	copy(lv.entries[pos+1:], lv.entries[pos:])
+	lv.entries[pos] = entry
+
+	// Keep the same entry selected when one is inserted above it
+	if !lv.autoScroll && pos < len(lv.entries)-1 && lv.matchesFilter(entry) && lv.filteredIndex(pos) <= lv.selectedIndex {
+		lv.selectedIndex++
+	}
 
 	// Trim to max entries
-	if len(lv.entries) > lv.maxEntries {
-		lv.entries = lv.entries[len(lv.entries)-lv.maxEntries:]
+	if overflow := len(lv.entries) - lv.maxEntries; overflow > 0 {
+		for _, dropped := range lv.entries[:overflow] {
+			if !lv.autoScroll && lv.matchesFilter(dropped) && lv.selectedIndex > 0 {
+				lv.selectedIndex--
+			}
+		}
+		lv.entries = lv.entries[overflow:]
 	}
 
 	// Auto-select newest entry if auto-scroll enabled
@@ -112,8 +143,7 @@ func (lv *LogView) updateContent() {
 
 	// Build filtered entries list
 	for _, entry := range lv.entries {
-		// Apply source filter (matches on IngestorName, not Source)
-		if lv.sourceFilter != "" && entry.IngestorName != lv.sourceFilter {
+		if !lv.matchesFilter(entry) {
 			continue
 		}
 		lv.filteredEntries = append(lv.filteredEntries, entry)
@@ -145,6 +175,23 @@ func (lv *LogView) updateContent() {
 	lv.ensureSelectedVisible()
 }
 
+// matchesFilter reports whether an entry passes the source filter.
+// The filter matches on IngestorName, not Source.
+func (lv *LogView) matchesFilter(entry ingest.LogEntry) bool {
+	return lv.sourceFilter == "" || entry.IngestorName == lv.sourceFilter
+}
+
+// filteredIndex returns how many entries before position i pass the filter.
+func (lv *LogView) filteredIndex(i int) int {
+	n := 0
+	for _, entry := range lv.entries[:i] {
+		if lv.matchesFilter(entry) {
+			n++
+		}
+	}
+	return n
+}
+
 // formatEntryCompact formats an entry for the compact log list view.
 func (lv *LogView) formatEntryCompact(entry ingest.LogEntry, maxWidth int) string {
 	ts := TimestampStyle.Render(entry.Timestamp.Format("15:04:05"))